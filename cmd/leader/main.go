@@ -2,98 +2,174 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	grpcapi "github.com/clintrovert/khitomer/internal/api/grpc"
 	"github.com/clintrovert/khitomer/internal/api/rest"
+	"github.com/clintrovert/khitomer/internal/artifacts"
+	"github.com/clintrovert/khitomer/internal/auth"
+	"github.com/clintrovert/khitomer/internal/config"
+	"github.com/clintrovert/khitomer/internal/election"
 	"github.com/clintrovert/khitomer/internal/jira"
 	"github.com/clintrovert/khitomer/internal/leader"
+	"github.com/clintrovert/khitomer/internal/metrics"
 	"github.com/clintrovert/khitomer/internal/planner"
 	"github.com/clintrovert/khitomer/internal/temporal"
-	pb "github.com/clintrovert/khitomer/proto"
+	"github.com/clintrovert/khitomer/internal/temporal/workflows"
+	"github.com/clintrovert/khitomer/internal/tracing"
 )
 
 func main() {
+	configPath := flag.String("config", os.Getenv(config.PathEnv), "path to the YAML config file")
+	flag.Parse()
+
 	// Initialize logger
-	logger, err := zap.NewProduction()
+	logLevel := zap.NewAtomicLevel()
+	loggerConfig := zap.NewProductionConfig()
+	loggerConfig.Level = logLevel
+	logger, err := loggerConfig.Build()
 	if err != nil {
 		panic(fmt.Sprintf("failed to create logger: %v", err))
 	}
 	defer logger.Sync()
 
-	// Get configuration from environment
-	temporalAddress := getEnv("TEMPORAL_ADDRESS", "localhost:7233")
-	temporalNamespace := getEnv("TEMPORAL_NAMESPACE", "default")
-	taskQueue := getEnv("TASK_QUEUE", "implementation-queue")
-	jiraBaseURL := getEnv("JIRA_BASE_URL", "")
-	jiraUsername := getEnv("JIRA_USERNAME", "")
-	jiraToken := getEnv("JIRA_TOKEN", "")
-	jiraProjectKey := getEnv("JIRA_PROJECT_KEY", "")
-	jiraCustomField := getEnv("JIRA_CUSTOM_FIELD", "Repository")
-	jiraStatusFilter := getEnv("JIRA_STATUS_FILTER", "Ready for Development")
-	jiraPollInterval := getEnv("JIRA_POLL_INTERVAL", "5m")
-	openaiAPIKey := getEnv("OPENAI_API_KEY", "")
-	openaiModel := getEnv("OPENAI_MODEL", "")
-	restPort := getEnv("REST_PORT", "8080")
-	grpcPort := getEnv("GRPC_PORT", "9090")
-
-	// Parse poll interval
-	pollInterval, err := time.ParseDuration(jiraPollInterval)
+	// Load configuration from the config file and environment
+	cfg := config.DefaultLeader()
+	if err := config.Load(*configPath, cfg); err != nil {
+		logger.Fatal("failed to load configuration", zap.Error(err))
+	}
+	logLevel.SetLevel(mustParseLevel(cfg.LogLevel))
+
+	// Set up tracing before creating traced clients
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Config(), "khitomer-leader")
+	if err != nil {
+		logger.Fatal("failed to set up tracing", zap.Error(err))
+	}
+
+	// Create authenticator
+	authConfig := auth.Config{
+		OIDCIssuer:   cfg.Auth.OIDCIssuer,
+		OIDCAudience: cfg.Auth.OIDCAudience,
+		JWKS:         cfg.Auth.OIDCJWKS,
+		RoleClaim:    cfg.Auth.RoleClaim,
+	}
+	authConfig.APIKeys, err = auth.ParseAPIKeys(cfg.Auth.APIKeys.Value)
+	if err != nil {
+		logger.Fatal("invalid API keys", zap.Error(err))
+	}
+	authConfig.CertificateRoles, err = cfg.Auth.ParseCertificateRoles()
 	if err != nil {
-		logger.Warn("invalid poll interval, using default", zap.Error(err))
-		pollInterval = 5 * time.Minute
+		logger.Fatal("invalid certificate roles", zap.Error(err))
+	}
+	authenticator, err := auth.NewAuthenticator(authConfig, logger)
+	if err != nil {
+		logger.Fatal("failed to create authenticator", zap.Error(err))
+	}
+
+	// Create artifact archive, which is nil unless configured
+	archive, err := artifacts.New(context.Background(), cfg.Artifacts.Config())
+	if err != nil {
+		logger.Fatal("failed to create artifact archive", zap.Error(err))
 	}
 
 	// Create Temporal client
-	temporalClient, err := temporal.NewClient(temporalAddress, temporalNamespace, taskQueue, logger)
+	defaults := temporal.WorkflowDefaults{
+		CommitPerStep: cfg.Workflow.CommitPerStep,
+		Cleanup: workflows.CleanupPolicy{
+			BranchPolicy:  cfg.Workflow.FailedBranchPolicy,
+			TemporalUIURL: cfg.Workflow.TemporalUIURL,
+		},
+		APIURL: cfg.API.PublicURL,
+	}
+	temporalClient, err := temporal.NewClient(cfg.Temporal.Address, cfg.Temporal.Namespace, cfg.Temporal.TaskQueue, defaults, archive, logger)
 	if err != nil {
 		logger.Fatal("failed to create temporal client", zap.Error(err))
 	}
 	defer temporalClient.Close()
 
 	// Create Jira client
-	jiraClient, err := jira.NewClient(jiraBaseURL, jiraUsername, jiraToken, jiraProjectKey, jiraCustomField, logger)
+	jiraClient, err := jira.NewClient(cfg.Jira.BaseURL, cfg.Jira.Username, cfg.Jira.Token.Value, cfg.Jira.RepositoryField, cfg.Jira.APIVersion, logger)
 	if err != nil {
 		logger.Fatal("failed to create jira client", zap.Error(err))
 	}
 
 	// Create Jira poller
-	statusFilter := []string{jiraStatusFilter}
-	jiraPoller := jira.NewPoller(jiraClient, statusFilter, pollInterval, logger)
+	jiraPoller := jira.NewPoller(jiraClient, cfg.Jira.PollerProjects(), cfg.Jira.PollInterval, logger)
 
 	// Create AI planner
-	aiPlanner := planner.NewAIPlanner(openaiAPIKey, openaiModel, logger)
+	aiPlanner := planner.NewAIPlanner(cfg.OpenAI.APIKey.Value, cfg.OpenAI.Model, logger)
+
+	// Create LLM budget
+	budget := leader.NewBudget(temporalClient, cfg.Usage.Config(), logger)
 
 	// Create orchestrator
-	orchestrator := leader.NewOrchestrator(jiraPoller, aiPlanner, temporalClient, logger)
+	orchestrator := leader.NewOrchestrator(jiraPoller, aiPlanner, temporalClient, budget, cfg.Orchestrator.Config(), logger)
+
+	// Create leader elector; only the leader polls Jira and plans tickets
+	var elector election.Elector
+	switch cfg.Election.Backend {
+	case "postgres":
+		elector = election.NewPostgresElector(cfg.Election.PostgresURL.Value, cfg.Election.LockID, cfg.Election.Interval, logger)
+	case "file":
+		elector = election.NewFileElector(cfg.Election.File, cfg.Election.Interval, logger)
+	default:
+		elector = election.Always{}
+	}
+
+	// Create manual trigger and plan previewer
+	trigger := leader.NewTrigger(jiraClient, aiPlanner, temporalClient, budget, logger)
+	previewer := leader.NewPreviewer(jiraClient, aiPlanner, temporalClient, budget, logger)
+
+	// Create the Jira comment commander and, if polling, its comment poller
+	var commander *leader.Commander
+	var commentPoller *jira.CommentPoller
+	if cfg.Commands.Enabled {
+		groupRoles, err := cfg.Commands.ParseGroupRoles()
+		if err != nil {
+			logger.Fatal("invalid group roles", zap.Error(err))
+		}
+		commander = leader.NewCommander(jiraClient, temporalClient, trigger, groupRoles, logger)
+		if cfg.Commands.PollInterval > 0 {
+			commentPoller = jira.NewCommentPoller(jiraClient, cfg.Jira.ProjectKeys(), cfg.Commands.PollInterval, logger)
+		}
+	}
 
 	// Create REST API handler
-	restHandler := rest.NewHandler(temporalClient, logger)
+	restHandler := rest.NewHandler(temporalClient, trigger, previewer, budget, logger)
 
 	// Create gRPC server
-	grpcServer := grpcapi.NewServer(temporalClient, logger)
+	grpcServer := grpcapi.NewServer(temporalClient, trigger, previewer, budget, logger)
 
 	// Setup REST API
 	router := chi.NewRouter()
 	router.Route("/api/v1", func(r chi.Router) {
+		r.Use(authenticator.Middleware, authenticator.AuditMiddleware)
 		restHandler.RegisterRoutes(r)
 	})
+	if commander != nil && cfg.Commands.WebhookSecret.Value != "" {
+		router.Method(http.MethodPost, "/webhooks/jira", rest.NewJiraWebhookHandler(cfg.Commands.WebhookSecret.Value, commander, logger))
+	}
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
+	router.Handle("/metrics", metrics.Handler())
 
 	// Start REST server
-	restAddr := fmt.Sprintf(":%s", restPort)
+	restAddr := fmt.Sprintf(":%s", cfg.API.RESTPort)
 	restServer := &http.Server{
 		Addr:    restAddr,
 		Handler: router,
@@ -107,15 +183,26 @@ func main() {
 	}()
 
 	// Start gRPC server
-	grpcAddr := fmt.Sprintf(":%s", grpcPort)
+	grpcAddr := fmt.Sprintf(":%s", cfg.API.GRPCPort)
 	grpcListener, err := net.Listen("tcp", grpcAddr)
 	if err != nil {
 		logger.Fatal("failed to listen on gRPC port", zap.Error(err))
 	}
 
-	grpcSrv := grpc.NewServer()
+	grpcOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(authenticator.UnaryServerInterceptor(grpcapi.Policies)),
+		grpc.ChainStreamInterceptor(authenticator.StreamServerInterceptor(grpcapi.Policies)),
+	}
+	if cfg.API.GRPCTLSCert != "" {
+		tlsConfig, err := auth.ServerTLSConfig(cfg.API.GRPCTLSCert, cfg.API.GRPCTLSKey, cfg.API.GRPCClientCA)
+		if err != nil {
+			logger.Fatal("failed to configure gRPC TLS", zap.Error(err))
+		}
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	grpcSrv := grpc.NewServer(grpcOpts...)
 	grpcServer.Register(grpcSrv)
-	pb.RegisterLeaderServiceServer(grpcSrv, grpcServer)
 
 	go func() {
 		logger.Info("starting gRPC server", zap.String("address", grpcAddr))
@@ -128,21 +215,77 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	go func() {
-		if err := orchestrator.Start(ctx); err != nil {
-			logger.Error("orchestrator failed", zap.Error(err))
+	// Only the leader polls for comment commands, alongside the orchestrator
+	lead := orchestrator.Start
+	if commentPoller != nil {
+		lead = func(ctx context.Context) error {
+			ctx, cancel := context.WithCancel(ctx)
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				commentPoller.Start(ctx, commander.Handle)
+			}()
+			err := orchestrator.Start(ctx)
+			cancel()
+			wg.Wait()
+			return err
 		}
+	}
+
+	orchestratorDone := make(chan struct{})
+	go func() {
+		defer close(orchestratorDone)
+		election.Run(ctx, elector, lead, logger)
 	}()
 
+	// Reload settings that can change at runtime on SIGHUP
+	reload := func() {
+		updated := config.DefaultLeader()
+		if err := config.Load(*configPath, updated); err != nil {
+			logger.Error("failed to reload configuration, keeping current settings", zap.Error(err))
+			return
+		}
+		if changed := config.RestartRequired(cfg, updated); len(changed) > 0 {
+			logger.Warn("ignoring changed settings that require a restart", zap.Strings("settings", changed))
+		}
+
+		logLevel.SetLevel(mustParseLevel(updated.LogLevel))
+		jiraPoller.SetProjects(updated.Jira.PollerProjects(), updated.Jira.PollInterval)
+		orchestrator.SetConfig(updated.Orchestrator.Config())
+		budget.SetConfig(updated.Usage.Config())
+		if commentPoller != nil {
+			commentPoller.SetProjects(updated.Jira.ProjectKeys(), cfg.Commands.PollInterval)
+		}
+		if commander != nil {
+			groupRoles, _ := updated.Commands.ParseGroupRoles()
+			commander.SetGroupRoles(groupRoles)
+		}
+
+		cfg.LogLevel = updated.LogLevel
+		cfg.Jira.Projects = updated.Jira.Projects
+		cfg.Jira.PollInterval = updated.Jira.PollInterval
+		cfg.Orchestrator = updated.Orchestrator
+		cfg.Usage = updated.Usage
+		cfg.Commands.GroupRoles = updated.Commands.GroupRoles
+		logger.Info("reloaded configuration")
+	}
+
 	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	<-sigChan
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		reload()
+	}
 
 	logger.Info("shutting down")
 
-	// Shutdown orchestrator
+	// Shutdown orchestrator, letting in-flight tasks drain
 	cancel()
+	<-orchestratorDone
 
 	// Shutdown servers
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -151,12 +294,18 @@ func main() {
 	restServer.Shutdown(shutdownCtx)
 	grpcSrv.GracefulStop()
 
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Warn("failed to flush traces", zap.Error(err))
+	}
+
 	logger.Info("shutdown complete")
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// mustParseLevel parses a log level that has already been validated
+func mustParseLevel(level string) zapcore.Level {
+	l, err := zapcore.ParseLevel(level)
+	if err != nil {
+		panic(err)
 	}
-	return defaultValue
+	return l
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"go.uber.org/zap"
+
+	workflows "github.com/clintrovert/khitomer/internal/temporal/workflows"
+)
+
+// replay checks that the checked-in workflow histories still replay against
+// the current workflow code, exiting non-zero if any of them doesn't
+func main() {
+	dir := flag.String("dir", workflows.HistoriesDir, "directory of JSON workflow histories")
+	flag.Parse()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Sync()
+
+	count, err := workflows.ReplayHistories(*dir, logger)
+	if err != nil {
+		logger.Fatal("workflow replay failed",
+			zap.Int("histories", count),
+			zap.Error(err),
+		)
+	}
+	if count == 0 {
+		logger.Warn("no workflow histories to replay", zap.String("dir", *dir))
+		return
+	}
+
+	logger.Info("all workflow histories replayed", zap.Int("histories", count))
+}
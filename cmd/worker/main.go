@@ -1,108 +1,224 @@
 package main
 
 import (
-	"log"
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
 	"go.temporal.io/sdk/worker"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	"github.com/clintrovert/khitomer/internal/activities"
+	"github.com/clintrovert/khitomer/internal/artifacts"
+	"github.com/clintrovert/khitomer/internal/config"
+	"github.com/clintrovert/khitomer/internal/deploy"
 	"github.com/clintrovert/khitomer/internal/github"
+	"github.com/clintrovert/khitomer/internal/guardrails"
 	"github.com/clintrovert/khitomer/internal/jira"
+	"github.com/clintrovert/khitomer/internal/metrics"
+	"github.com/clintrovert/khitomer/internal/scan"
 	workflows "github.com/clintrovert/khitomer/internal/temporal/workflows"
+	"github.com/clintrovert/khitomer/internal/tracing"
 )
 
 func main() {
+	configPath := flag.String("config", os.Getenv(config.PathEnv), "path to the YAML config file")
+	flag.Parse()
+
 	// Initialize logger
-	logger, err := zap.NewProduction()
+	logLevel := zap.NewAtomicLevel()
+	loggerConfig := zap.NewProductionConfig()
+	loggerConfig.Level = logLevel
+	logger, err := loggerConfig.Build()
 	if err != nil {
-		log.Fatalf("failed to create logger: %v", err)
+		panic(fmt.Sprintf("failed to create logger: %v", err))
 	}
 	defer logger.Sync()
 
-	// Get configuration from environment
-	temporalAddress := getEnv("TEMPORAL_ADDRESS", "localhost:7233")
-	temporalNamespace := getEnv("TEMPORAL_NAMESPACE", "default")
-	taskQueue := getEnv("TASK_QUEUE", "implementation-queue")
-	githubToken := getEnv("GITHUB_TOKEN", "")
-	workspaceDir := getEnv("WORKSPACE_DIR", "/tmp/khitomer-workspace")
-	jiraBaseURL := getEnv("JIRA_BASE_URL", "")
-	jiraUsername := getEnv("JIRA_USERNAME", "")
-	jiraToken := getEnv("JIRA_TOKEN", "")
+	// Load configuration from the config file and environment
+	cfg := config.DefaultWorker()
+	if err := config.Load(*configPath, cfg); err != nil {
+		logger.Fatal("failed to load configuration", zap.Error(err))
+	}
+	logLevel.SetLevel(mustParseLevel(cfg.LogLevel))
+
+	// Set up tracing; the Temporal interceptor continues traces started by
+	// the leader in workflows and activities run by this worker
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Config(), "khitomer-worker")
+	if err != nil {
+		logger.Fatal("failed to set up tracing", zap.Error(err))
+	}
+	tracingInterceptor, err := tracing.NewTemporalInterceptor()
+	if err != nil {
+		logger.Fatal("failed to create tracing interceptor", zap.Error(err))
+	}
 
 	// Create Temporal client
 	c, err := client.Dial(client.Options{
-		HostPort:  temporalAddress,
-		Namespace: temporalNamespace,
+		HostPort:       cfg.Temporal.Address,
+		Namespace:      cfg.Temporal.Namespace,
+		MetricsHandler: metrics.NewTemporalHandler(),
+		Interceptors:   []interceptor.ClientInterceptor{tracingInterceptor},
 	})
 	if err != nil {
 		logger.Fatal("failed to create temporal client", zap.Error(err))
 	}
 	defer c.Close()
 
+	// Create pre-commit scanner
+	scanner := scan.NewScanner(cfg.Scan.Config())
+
+	// Create guardrail checker
+	guardrailChecker := guardrails.NewChecker(cfg.Guardrails.Config())
+
+	// Configure commit identity, message template and signing
+	commitConfig := github.CommitConfig{
+		Author:    github.Identity{Name: cfg.Commit.AuthorName, Email: cfg.Commit.AuthorEmail},
+		Committer: github.Identity{Name: cfg.Commit.CommitterName, Email: cfg.Commit.CommitterEmail},
+		Scanner:   scanner,
+	}
+	if cfg.Commit.MessageTemplate != "" {
+		commitConfig.MessageTemplate, err = github.LoadCommitMessageTemplate(cfg.Commit.MessageTemplate)
+		if err != nil {
+			logger.Fatal("failed to load commit message template", zap.Error(err))
+		}
+	}
+	if cfg.Commit.SigningFormat != "" {
+		commitConfig.Signer, err = github.NewSigner(cfg.Commit.SigningFormat, cfg.Commit.SigningKey, cfg.Commit.SigningPassphrase.Value)
+		if err != nil {
+			logger.Fatal("failed to create commit signer", zap.Error(err))
+		}
+	}
+
 	// Create GitHub client
-	githubClient := github.NewClient(githubToken, workspaceDir, logger)
-	
+	githubClient := github.NewClient(cfg.GitHub.Token.Value, cfg.GitHub.WorkspaceDir, commitConfig, logger)
+
 	// Create Jira client (for updating Jira)
 	var jiraClient *jira.Client
-	if jiraBaseURL != "" && jiraUsername != "" && jiraToken != "" {
-		jiraClient, err = jira.NewClient(jiraBaseURL, jiraUsername, jiraToken, "", "", logger)
+	if cfg.Jira.BaseURL != "" {
+		jiraClient, err = jira.NewClient(cfg.Jira.BaseURL, cfg.Jira.Username, cfg.Jira.Token.Value, "", cfg.Jira.APIVersion, logger)
 		if err != nil {
 			logger.Warn("failed to create jira client", zap.Error(err))
 		}
 	}
 
-	// Initialize activities
-	githubActivities := activities.NewGitHubActivities(githubClient, logger)
-	activities.SetGitHubActivities(githubActivities)
+	// Create artifact archive, which is nil unless configured
+	archive, err := artifacts.New(context.Background(), cfg.Artifacts.Config())
+	if err != nil {
+		logger.Fatal("failed to create artifact archive", zap.Error(err))
+	}
 
+	// Initialize activities; Jira and deploy activities are only available when configured
+	acts := &activities.Activities{
+		GitHub:     activities.NewGitHubActivities(githubClient, logger),
+		Testing:    activities.NewTestingActivities(cfg.Testing.CoverageThreshold, logger),
+		Guardrails: activities.NewGuardrailActivities(guardrailChecker, logger),
+		Archive:    archive,
+	}
 	if jiraClient != nil {
-		jiraActivities := activities.NewJiraActivities(jiraClient, logger)
-		activities.SetJiraActivities(jiraActivities)
+		acts.Jira = activities.NewJiraActivities(jiraClient, logger)
+	}
+	if cfg.Deploy.Script != "" {
+		acts.Deploy = activities.NewDeployActivities(deploy.NewScriptDeployer(cfg.Deploy.Script), logger)
 	}
 
 	// Create worker
-	w := worker.New(c, taskQueue, worker.Options{})
+	w := worker.New(c, cfg.Temporal.TaskQueue, worker.Options{
+		Interceptors: []interceptor.WorkerInterceptor{metrics.NewWorkerInterceptor()},
+	})
 
-	// Register workflow
-	w.RegisterWorkflow(workflows.ImplementationWorkflow)
+	// Register workflows
+	workflows.Register(w)
 
 	// Register activities
-	w.RegisterActivity(activities.CloneRepositoryActivity)
-	w.RegisterActivity(activities.CreateBranchActivity)
-	w.RegisterActivity(activities.CodeGenerationActivity)
-	w.RegisterActivity(activities.TestingActivity)
-	w.RegisterActivity(activities.CommitChangesActivity)
-	w.RegisterActivity(activities.CreatePRActivity)
-	w.RegisterActivity(activities.UpdateJiraActivity)
+	w.RegisterActivity(acts)
 
 	// Start worker
 	logger.Info("starting worker",
-		zap.String("task_queue", taskQueue),
-		zap.String("namespace", temporalNamespace),
+		zap.String("task_queue", cfg.Temporal.TaskQueue),
+		zap.String("namespace", cfg.Temporal.Namespace),
 	)
 
-	err = w.Run(worker.InterruptCh())
-	if err != nil {
+	if err := w.Start(); err != nil {
 		logger.Fatal("worker failed", zap.Error(err))
 	}
 
-	// Handle shutdown
+	// Start metrics server
+	var metricsServer *http.Server
+	if cfg.Metrics.Port != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		metricsServer = &http.Server{
+			Addr:    fmt.Sprintf(":%s", cfg.Metrics.Port),
+			Handler: mux,
+		}
+
+		go func() {
+			logger.Info("starting metrics server", zap.String("address", metricsServer.Addr))
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Fatal("failed to start metrics server", zap.Error(err))
+			}
+		}()
+	}
+
+	// Reload settings that can change at runtime on SIGHUP
+	reload := func() {
+		updated := config.DefaultWorker()
+		if err := config.Load(*configPath, updated); err != nil {
+			logger.Error("failed to reload configuration, keeping current settings", zap.Error(err))
+			return
+		}
+		if changed := config.RestartRequired(cfg, updated); len(changed) > 0 {
+			logger.Warn("ignoring changed settings that require a restart", zap.Strings("settings", changed))
+		}
+
+		logLevel.SetLevel(mustParseLevel(updated.LogLevel))
+		acts.Testing.SetCoverageThreshold(updated.Testing.CoverageThreshold)
+		acts.Guardrails.SetChecker(guardrails.NewChecker(updated.Guardrails.Config()))
+
+		cfg.LogLevel = updated.LogLevel
+		cfg.Testing = updated.Testing
+		cfg.Guardrails = updated.Guardrails
+		logger.Info("reloaded configuration")
+	}
+
+	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	<-sigChan
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		reload()
+	}
 
 	logger.Info("shutting down worker")
-}
+	w.Stop()
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+
+	if metricsServer != nil {
+		metricsServer.Shutdown(shutdownCtx)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Warn("failed to flush traces", zap.Error(err))
 	}
-	return defaultValue
 }
 
+// mustParseLevel parses a log level that has already been validated
+func mustParseLevel(level string) zapcore.Level {
+	l, err := zapcore.ParseLevel(level)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
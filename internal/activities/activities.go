@@ -0,0 +1,162 @@
+package activities
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/clintrovert/khitomer/internal/artifacts"
+	"github.com/clintrovert/khitomer/internal/deploy"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// Activities holds the dependencies of every activity and is registered with
+// the Temporal worker as a whole:
+//
+//	w.RegisterActivity(&activities.Activities{GitHub: ga, Jira: ja, ...})
+//
+// Workflows reference activities through a nil *Activities, e.g.
+// workflow.ExecuteActivity(ctx, a.CloneRepositoryActivity, repo). A nil
+// dependency fails its activities with a non-retryable application error.
+type Activities struct {
+	GitHub     *GitHubActivities
+	Jira       *JiraActivities
+	Testing    *TestingActivities
+	Guardrails *GuardrailActivities
+	Deploy     *DeployActivities
+	// Archive keeps codegen diffs and test reports; nil archives nothing
+	Archive *artifacts.Archive
+}
+
+// notConfigured returns a non-retryable error for a missing dependency
+func notConfigured(dependency string) error {
+	return temporal.NewNonRetryableApplicationError(dependency+" activities not configured on this worker", ErrTypeNotConfigured, nil)
+}
+
+// CloneRepositoryActivity is the activity function for cloning repositories
+func (a *Activities) CloneRepositoryActivity(ctx context.Context, repo *types.RepositoryInfo) (GitHubOperationResult, error) {
+	if a.GitHub == nil {
+		return GitHubOperationResult{}, notConfigured("GitHub")
+	}
+	return a.GitHub.CloneRepositoryActivity(ctx, repo)
+}
+
+// CreateBranchActivity is the activity function for creating branches
+func (a *Activities) CreateBranchActivity(ctx context.Context, repo *types.RepositoryInfo, branchName string) (GitHubOperationResult, error) {
+	if a.GitHub == nil {
+		return GitHubOperationResult{}, notConfigured("GitHub")
+	}
+	return a.GitHub.CreateBranchActivity(ctx, repo, branchName)
+}
+
+// TestingActivity is the activity function for running tests. The report is
+// archived for the plan step the tests ran at, or step 0 for the final run.
+func (a *Activities) TestingActivity(ctx context.Context, repoPath, baseBranch string, stepOrder int) (TestingResult, error) {
+	if a.Testing == nil {
+		return TestingResult{}, notConfigured("Testing")
+	}
+	result, err := a.Testing.TestingActivity(ctx, repoPath, baseBranch)
+	if err == nil {
+		a.archiveJSON(ctx, artifacts.TestReportName(stepOrder, activity.GetInfo(ctx).Attempt), result)
+	}
+	return result, err
+}
+
+// CheckGuardrailsActivity is the activity function for enforcing guardrails
+func (a *Activities) CheckGuardrailsActivity(ctx context.Context, repoPath, baseBranch string) (GuardrailResult, error) {
+	if a.Guardrails == nil {
+		return GuardrailResult{}, notConfigured("Guardrail")
+	}
+	return a.Guardrails.CheckGuardrailsActivity(ctx, repoPath, baseBranch)
+}
+
+// CommitChangesActivity is the activity function for committing changes
+func (a *Activities) CommitChangesActivity(ctx context.Context, repo *types.RepositoryInfo, repoPath string, message types.CommitMessage) (GitHubOperationResult, error) {
+	if a.GitHub == nil {
+		return GitHubOperationResult{}, notConfigured("GitHub")
+	}
+	return a.GitHub.CommitChangesActivity(ctx, repo, repoPath, message)
+}
+
+// PushBranchActivity is the activity function for pushing branches
+func (a *Activities) PushBranchActivity(ctx context.Context, repo *types.RepositoryInfo, repoPath string) (GitHubOperationResult, error) {
+	if a.GitHub == nil {
+		return GitHubOperationResult{}, notConfigured("GitHub")
+	}
+	return a.GitHub.PushBranchActivity(ctx, repo, repoPath)
+}
+
+// CreatePRActivity is the activity function for creating pull requests
+func (a *Activities) CreatePRActivity(ctx context.Context, repo *types.RepositoryInfo, title, description string, draft bool, labels []string) (GitHubOperationResult, error) {
+	if a.GitHub == nil {
+		return GitHubOperationResult{}, notConfigured("GitHub")
+	}
+	return a.GitHub.CreatePRActivity(ctx, repo, title, description, draft, labels)
+}
+
+// UpdateJiraActivity is the activity function for updating Jira
+func (a *Activities) UpdateJiraActivity(ctx context.Context, ticketID, prURL string, usage *types.UsageTotals, summary string) (JiraUpdateResult, error) {
+	if a.Jira == nil {
+		return JiraUpdateResult{}, notConfigured("Jira")
+	}
+	return a.Jira.UpdateJiraActivity(ctx, ticketID, prURL, usage, summary)
+}
+
+// AddJiraCommentActivity is the activity function for commenting on Jira
+func (a *Activities) AddJiraCommentActivity(ctx context.Context, ticketID, comment string) (JiraUpdateResult, error) {
+	if a.Jira == nil {
+		return JiraUpdateResult{}, notConfigured("Jira")
+	}
+	return a.Jira.AddCommentActivity(ctx, ticketID, comment)
+}
+
+// DeleteBranchActivity is the activity function for deleting feature branches
+func (a *Activities) DeleteBranchActivity(ctx context.Context, repo *types.RepositoryInfo) (GitHubOperationResult, error) {
+	if a.GitHub == nil {
+		return GitHubOperationResult{}, notConfigured("GitHub")
+	}
+	return a.GitHub.DeleteBranchActivity(ctx, repo)
+}
+
+// RemoveWorkspaceActivity is the activity function for removing cloned repositories
+func (a *Activities) RemoveWorkspaceActivity(ctx context.Context, repo *types.RepositoryInfo) (GitHubOperationResult, error) {
+	if a.GitHub == nil {
+		return GitHubOperationResult{}, notConfigured("GitHub")
+	}
+	return a.GitHub.RemoveWorkspaceActivity(ctx, repo)
+}
+
+// ClonePreviewActivity is the activity function for cloning repositories into
+// throwaway workspaces
+func (a *Activities) ClonePreviewActivity(ctx context.Context, repo *types.RepositoryInfo) (GitHubOperationResult, error) {
+	if a.GitHub == nil {
+		return GitHubOperationResult{}, notConfigured("GitHub")
+	}
+	return a.GitHub.ClonePreviewActivity(ctx, repo)
+}
+
+// RemovePreviewActivity is the activity function for removing throwaway
+// workspaces
+func (a *Activities) RemovePreviewActivity(ctx context.Context, repoPath string) (GitHubOperationResult, error) {
+	if a.GitHub == nil {
+		return GitHubOperationResult{}, notConfigured("GitHub")
+	}
+	return a.GitHub.RemovePreviewActivity(ctx, repoPath)
+}
+
+// RestoreJiraStatusActivity is the activity function for restoring Jira statuses
+func (a *Activities) RestoreJiraStatusActivity(ctx context.Context, ticketID, status string) (JiraUpdateResult, error) {
+	if a.Jira == nil {
+		return JiraUpdateResult{}, notConfigured("Jira")
+	}
+	return a.Jira.RestoreStatusActivity(ctx, ticketID, status)
+}
+
+// DeployActivity is the activity function for deploying changes
+func (a *Activities) DeployActivity(ctx context.Context, req deploy.Request) (DeploymentResult, error) {
+	if a.Deploy == nil {
+		return DeploymentResult{}, notConfigured("Deploy")
+	}
+	return a.Deploy.DeployActivity(ctx, req)
+}
@@ -0,0 +1,70 @@
+package activities
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.temporal.io/sdk/activity"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/artifacts"
+)
+
+// archive stores an artifact of the activity's workflow run. Archiving is
+// best effort: failures are logged and the activity carries on.
+func (a *Activities) archive(ctx context.Context, name string, data []byte) {
+	if a.Archive == nil {
+		return
+	}
+	info := activity.GetInfo(ctx)
+	run := artifacts.Run{WorkflowID: info.WorkflowExecution.ID, RunID: info.WorkflowExecution.RunID}
+	if err := a.Archive.Put(ctx, run, name, data); err != nil {
+		activity.GetLogger(ctx).Warn("failed to archive artifact", zap.String("name", name), zap.Error(err))
+	}
+}
+
+// archiveJSON stores v as an indented JSON artifact
+func (a *Activities) archiveJSON(ctx context.Context, name string, v interface{}) {
+	if a.Archive == nil {
+		return
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		activity.GetLogger(ctx).Warn("failed to encode artifact", zap.String("name", name), zap.Error(err))
+		return
+	}
+	a.archive(ctx, name, data)
+}
+
+// snapshot returns the worktree's tree hash before the activity changes it,
+// or "" if nothing is archived
+func (a *Activities) snapshot(ctx context.Context, repoPath string) string {
+	if a.Archive == nil {
+		return ""
+	}
+	tree, err := snapshotTree(ctx, repoPath)
+	if err != nil {
+		activity.GetLogger(ctx).Warn("failed to snapshot worktree", zap.Error(err))
+		return ""
+	}
+	return tree
+}
+
+// archiveDiff stores the changes made to the worktree since the snapshot
+// before was taken
+func (a *Activities) archiveDiff(ctx context.Context, repoPath, before, name string) {
+	if before == "" {
+		return
+	}
+	after, err := snapshotTree(ctx, repoPath)
+	if err != nil {
+		activity.GetLogger(ctx).Warn("failed to snapshot worktree", zap.Error(err))
+		return
+	}
+	diff, err := treeDiff(ctx, repoPath, before, after)
+	if err != nil {
+		activity.GetLogger(ctx).Warn("failed to compute diff", zap.Error(err))
+		return
+	}
+	a.archive(ctx, name, diff)
+}
@@ -0,0 +1,58 @@
+package activities
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/deploy"
+)
+
+// DeployActivities handles deployment activities
+type DeployActivities struct {
+	deployer deploy.Deployer
+	logger   *zap.Logger
+}
+
+// NewDeployActivities creates a new deployment activities handler
+func NewDeployActivities(deployer deploy.Deployer, logger *zap.Logger) *DeployActivities {
+	return &DeployActivities{
+		deployer: deployer,
+		logger:   logger,
+	}
+}
+
+// DeployActivity deploys the worktree with the configured deployer
+func (a *DeployActivities) DeployActivity(ctx context.Context, req deploy.Request) (DeploymentResult, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("deploying",
+		zap.String("jira_ticket", req.JiraKey),
+		zap.String("branch", req.Branch),
+		zap.Int("step", req.StepOrder),
+	)
+
+	var result *deploy.Result
+	err := withHeartbeat(ctx, "deploy", func() error {
+		var err error
+		result, err = a.deployer.Deploy(ctx, req)
+		return err
+	})
+	if err != nil {
+		var output string
+		if result != nil {
+			output = result.Output
+		}
+		return DeploymentResult{Success: false, Output: output}, err
+	}
+
+	logger.Info("deployed",
+		zap.String("url", result.URL),
+	)
+
+	return DeploymentResult{
+		Success: true,
+		URL:     result.URL,
+		Output:  result.Output,
+	}, nil
+}
@@ -0,0 +1,130 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/clintrovert/khitomer/internal/guardrails"
+)
+
+// diffStats returns per-file line counts for the worktree relative to the
+// base branch, counting every line of an untracked file as added
+func diffStats(ctx context.Context, repoPath, baseBranch string) ([]guardrails.FileChange, error) {
+	numstat, err := runGit(ctx, repoPath, "diff", "--numstat", "--no-renames", "-z", baseRef(baseBranch))
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []guardrails.FileChange
+	// With -z each record is "added\tremoved\tpath\x00"
+	for _, record := range strings.Split(string(numstat), "\x00") {
+		fields := strings.SplitN(record, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		// Binary files report "-" for both counts
+		added, _ := strconv.Atoi(fields[0])
+		removed, _ := strconv.Atoi(fields[1])
+		changes = append(changes, guardrails.FileChange{
+			Path:         fields[2],
+			LinesAdded:   added,
+			LinesRemoved: removed,
+		})
+	}
+
+	untracked, err := runGit(ctx, repoPath, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range strings.Split(string(untracked), "\x00") {
+		if file == "" {
+			continue
+		}
+		lines, err := countFileLines(filepath.Join(repoPath, file))
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, guardrails.FileChange{
+			Path:       file,
+			LinesAdded: lines,
+		})
+	}
+
+	return changes, nil
+}
+
+// countFileLines counts the lines in a file, including an unterminated last line
+func countFileLines(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	lines := bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		lines++
+	}
+	return lines, nil
+}
+
+// baseRef returns the ref to diff against; the clone keeps a local copy of the
+// base branch, so per-step commits on the feature branch are included
+func baseRef(baseBranch string) string {
+	if baseBranch == "" {
+		return "HEAD"
+	}
+	return "refs/heads/" + baseBranch
+}
+
+// snapshotTree writes the worktree, including untracked files, as a tree
+// object and returns its hash. A scratch index is used so the repository's
+// own index is left alone.
+func snapshotTree(ctx context.Context, repoPath string) (string, error) {
+	dir, err := os.MkdirTemp("", "khitomer-index-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create index directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(dir, "index")}
+
+	if _, err := runGitEnv(ctx, repoPath, env, "read-tree", "HEAD"); err != nil {
+		return "", err
+	}
+	if _, err := runGitEnv(ctx, repoPath, env, "add", "--all"); err != nil {
+		return "", err
+	}
+	tree, err := runGitEnv(ctx, repoPath, env, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(tree)), nil
+}
+
+// treeDiff returns the unified diff between two trees
+func treeDiff(ctx context.Context, repoPath, from, to string) ([]byte, error) {
+	return runGit(ctx, repoPath, "diff", "--no-color", "--no-ext-diff", from, to)
+}
+
+func runGit(ctx context.Context, repoPath string, args ...string) ([]byte, error) {
+	return runGitEnv(ctx, repoPath, nil, args...)
+}
+
+// runGitEnv runs git with extra environment variables
+func runGitEnv(ctx context.Context, repoPath string, env []string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git %s: %w", args[0], err)
+	}
+	return output, nil
+}
@@ -0,0 +1,43 @@
+package activities
+
+import (
+	"errors"
+
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/clintrovert/khitomer/internal/github"
+	"github.com/clintrovert/khitomer/internal/jira"
+)
+
+// Application error types returned by activities. Workflows list the ones
+// retrying cannot fix in their retry policies' NonRetryableErrorTypes.
+const (
+	// ErrTypeNotConfigured is returned when an activity runs on a worker that
+	// lacks the dependency it needs
+	ErrTypeNotConfigured = "ActivityNotConfigured"
+	// ErrTypeAuthFailed is returned when GitHub or Jira rejects the credentials
+	ErrTypeAuthFailed = "AuthenticationFailed"
+	// ErrTypeNotFound is returned when a repository or issue does not exist
+	ErrTypeNotFound = "NotFound"
+	// ErrTypePullRequestExists is returned when the feature branch already has
+	// an open pull request
+	ErrTypePullRequestExists = "PullRequestAlreadyExists"
+	// ErrTypeSensitiveContent is returned when staged changes contain secrets
+	// or disallowed files
+	ErrTypeSensitiveContent = "SensitiveContentDetected"
+)
+
+// applicationError converts client errors with a known cause into typed
+// application errors so retry policies can match on them. Other errors are
+// returned unchanged and retried.
+func applicationError(err error) error {
+	switch {
+	case errors.Is(err, github.ErrAuthFailed), errors.Is(err, jira.ErrAuthFailed):
+		return temporal.NewApplicationErrorWithCause(err.Error(), ErrTypeAuthFailed, err)
+	case errors.Is(err, github.ErrRepositoryNotFound), errors.Is(err, jira.ErrIssueNotFound):
+		return temporal.NewApplicationErrorWithCause(err.Error(), ErrTypeNotFound, err)
+	case errors.Is(err, github.ErrPullRequestExists):
+		return temporal.NewApplicationErrorWithCause(err.Error(), ErrTypePullRequestExists, err)
+	}
+	return err
+}
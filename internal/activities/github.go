@@ -2,11 +2,16 @@ package activities
 
 import (
 	"context"
+	"errors"
+	"strconv"
 
 	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
 	"go.uber.org/zap"
 
 	"github.com/clintrovert/khitomer/internal/github"
+	"github.com/clintrovert/khitomer/internal/metrics"
+	"github.com/clintrovert/khitomer/internal/scan"
 	"github.com/clintrovert/khitomer/pkg/types"
 )
 
@@ -32,9 +37,16 @@ func (a *GitHubActivities) CloneRepositoryActivity(ctx context.Context, repo *ty
 		zap.String("name", repo.Name),
 	)
 
-	repoPath, err := a.githubClient.CloneRepository(ctx, repo.Owner, repo.Name, repo.BaseBranch)
+	// Git progress doubles as the heartbeat so stalled clones are detected.
+	// Checkout reports no progress, so a ticker heartbeats through it.
+	progress := &progressWriter{ctx: ctx, stage: "clone"}
+	var repoPath string
+	err := withHeartbeat(ctx, "clone", func() (err error) {
+		repoPath, err = a.githubClient.CloneRepository(ctx, repo.Owner, repo.Name, repo.BaseBranch, progress)
+		return err
+	})
 	if err != nil {
-		return GitHubOperationResult{Success: false, Message: err.Error()}, err
+		return GitHubOperationResult{Success: false, Message: err.Error()}, applicationError(err)
 	}
 
 	return GitHubOperationResult{
@@ -57,7 +69,7 @@ func (a *GitHubActivities) CreateBranchActivity(ctx context.Context, repo *types
 
 	err := a.githubClient.CreateBranch(repoPath, repo.BaseBranch, branchName)
 	if err != nil {
-		return GitHubOperationResult{Success: false, Message: err.Error()}, err
+		return GitHubOperationResult{Success: false, Message: err.Error()}, applicationError(err)
 	}
 
 	return GitHubOperationResult{
@@ -67,48 +79,173 @@ func (a *GitHubActivities) CreateBranchActivity(ctx context.Context, repo *types
 	}, nil
 }
 
-// CommitChangesActivity commits changes to the repository
-func (a *GitHubActivities) CommitChangesActivity(ctx context.Context, repo *types.RepositoryInfo, repoPath, message string) (GitHubOperationResult, error) {
+// CommitChangesActivity commits changes to the repository. The commit is
+// local until PushBranchActivity runs.
+func (a *GitHubActivities) CommitChangesActivity(ctx context.Context, repo *types.RepositoryInfo, repoPath string, message types.CommitMessage) (GitHubOperationResult, error) {
 	logger := activity.GetLogger(ctx)
 	logger.Info("committing changes",
 		zap.String("repo", repo.Name),
-		zap.String("message", message),
+		zap.String("subject", message.Subject),
 	)
 
-	err := a.githubClient.CommitChanges(repoPath, message)
+	sha, err := a.githubClient.CommitChanges(repoPath, message)
 	if err != nil {
-		return GitHubOperationResult{Success: false, Message: err.Error()}, err
+		// Retrying won't remove sensitive content, so fail the run immediately
+		var findingsErr *scan.FindingsError
+		if errors.As(err, &findingsErr) {
+			logger.Error("sensitive content detected, refusing to commit",
+				zap.Int("findings", len(findingsErr.Findings)),
+			)
+			return GitHubOperationResult{Success: false, Message: err.Error()},
+				temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeSensitiveContent, err)
+		}
+		return GitHubOperationResult{Success: false, Message: err.Error()}, applicationError(err)
+	}
+
+	if sha == "" {
+		return GitHubOperationResult{
+			Success: true,
+			Message: "no changes to commit",
+		}, nil
 	}
 
-	// Push the branch
-	err = a.githubClient.PushBranch(ctx, repoPath, repo.FeatureBranch)
+	return GitHubOperationResult{
+		Success:   true,
+		Message:   "changes committed successfully",
+		CommitSHA: sha,
+	}, nil
+}
+
+// PushBranchActivity pushes the feature branch to GitHub
+func (a *GitHubActivities) PushBranchActivity(ctx context.Context, repo *types.RepositoryInfo, repoPath string) (GitHubOperationResult, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("pushing branch",
+		zap.String("repo", repo.Name),
+		zap.String("branch", repo.FeatureBranch),
+	)
+
+	err := a.githubClient.PushBranch(ctx, repoPath, repo.FeatureBranch)
 	if err != nil {
-		return GitHubOperationResult{Success: false, Message: err.Error()}, err
+		return GitHubOperationResult{Success: false, Message: err.Error()}, applicationError(err)
 	}
 
 	return GitHubOperationResult{
-		Success: true,
-		Message: "changes committed and pushed successfully",
+		Success:    true,
+		Message:    "branch pushed successfully",
+		BranchName: repo.FeatureBranch,
 	}, nil
 }
 
 // CreatePRActivity creates a pull request
-func (a *GitHubActivities) CreatePRActivity(ctx context.Context, repo *types.RepositoryInfo, title, description string) (GitHubOperationResult, error) {
+func (a *GitHubActivities) CreatePRActivity(ctx context.Context, repo *types.RepositoryInfo, title, description string, draft bool, labels []string) (GitHubOperationResult, error) {
 	logger := activity.GetLogger(ctx)
 	logger.Info("creating pull request",
 		zap.String("repo", repo.Name),
 		zap.String("title", title),
+		zap.Bool("draft", draft),
+		zap.Strings("labels", labels),
 	)
 
-	prInfo, err := a.githubClient.CreatePullRequest(ctx, repo.Owner, repo.Name, repo.BaseBranch, repo.FeatureBranch, title, description)
+	prInfo, err := a.githubClient.CreatePullRequest(ctx, repo.Owner, repo.Name, repo.BaseBranch, repo.FeatureBranch, title, description, draft)
 	if err != nil {
-		return GitHubOperationResult{Success: false, Message: err.Error()}, err
+		return GitHubOperationResult{Success: false, Message: err.Error()}, applicationError(err)
+	}
+	metrics.PullRequestsCreated.WithLabelValues(strconv.FormatBool(draft)).Inc()
+
+	if len(labels) > 0 {
+		// Labels are advisory; the PR is still usable without them
+		if err := a.githubClient.AddLabels(ctx, repo.Owner, repo.Name, int(prInfo.PRNumber), labels); err != nil {
+			logger.Warn("failed to label pull request", zap.Error(err))
+		}
 	}
 
 	return GitHubOperationResult{
 		Success: true,
 		Message: "pull request created successfully",
-		PRInfo:   prInfo,
+		PRInfo:  prInfo,
+	}, nil
+}
+
+// DeleteBranchActivity deletes the feature branch from GitHub
+func (a *GitHubActivities) DeleteBranchActivity(ctx context.Context, repo *types.RepositoryInfo) (GitHubOperationResult, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("deleting branch",
+		zap.String("repo", repo.Name),
+		zap.String("branch", repo.FeatureBranch),
+	)
+
+	err := a.githubClient.DeleteBranch(ctx, repo.Owner, repo.Name, repo.FeatureBranch)
+	if err != nil {
+		return GitHubOperationResult{Success: false, Message: err.Error()}, applicationError(err)
+	}
+
+	return GitHubOperationResult{
+		Success:    true,
+		Message:    "branch deleted successfully",
+		BranchName: repo.FeatureBranch,
 	}, nil
 }
 
+// RemoveWorkspaceActivity deletes the repository's clone from the workspace
+func (a *GitHubActivities) RemoveWorkspaceActivity(ctx context.Context, repo *types.RepositoryInfo) (GitHubOperationResult, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("removing workspace",
+		zap.String("owner", repo.Owner),
+		zap.String("name", repo.Name),
+	)
+
+	err := a.githubClient.RemoveRepository(repo.Owner, repo.Name)
+	if err != nil {
+		return GitHubOperationResult{Success: false, Message: err.Error()}, err
+	}
+
+	return GitHubOperationResult{
+		Success: true,
+		Message: "workspace removed successfully",
+	}, nil
+}
+
+// ClonePreviewActivity clones a repository into a throwaway workspace for a
+// plan preview
+func (a *GitHubActivities) ClonePreviewActivity(ctx context.Context, repo *types.RepositoryInfo) (GitHubOperationResult, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("cloning repository for preview",
+		zap.String("owner", repo.Owner),
+		zap.String("name", repo.Name),
+	)
+
+	progress := &progressWriter{ctx: ctx, stage: "clone"}
+	var repoPath string
+	err := withHeartbeat(ctx, "clone", func() (err error) {
+		repoPath, err = a.githubClient.CloneRepositoryTemp(ctx, repo.Owner, repo.Name, repo.BaseBranch, progress)
+		return err
+	})
+	if err != nil {
+		return GitHubOperationResult{Success: false, Message: err.Error()}, applicationError(err)
+	}
+
+	return GitHubOperationResult{
+		Success:        true,
+		Message:        "repository cloned successfully",
+		RepositoryPath: repoPath,
+	}, nil
+}
+
+// RemovePreviewActivity deletes a throwaway workspace made by
+// ClonePreviewActivity
+func (a *GitHubActivities) RemovePreviewActivity(ctx context.Context, repoPath string) (GitHubOperationResult, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("removing preview workspace",
+		zap.String("repo_path", repoPath),
+	)
+
+	err := a.githubClient.RemoveTempRepository(repoPath)
+	if err != nil {
+		return GitHubOperationResult{Success: false, Message: err.Error()}, err
+	}
+
+	return GitHubOperationResult{
+		Success: true,
+		Message: "workspace removed successfully",
+	}, nil
+}
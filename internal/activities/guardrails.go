@@ -0,0 +1,83 @@
+package activities
+
+import (
+	"context"
+	"sync"
+
+	"go.temporal.io/sdk/activity"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/guardrails"
+)
+
+// GuardrailActivities enforces diff-size and protected-path limits on generated changes
+type GuardrailActivities struct {
+	logger *zap.Logger
+
+	mu      sync.RWMutex
+	checker *guardrails.Checker
+}
+
+// NewGuardrailActivities creates a new guardrail activities handler
+func NewGuardrailActivities(checker *guardrails.Checker, logger *zap.Logger) *GuardrailActivities {
+	return &GuardrailActivities{
+		checker: checker,
+		logger:  logger,
+	}
+}
+
+// SetChecker replaces the checker used by subsequent activities
+func (a *GuardrailActivities) SetChecker(checker *guardrails.Checker) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.checker = checker
+}
+
+// currentChecker returns the checker to use for an activity
+func (a *GuardrailActivities) currentChecker() *guardrails.Checker {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.checker
+}
+
+// CheckGuardrailsActivity measures the changes in the repository relative to
+// the base branch, committed or not, and reports any guardrail they breach
+func (a *GuardrailActivities) CheckGuardrailsActivity(ctx context.Context, repoPath, baseBranch string) (GuardrailResult, error) {
+	logger := activity.GetLogger(ctx)
+	checker := a.currentChecker()
+	logger.Info("checking guardrails",
+		zap.String("repo_path", repoPath),
+	)
+
+	changes, err := diffStats(ctx, repoPath, baseBranch)
+	if err != nil {
+		return GuardrailResult{}, err
+	}
+
+	codeowners, err := guardrails.LoadCodeowners(repoPath)
+	if err != nil {
+		logger.Warn("failed to load CODEOWNERS", zap.Error(err))
+	}
+
+	result := GuardrailResult{
+		FilesChanged: len(changes),
+		Action:       checker.Action(),
+		ReviewLabel:  checker.ReviewLabel(),
+	}
+	for _, change := range changes {
+		result.LinesAdded += change.LinesAdded
+		result.LinesRemoved += change.LinesRemoved
+	}
+	for _, v := range checker.Check(changes, codeowners) {
+		result.Violations = append(result.Violations, v.String())
+	}
+
+	if len(result.Violations) > 0 {
+		logger.Warn("guardrails breached",
+			zap.Strings("violations", result.Violations),
+			zap.String("action", result.Action),
+		)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,123 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+)
+
+// heartbeatInterval is how often commands without their own progress output
+// heartbeat. The SDK throttles heartbeats, so this only bounds staleness.
+const heartbeatInterval = 10 * time.Second
+
+// progressWriter heartbeats the latest line of git progress output, which
+// uses carriage returns to redraw counters in place
+type progressWriter struct {
+	ctx   context.Context
+	stage string
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	lines := strings.FieldsFunc(string(p), func(r rune) bool {
+		return r == '\r' || r == '\n'
+	})
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			activity.RecordHeartbeat(w.ctx, Progress{Stage: w.stage, Detail: line})
+			break
+		}
+	}
+	return len(p), nil
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes and reads
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+// lastLine returns the last non-empty line written so far
+func (b *syncBuffer) lastLine() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := strings.TrimRight(b.buf.String(), "\r\n")
+	if i := strings.LastIndexAny(out, "\r\n"); i >= 0 {
+		out = out[i+1:]
+	}
+	return strings.TrimSpace(out)
+}
+
+// runWithHeartbeat runs cmd, heartbeating its elapsed time and latest output
+// line until it exits, and returns its combined output
+func runWithHeartbeat(ctx context.Context, stage string, cmd *exec.Cmd) ([]byte, error) {
+	var output syncBuffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	start := time.Now()
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			return output.Bytes(), err
+		case <-ticker.C:
+			activity.RecordHeartbeat(ctx, Progress{
+				Stage:   stage,
+				Elapsed: time.Since(start).Round(time.Second),
+				Detail:  output.lastLine(),
+			})
+		}
+	}
+}
+
+// withHeartbeat runs fn, heartbeating its elapsed time until it returns
+func withHeartbeat(ctx context.Context, stage string, fn func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	start := time.Now()
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ticker.C:
+			activity.RecordHeartbeat(ctx, Progress{
+				Stage:   stage,
+				Elapsed: time.Since(start).Round(time.Second),
+			})
+		}
+	}
+}
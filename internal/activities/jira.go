@@ -3,11 +3,13 @@ package activities
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"go.temporal.io/sdk/activity"
 	"go.uber.org/zap"
 
 	"github.com/clintrovert/khitomer/internal/jira"
+	"github.com/clintrovert/khitomer/pkg/types"
 )
 
 // JiraActivities handles Jira-related activities
@@ -24,8 +26,12 @@ func NewJiraActivities(jiraClient *jira.Client, logger *zap.Logger) *JiraActivit
 	}
 }
 
-// UpdateJiraActivity updates Jira with PR link and status
-func (a *JiraActivities) UpdateJiraActivity(ctx context.Context, ticketID, prURL string) (JiraUpdateResult, error) {
+// UpdateJiraActivity updates Jira with PR link and status. usage, if the
+// run recorded any, is summarised in the comment; it is nil for activities
+// scheduled by runs started before usage accounting. summary is Markdown
+// describing the plan and test results, and is empty for activities
+// scheduled by runs started before it was added.
+func (a *JiraActivities) UpdateJiraActivity(ctx context.Context, ticketID, prURL string, usage *types.UsageTotals, summary string) (JiraUpdateResult, error) {
 	logger := activity.GetLogger(ctx)
 	logger.Info("updating Jira",
 		zap.String("ticket_id", ticketID),
@@ -33,14 +39,21 @@ func (a *JiraActivities) UpdateJiraActivity(ctx context.Context, ticketID, prURL
 	)
 
 	comment := fmt.Sprintf("Pull request created: %s", prURL)
-	err := a.jiraClient.AddComment(ticketID, comment)
+	if summary != "" {
+		comment += "\n\n" + strings.TrimSpace(summary)
+	}
+	if usage != nil && len(usage.Calls) > 0 {
+		comment += fmt.Sprintf("\n\nLLM usage: %d prompt + %d completion tokens over %d calls, $%.2f",
+			usage.PromptTokens, usage.CompletionTokens, len(usage.Calls), usage.Cost)
+	}
+	err := a.jiraClient.AddComment(ctx, ticketID, comment)
 	if err != nil {
 		logger.Error("failed to add comment", zap.Error(err))
-		return JiraUpdateResult{Success: false, Message: err.Error()}, err
+		return JiraUpdateResult{Success: false, Message: err.Error()}, applicationError(err)
 	}
 
 	// Optionally update status to "In Review" or similar
-	// err = a.jiraClient.UpdateTaskStatus(ticketID, "In Review")
+	// err = a.jiraClient.UpdateTaskStatus(ctx, ticketID, "In Review")
 	// if err != nil {
 	// 	logger.Warn("failed to update status", zap.Error(err))
 	// }
@@ -51,3 +64,54 @@ func (a *JiraActivities) UpdateJiraActivity(ctx context.Context, ticketID, prURL
 	}, nil
 }
 
+// AddCommentActivity adds a comment to a Jira ticket
+func (a *JiraActivities) AddCommentActivity(ctx context.Context, ticketID, comment string) (JiraUpdateResult, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("adding Jira comment",
+		zap.String("ticket_id", ticketID),
+	)
+
+	err := a.jiraClient.AddComment(ctx, ticketID, comment)
+	if err != nil {
+		logger.Error("failed to add comment", zap.Error(err))
+		return JiraUpdateResult{Success: false, Message: err.Error()}, applicationError(err)
+	}
+
+	return JiraUpdateResult{
+		Success: true,
+		Message: "Jira comment added successfully",
+	}, nil
+}
+
+// RestoreStatusActivity moves a Jira ticket back to the given status if it has
+// since been transitioned elsewhere
+func (a *JiraActivities) RestoreStatusActivity(ctx context.Context, ticketID, status string) (JiraUpdateResult, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("restoring Jira status",
+		zap.String("ticket_id", ticketID),
+		zap.String("status", status),
+	)
+
+	current, err := a.jiraClient.GetTaskStatus(ctx, ticketID)
+	if err != nil {
+		logger.Error("failed to get status", zap.Error(err))
+		return JiraUpdateResult{Success: false, Message: err.Error()}, applicationError(err)
+	}
+	if strings.EqualFold(current, status) {
+		return JiraUpdateResult{
+			Success: true,
+			Message: "Jira status already " + status,
+		}, nil
+	}
+
+	err = a.jiraClient.UpdateTaskStatus(ctx, ticketID, status)
+	if err != nil {
+		logger.Error("failed to update status", zap.Error(err))
+		return JiraUpdateResult{Success: false, Message: err.Error()}, applicationError(err)
+	}
+
+	return JiraUpdateResult{
+		Success: true,
+		Message: "Jira status restored successfully",
+	}, nil
+}
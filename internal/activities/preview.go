@@ -0,0 +1,47 @@
+package activities
+
+import (
+	"context"
+	"strings"
+
+	"go.temporal.io/sdk/activity"
+	"go.uber.org/zap"
+)
+
+// MaxDiffBytes caps the diff returned by DiffActivity, keeping it well under
+// Temporal's payload size limit
+const MaxDiffBytes = 1 << 20
+
+// DiffActivity returns the uncommitted changes in the worktree, including new
+// files, as a unified diff against HEAD. It needs no dependencies, so it is
+// available on every worker.
+func (a *Activities) DiffActivity(ctx context.Context, repoPath string) (DiffResult, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("computing diff",
+		zap.String("repo_path", repoPath),
+	)
+
+	// Mark new files as intended to be added so the diff includes them
+	if _, err := runGit(ctx, repoPath, "add", "--all", "--intent-to-add"); err != nil {
+		return DiffResult{}, err
+	}
+
+	names, err := runGit(ctx, repoPath, "diff", "--name-only", "-z", "HEAD")
+	if err != nil {
+		return DiffResult{}, err
+	}
+	diff, err := runGit(ctx, repoPath, "diff", "--no-color", "--no-ext-diff", "HEAD")
+	if err != nil {
+		return DiffResult{}, err
+	}
+
+	result := DiffResult{
+		Diff:         string(diff),
+		FilesChanged: strings.Count(string(names), "\x00"),
+	}
+	if len(result.Diff) > MaxDiffBytes {
+		result.Diff = result.Diff[:MaxDiffBytes]
+		result.Truncated = true
+	}
+	return result, nil
+}
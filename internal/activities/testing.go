@@ -1,16 +1,101 @@
 package activities
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"go.temporal.io/sdk/activity"
 	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/coverage"
+	"github.com/clintrovert/khitomer/internal/metrics"
 )
 
-// TestingActivity runs tests in the repository
-func TestingActivity(ctx context.Context, repoPath string) (TestingResult, error) {
+// testRunner describes how to run tests with coverage for a project type
+type testRunner struct {
+	name    string
+	markers []string
+	binary  string
+	// args returns the command arguments, writing coverage into coverDir
+	args func(coverDir string) []string
+	// coverFile is the name of the coverage file written into coverDir
+	coverFile string
+	format    string
+}
+
+var testRunners = []testRunner{
+	{
+		name:    "go",
+		markers: []string{"go.mod"},
+		binary:  "go",
+		args: func(coverDir string) []string {
+			return []string{"test", "-coverprofile=" + filepath.Join(coverDir, "coverage.out"), "./..."}
+		},
+		coverFile: "coverage.out",
+		format:    "go",
+	},
+	{
+		name:    "node",
+		markers: []string{"package.json"},
+		binary:  "npm",
+		args: func(coverDir string) []string {
+			return []string{"test", "--", "--coverage", "--coverageReporters=lcovonly", "--coverageDirectory=" + coverDir}
+		},
+		coverFile: "lcov.info",
+		format:    "lcov",
+	},
+	{
+		name:    "python",
+		markers: []string{"pyproject.toml", "setup.py", "requirements.txt"},
+		binary:  "python",
+		args: func(coverDir string) []string {
+			return []string{"-m", "pytest", "--cov=.", "--cov-report=lcov:" + filepath.Join(coverDir, "lcov.info")}
+		},
+		coverFile: "lcov.info",
+		format:    "lcov",
+	},
+}
+
+// TestingActivities handles test execution and coverage reporting
+type TestingActivities struct {
+	logger *zap.Logger
+
+	mu                sync.RWMutex
+	coverageThreshold float64
+}
+
+// NewTestingActivities creates a new testing activities handler. A coverage
+// threshold of zero disables the changed-line coverage check.
+func NewTestingActivities(coverageThreshold float64, logger *zap.Logger) *TestingActivities {
+	return &TestingActivities{
+		coverageThreshold: coverageThreshold,
+		logger:            logger,
+	}
+}
+
+// SetCoverageThreshold replaces the threshold used by subsequent test runs
+func (a *TestingActivities) SetCoverageThreshold(threshold float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.coverageThreshold = threshold
+}
+
+// threshold returns the current coverage threshold
+func (a *TestingActivities) threshold() float64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.coverageThreshold
+}
+
+// TestingActivity runs tests in the repository and collects coverage for the
+// lines changed relative to the base branch
+func (a *TestingActivities) TestingActivity(ctx context.Context, repoPath, baseBranch string) (TestingResult, error) {
 	logger := activity.GetLogger(ctx)
 	logger.Info("running tests",
 		zap.String("repo_path", repoPath),
@@ -21,36 +106,139 @@ func TestingActivity(ctx context.Context, repoPath string) (TestingResult, error
 		Failures: []string{},
 	}
 
-	// Try to find and run tests
-	// This is a placeholder - in a real implementation, you would:
-	// 1. Detect the project type (Go, Node, Python, etc.)
-	// 2. Run the appropriate test command
-	// 3. Parse test results
-
-	// Example for Go projects
-	if _, err := exec.LookPath("go"); err == nil {
-		// Check if it's a Go project
-		if _, err := filepath.Glob(filepath.Join(repoPath, "*.go")); err == nil {
-			cmd := exec.CommandContext(ctx, "go", "test", "./...")
-			cmd.Dir = repoPath
-			output, err := cmd.CombinedOutput()
-			
-			result.Output = string(output)
-			if err != nil {
-				result.Failures = append(result.Failures, err.Error())
-				logger.Warn("tests failed", zap.Error(err))
-				return result, nil // Don't fail the workflow if tests fail
-			}
-			
-			result.Passed = true
-			logger.Info("tests passed")
-			return result, nil
+	runner := detectTestRunner(repoPath)
+	if runner == nil {
+		// If no tests found or project type not supported, assume success
+		logger.Info("no tests found or project type not supported, assuming success")
+		result.Passed = true
+		return result, nil
+	}
+
+	if _, err := exec.LookPath(runner.binary); err != nil {
+		logger.Warn("test runner not installed, assuming success",
+			zap.String("runner", runner.name),
+			zap.String("binary", runner.binary),
+		)
+		result.Passed = true
+		return result, nil
+	}
+
+	// Coverage output lives outside the worktree so it is never committed
+	coverDir, err := os.MkdirTemp("", "khitomer-coverage-")
+	if err != nil {
+		return result, fmt.Errorf("failed to create coverage directory: %w", err)
+	}
+	defer os.RemoveAll(coverDir)
+
+	cmd := exec.CommandContext(ctx, runner.binary, runner.args(coverDir)...)
+	cmd.Dir = repoPath
+	output, err := runWithHeartbeat(ctx, "test:"+runner.name, cmd)
+
+	result.Output = string(output)
+	if err != nil {
+		result.Failures = append(result.Failures, err.Error())
+		logger.Warn("tests failed", zap.Error(err))
+		metrics.TestRuns.WithLabelValues("failed").Inc()
+	} else {
+		result.Passed = true
+		logger.Info("tests passed")
+		metrics.TestRuns.WithLabelValues("passed").Inc()
+	}
+
+	report, err := a.coverageReport(ctx, runner, repoPath, baseBranch, filepath.Join(coverDir, runner.coverFile))
+	if err != nil {
+		// Coverage is informational; don't fail the workflow if it can't be computed
+		logger.Warn("failed to compute coverage", zap.Error(err))
+		return result, nil
+	}
+
+	result.Coverage = report
+	logger.Info("computed coverage",
+		zap.Float64("total_coverage", report.TotalCoverage),
+		zap.Float64("delta_coverage", report.DeltaCoverage),
+		zap.Bool("below_threshold", report.BelowThreshold),
+	)
+
+	return result, nil // Don't fail the workflow if tests fail
+}
+
+// coverageReport parses the runner's coverage output and intersects it with
+// the lines changed in the worktree
+func (a *TestingActivities) coverageReport(ctx context.Context, runner *testRunner, repoPath, baseBranch, coverPath string) (*coverage.Report, error) {
+	f, err := os.Open(coverPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open coverage file: %w", err)
+	}
+	defer f.Close()
+
+	var profile coverage.Profile
+	switch runner.format {
+	case "go":
+		modulePath, err := coverage.ReadModulePath(repoPath)
+		if err != nil {
+			return nil, err
+		}
+		profile, err = coverage.ParseGoProfile(f, modulePath)
+		if err != nil {
+			return nil, err
 		}
+	default:
+		profile, err = coverage.ParseLCOV(f, repoPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	changed, err := changedLines(ctx, repoPath, baseBranch)
+	if err != nil {
+		return nil, err
 	}
 
-	// If no tests found or project type not supported, assume success
-	logger.Info("no tests found or project type not supported, assuming success")
-	result.Passed = true
-	return result, nil
+	return coverage.NewReport(runner.format, profile, changed, a.threshold()), nil
 }
 
+// detectTestRunner returns the first runner whose marker file exists in the repository
+func detectTestRunner(repoPath string) *testRunner {
+	for i := range testRunners {
+		for _, marker := range testRunners[i].markers {
+			if _, err := os.Stat(filepath.Join(repoPath, marker)); err == nil {
+				return &testRunners[i]
+			}
+		}
+	}
+	return nil
+}
+
+// changedLines returns the lines added or modified in the worktree relative to
+// the base branch, treating every line of an untracked file as added
+func changedLines(ctx context.Context, repoPath, baseBranch string) (coverage.ChangedLines, error) {
+	diff, err := runGit(ctx, repoPath, "diff", "--unified=0", "--no-color", "--no-ext-diff", baseRef(baseBranch))
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := coverage.ParseUnifiedDiff(bytes.NewReader(diff))
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := runGit(ctx, repoPath, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range strings.Split(string(untracked), "\x00") {
+		if file == "" {
+			continue
+		}
+		lines, err := countFileLines(filepath.Join(repoPath, file))
+		if err != nil {
+			continue
+		}
+		for l := 1; l <= lines; l++ {
+			changed.Add(file, l)
+		}
+	}
+
+	return changed, nil
+}
@@ -1,16 +1,20 @@
 package activities
 
 import (
+	"time"
+
+	"github.com/clintrovert/khitomer/internal/coverage"
 	"github.com/clintrovert/khitomer/pkg/types"
 )
 
 // GitHubOperationResult contains the result of a GitHub operation
 type GitHubOperationResult struct {
-	Success       bool
-	Message       string
-	PRInfo        *types.PRInfo
-	BranchName    string
+	Success        bool
+	Message        string
+	PRInfo         *types.PRInfo
+	BranchName     string
 	RepositoryPath string
+	CommitSHA      string
 }
 
 // CodeGenerationResult contains the result of code generation
@@ -19,6 +23,8 @@ type CodeGenerationResult struct {
 	ModifiedFiles []string
 	CreatedFiles  []string
 	Summary       string
+	// Usage lists the LLM calls made to generate the code
+	Usage []types.LLMUsage
 }
 
 // TestingResult contains the result of testing
@@ -26,6 +32,34 @@ type TestingResult struct {
 	Passed   bool
 	Output   string
 	Failures []string
+	Coverage *coverage.Report
+	// Usage lists the LLM calls made to repair failing tests
+	Usage []types.LLMUsage
+}
+
+// GuardrailResult contains the outcome of a guardrail check
+type GuardrailResult struct {
+	FilesChanged int
+	LinesAdded   int
+	LinesRemoved int
+	Violations   []string
+	Action       string
+	ReviewLabel  string
+}
+
+// DiffResult contains the worktree's changes as a unified diff
+type DiffResult struct {
+	Diff         string
+	FilesChanged int
+	// Truncated reports whether Diff was cut at MaxDiffBytes
+	Truncated bool
+}
+
+// DeploymentResult contains the result of a deployment
+type DeploymentResult struct {
+	Success bool
+	URL     string
+	Output  string
 }
 
 // JiraUpdateResult contains the result of a Jira update
@@ -34,3 +68,11 @@ type JiraUpdateResult struct {
 	Message string
 }
 
+// Progress is recorded as heartbeat details by long-running activities
+type Progress struct {
+	Stage   string
+	Current int
+	Total   int
+	Elapsed time.Duration
+	Detail  string
+}
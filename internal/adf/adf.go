@@ -0,0 +1,108 @@
+// Package adf converts between Atlassian Document Format, which Jira Cloud's
+// v3 API uses for rich text, and Markdown.
+package adf
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Node types
+const (
+	TypeDoc         = "doc"
+	TypeParagraph   = "paragraph"
+	TypeText        = "text"
+	TypeHeading     = "heading"
+	TypeBulletList  = "bulletList"
+	TypeOrderedList = "orderedList"
+	TypeListItem    = "listItem"
+	TypeCodeBlock   = "codeBlock"
+	TypeBlockquote  = "blockquote"
+	TypeRule        = "rule"
+	TypeHardBreak   = "hardBreak"
+	TypeTable       = "table"
+	TypeTableRow    = "tableRow"
+	TypeTableHeader = "tableHeader"
+	TypeTableCell   = "tableCell"
+	TypeMention     = "mention"
+	TypeEmoji       = "emoji"
+	TypeInlineCard  = "inlineCard"
+	TypeBlockCard   = "blockCard"
+	TypePanel       = "panel"
+	TypeExpand      = "expand"
+	TypeNestedExp   = "nestedExpand"
+	TypeTaskList    = "taskList"
+	TypeTaskItem    = "taskItem"
+	TypeStatus      = "status"
+	TypeDate        = "date"
+)
+
+// Mark types
+const (
+	MarkStrong = "strong"
+	MarkEm     = "em"
+	MarkCode   = "code"
+	MarkStrike = "strike"
+	MarkLink   = "link"
+)
+
+// Node is a node of an ADF document. The document itself is a node of type
+// "doc" with version 1.
+type Node struct {
+	Type    string                 `json:"type"`
+	Version int                    `json:"version,omitempty"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+	Content []*Node                `json:"content,omitempty"`
+	Text    string                 `json:"text,omitempty"`
+	Marks   []Mark                 `json:"marks,omitempty"`
+}
+
+// Mark is formatting applied to a text node
+type Mark struct {
+	Type  string                 `json:"type"`
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// Parse decodes an ADF document
+func Parse(data []byte) (*Node, error) {
+	var doc Node
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode ADF: %w", err)
+	}
+	if doc.Type != TypeDoc {
+		return nil, fmt.Errorf("ADF root is %q, not %q", doc.Type, TypeDoc)
+	}
+	return &doc, nil
+}
+
+// newDoc returns a document holding blocks
+func newDoc(blocks []*Node) *Node {
+	return &Node{Type: TypeDoc, Version: 1, Content: blocks}
+}
+
+// stringAttr returns a node's string attribute, or "" if it has none
+func (n *Node) stringAttr(name string) string {
+	value, _ := n.Attrs[name].(string)
+	return value
+}
+
+// intAttr returns a node's numeric attribute, or def if it has none
+func (n *Node) intAttr(name string, def int) int {
+	switch value := n.Attrs[name].(type) {
+	case float64:
+		return int(value)
+	case int:
+		return value
+	}
+	return def
+}
+
+// hasMark reports whether a text node has a mark of the given type
+func (n *Node) hasMark(markType string) bool {
+	for _, mark := range n.Marks {
+		if mark.Type == markType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package adf
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files from the current converters")
+
+// goldenDir holds the golden conversions. Each <name>.json document in its
+// to_markdown directory is checked against <name>.md, and each <name>.md in
+// its from_markdown directory against <name>.json.
+const goldenDir = "testdata/golden"
+
+func TestToMarkdownGolden(t *testing.T) {
+	checkGolden(t, filepath.Join(goldenDir, "to_markdown"), ".json", ".md", func(input []byte) ([]byte, error) {
+		doc, err := Parse(input)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(ToMarkdown(doc) + "\n"), nil
+	})
+}
+
+func TestFromMarkdownGolden(t *testing.T) {
+	checkGolden(t, filepath.Join(goldenDir, "from_markdown"), ".md", ".json", func(input []byte) ([]byte, error) {
+		data, err := json.MarshalIndent(FromMarkdown(string(input)), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	})
+}
+
+// checkGolden converts each file in dir with the input extension and compares
+// the result with the file of the same name and the output extension
+func checkGolden(t *testing.T, dir, inputExt, outputExt string, convert func([]byte) ([]byte, error)) {
+	inputs, err := filepath.Glob(filepath.Join(dir, "*"+inputExt))
+	require.NoError(t, err)
+	require.NotEmpty(t, inputs, "no golden inputs in %s", dir)
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), inputExt)
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(input)
+			require.NoError(t, err)
+			got, err := convert(data)
+			require.NoError(t, err)
+
+			output := strings.TrimSuffix(input, inputExt) + outputExt
+			if *update {
+				require.NoError(t, os.WriteFile(output, got, 0o644))
+				return
+			}
+			want, err := os.ReadFile(output)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package adf
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ToMarkdown renders a document as Markdown. Mentions are rendered as
+// @name, cards as their URL and media, which has no text, is dropped.
+func ToMarkdown(doc *Node) string {
+	if doc == nil {
+		return ""
+	}
+	return strings.TrimSpace(renderBlocks(doc.Content))
+}
+
+// renderBlocks renders block nodes separated by blank lines
+func renderBlocks(nodes []*Node) string {
+	var blocks []string
+	for _, node := range nodes {
+		if block := renderBlock(node); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// renderBlock renders a block node, without a trailing newline
+func renderBlock(node *Node) string {
+	switch node.Type {
+	case TypeParagraph:
+		return renderInline(node.Content)
+	case TypeHeading:
+		level := node.intAttr("level", 1)
+		if level < 1 || level > 6 {
+			level = 1
+		}
+		return strings.Repeat("#", level) + " " + renderInline(node.Content)
+	case TypeBulletList:
+		return renderList(node, func(int) string { return "- " })
+	case TypeOrderedList:
+		start := node.intAttr("order", 1)
+		return renderList(node, func(i int) string { return strconv.Itoa(start+i) + ". " })
+	case TypeTaskList:
+		return renderList(node, func(i int) string {
+			if node.Content[i].stringAttr("state") == "DONE" {
+				return "- [x] "
+			}
+			return "- [ ] "
+		})
+	case TypeCodeBlock:
+		return "```" + node.stringAttr("language") + "\n" + plainText(node.Content) + "\n```"
+	case TypeBlockquote, TypePanel:
+		return quote(renderBlocks(node.Content))
+	case TypeRule:
+		return "---"
+	case TypeTable:
+		return renderTable(node)
+	case TypeExpand, TypeNestedExp:
+		body := renderBlocks(node.Content)
+		if title := node.stringAttr("title"); title != "" {
+			return strings.TrimSpace("**" + title + "**\n\n" + body)
+		}
+		return body
+	case TypeBlockCard:
+		return node.stringAttr("url")
+	case TypeText, TypeHardBreak, TypeMention, TypeEmoji, TypeInlineCard, TypeStatus, TypeDate:
+		return renderInline([]*Node{node})
+	default:
+		// Unknown blocks, and media, keep whatever text they contain
+		return renderBlocks(node.Content)
+	}
+}
+
+// renderList renders a list's items, each introduced by its marker and with
+// continuation lines indented to match
+func renderList(list *Node, marker func(i int) string) string {
+	items := make([]string, 0, len(list.Content))
+	for i, item := range list.Content {
+		prefix := marker(i)
+		var body string
+		if item.Type == TypeTaskItem {
+			body = renderInline(item.Content)
+		} else {
+			body = renderListItem(item)
+		}
+		items = append(items, prefix+indent(body, len(prefix)))
+	}
+	return strings.Join(items, "\n")
+}
+
+// renderListItem renders a list item's blocks. Nested lists follow their
+// paragraph directly so the list stays tight.
+func renderListItem(item *Node) string {
+	var sb strings.Builder
+	for i, block := range item.Content {
+		rendered := renderBlock(block)
+		if rendered == "" {
+			continue
+		}
+		if i > 0 {
+			if block.Type == TypeBulletList || block.Type == TypeOrderedList || block.Type == TypeTaskList {
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString("\n\n")
+			}
+		}
+		sb.WriteString(rendered)
+	}
+	return sb.String()
+}
+
+// renderTable renders a table as a GitHub-flavoured Markdown table. The
+// first row is the header, whether or not its cells are header cells.
+func renderTable(table *Node) string {
+	var rows [][]string
+	columns := 0
+	for _, row := range table.Content {
+		var cells []string
+		for _, cell := range row.Content {
+			cells = append(cells, renderCell(cell))
+		}
+		if len(cells) > columns {
+			columns = len(cells)
+		}
+		rows = append(rows, cells)
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+
+	line := func(cells []string) string {
+		for len(cells) < columns {
+			cells = append(cells, "")
+		}
+		return "| " + strings.Join(cells, " | ") + " |"
+	}
+	separator := make([]string, columns)
+	for i := range separator {
+		separator[i] = "---"
+	}
+
+	lines := []string{line(rows[0]), line(separator)}
+	for _, row := range rows[1:] {
+		lines = append(lines, line(row))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderCell renders a table cell's blocks on one line
+func renderCell(cell *Node) string {
+	var parts []string
+	for _, block := range cell.Content {
+		if rendered := renderBlock(block); rendered != "" {
+			parts = append(parts, rendered)
+		}
+	}
+	text := strings.Join(parts, " ")
+	text = strings.ReplaceAll(text, "\n", " ")
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// renderInline renders inline nodes
+func renderInline(nodes []*Node) string {
+	var sb strings.Builder
+	for _, node := range nodes {
+		switch node.Type {
+		case TypeText:
+			sb.WriteString(renderText(node))
+		case TypeHardBreak:
+			sb.WriteString("\n")
+		case TypeMention:
+			text := node.stringAttr("text")
+			if text == "" {
+				text = node.stringAttr("id")
+			}
+			if !strings.HasPrefix(text, "@") {
+				text = "@" + text
+			}
+			sb.WriteString(text)
+		case TypeEmoji:
+			if text := node.stringAttr("text"); text != "" {
+				sb.WriteString(text)
+			} else {
+				sb.WriteString(node.stringAttr("shortName"))
+			}
+		case TypeInlineCard:
+			sb.WriteString(node.stringAttr("url"))
+		case TypeStatus:
+			sb.WriteString("[" + node.stringAttr("text") + "]")
+		case TypeDate:
+			sb.WriteString(renderDate(node.stringAttr("timestamp")))
+		default:
+			sb.WriteString(renderInline(node.Content))
+		}
+	}
+	return sb.String()
+}
+
+// renderText renders a text node with its marks
+func renderText(node *Node) string {
+	text := node.Text
+	if node.hasMark(MarkCode) {
+		text = "`" + text + "`"
+	} else {
+		// Markdown emphasis can't start or end with whitespace, so it wraps
+		// the trimmed text
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" {
+			return text
+		}
+		lead := text[:strings.Index(text, trimmed)]
+		trail := text[len(lead)+len(trimmed):]
+		if node.hasMark(MarkStrike) {
+			trimmed = "~~" + trimmed + "~~"
+		}
+		if node.hasMark(MarkEm) {
+			trimmed = "_" + trimmed + "_"
+		}
+		if node.hasMark(MarkStrong) {
+			trimmed = "**" + trimmed + "**"
+		}
+		text = lead + trimmed + trail
+	}
+
+	for _, mark := range node.Marks {
+		if mark.Type != MarkLink {
+			continue
+		}
+		href, _ := mark.Attrs["href"].(string)
+		if href != "" {
+			text = "[" + text + "](" + href + ")"
+		}
+	}
+	return text
+}
+
+// renderDate renders a date node's timestamp, in milliseconds since the
+// epoch, as a UTC date
+func renderDate(timestamp string) string {
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return timestamp
+	}
+	return time.UnixMilli(ms).UTC().Format("2006-01-02")
+}
+
+// plainText concatenates the text of inline nodes, ignoring marks
+func plainText(nodes []*Node) string {
+	var sb strings.Builder
+	for _, node := range nodes {
+		if node.Type == TypeHardBreak {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString(node.Text)
+	}
+	return sb.String()
+}
+
+// indent indents every line of text after the first by n spaces
+func indent(text string, n int) string {
+	pad := strings.Repeat(" ", n)
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = pad + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// quote prefixes every line of text with "> "
+func quote(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,463 @@
+package adf
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	rulePattern      = regexp.MustCompile(`^(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+	fencePattern     = regexp.MustCompile("^(```+|~~~+)\\s*([\\w+#.-]*)")
+	bulletPattern    = regexp.MustCompile(`^(\s*)([-*+])\s+(.*)$`)
+	orderedPattern   = regexp.MustCompile(`^(\s*)(\d{1,9})[.)]\s+(.*)$`)
+	delimiterPattern = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+	mentionPattern   = regexp.MustCompile(`^\[~accountid:([^\]\s]+)\]`)
+	linkPattern      = regexp.MustCompile(`^\[([^\]]*)\]\(([^)\s]+)\)`)
+	urlPattern       = regexp.MustCompile(`^https?://[^\s<>)\]]*[^\s<>)\].,;:!?'"]`)
+)
+
+// emojis are the shortcodes converted to emoji nodes. Others are left as
+// text, as colons appear in ordinary prose too.
+var emojis = map[string]string{
+	":warning:":            "⚠️",
+	":white_check_mark:":   "✅",
+	":heavy_check_mark:":   "✔️",
+	":x:":                  "❌",
+	":information_source:": "ℹ️",
+}
+
+// FromMarkdown converts Markdown to a document. It understands headings,
+// paragraphs, bullet and ordered lists, fenced code blocks, blockquotes,
+// rules, tables, links, emphasis, strikethrough and inline code. Single
+// newlines within a paragraph are kept as hard breaks, and Jira wiki
+// mentions of the form [~accountid:ID] become mentions.
+func FromMarkdown(markdown string) *Node {
+	markdown = strings.ReplaceAll(markdown, "\r\n", "\n")
+	return newDoc(parseBlocks(strings.Split(markdown, "\n")))
+}
+
+// parseBlocks parses lines into block nodes
+func parseBlocks(lines []string) []*Node {
+	var blocks []*Node
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+		case fencePattern.MatchString(trimmed):
+			var block *Node
+			block, i = parseCodeBlock(lines, i)
+			blocks = append(blocks, block)
+		case headingPattern.MatchString(trimmed):
+			m := headingPattern.FindStringSubmatch(trimmed)
+			blocks = append(blocks, &Node{
+				Type:    TypeHeading,
+				Attrs:   map[string]interface{}{"level": len(m[1])},
+				Content: parseInline(m[2]),
+			})
+			i++
+		case rulePattern.MatchString(trimmed):
+			blocks = append(blocks, &Node{Type: TypeRule})
+			i++
+		case strings.HasPrefix(trimmed, ">"):
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				content := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(content, " "))
+			}
+			blocks = append(blocks, &Node{Type: TypeBlockquote, Content: parseBlocks(quoted)})
+		case isTableStart(lines, i):
+			var block *Node
+			block, i = parseTable(lines, i)
+			blocks = append(blocks, block)
+		case bulletPattern.MatchString(line) || orderedPattern.MatchString(line):
+			var block *Node
+			block, i = parseList(lines, i)
+			blocks = append(blocks, block)
+		default:
+			var paragraph []string
+			for ; i < len(lines) && !startsBlock(lines, i); i++ {
+				paragraph = append(paragraph, strings.TrimSpace(lines[i]))
+			}
+			blocks = append(blocks, &Node{Type: TypeParagraph, Content: parseInline(strings.Join(paragraph, "\n"))})
+		}
+	}
+	return blocks
+}
+
+// startsBlock reports whether line i is blank or starts a block other than
+// a paragraph
+func startsBlock(lines []string, i int) bool {
+	line := lines[i]
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" ||
+		fencePattern.MatchString(trimmed) ||
+		headingPattern.MatchString(trimmed) ||
+		rulePattern.MatchString(trimmed) ||
+		strings.HasPrefix(trimmed, ">") ||
+		isTableStart(lines, i) ||
+		bulletPattern.MatchString(line) ||
+		orderedPattern.MatchString(line)
+}
+
+// parseCodeBlock parses the fenced code block starting at line i, returning
+// it and the index of the line after it. An unclosed fence runs to the end.
+func parseCodeBlock(lines []string, i int) (*Node, int) {
+	m := fencePattern.FindStringSubmatch(strings.TrimSpace(lines[i]))
+	fence, language := m[1], m[2]
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+			i++
+			break
+		}
+		code = append(code, lines[i])
+	}
+
+	block := &Node{Type: TypeCodeBlock}
+	if language != "" {
+		block.Attrs = map[string]interface{}{"language": language}
+	}
+	if text := strings.Join(code, "\n"); text != "" {
+		block.Content = []*Node{{Type: TypeText, Text: text}}
+	}
+	return block, i
+}
+
+// isTableStart reports whether line i is a table header row, i.e. it has a
+// pipe and is followed by a delimiter row
+func isTableStart(lines []string, i int) bool {
+	return i+1 < len(lines) &&
+		strings.Contains(lines[i], "|") &&
+		strings.Contains(lines[i+1], "-") &&
+		delimiterPattern.MatchString(strings.TrimSpace(lines[i+1]))
+}
+
+// parseTable parses the table starting at line i, returning it and the index
+// of the line after it
+func parseTable(lines []string, i int) (*Node, int) {
+	table := &Node{
+		Type:  TypeTable,
+		Attrs: map[string]interface{}{"isNumberColumnEnabled": false, "layout": "default"},
+	}
+	header := splitRow(lines[i])
+	table.Content = append(table.Content, tableRow(header, TypeTableHeader, len(header)))
+
+	for i += 2; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || !strings.Contains(trimmed, "|") {
+			break
+		}
+		table.Content = append(table.Content, tableRow(splitRow(trimmed), TypeTableCell, len(header)))
+	}
+	return table, i
+}
+
+// tableRow builds a row of cells of cellType, padded or cut to columns
+func tableRow(cells []string, cellType string, columns int) *Node {
+	row := &Node{Type: TypeTableRow}
+	for c := 0; c < columns; c++ {
+		paragraph := &Node{Type: TypeParagraph}
+		if c < len(cells) {
+			paragraph.Content = parseInline(cells[c])
+		}
+		row.Content = append(row.Content, &Node{Type: cellType, Content: []*Node{paragraph}})
+	}
+	return row
+}
+
+// splitRow splits a table row into its trimmed cells, honouring escaped
+// pipes
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for j := 0; j < len(line); j++ {
+		switch {
+		case line[j] == '\\' && j+1 < len(line) && line[j+1] == '|':
+			cell.WriteByte('|')
+			j++
+		case line[j] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[j])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// listMarker is a parsed list item line
+type listMarker struct {
+	ordered bool
+	indent  int
+	// width is the indent of the item's content
+	width int
+	start int
+	text  string
+}
+
+// parseMarker parses a list item line
+func parseMarker(line string) (listMarker, bool) {
+	if m := bulletPattern.FindStringSubmatch(line); m != nil {
+		return listMarker{indent: len(m[1]), width: len(m[1]) + len(m[2]) + 1, text: m[3]}, true
+	}
+	if m := orderedPattern.FindStringSubmatch(line); m != nil {
+		start, _ := strconv.Atoi(m[2])
+		return listMarker{ordered: true, indent: len(m[1]), width: len(m[1]) + len(m[2]) + 2, start: start, text: m[3]}, true
+	}
+	return listMarker{}, false
+}
+
+// parseList parses the list starting at line i, returning it and the index
+// of the line after it. Items continue over lines indented past their
+// marker, which may hold nested lists, and over unindented lines that
+// don't start another block.
+func parseList(lines []string, i int) (*Node, int) {
+	first, _ := parseMarker(lines[i])
+	list := &Node{Type: TypeBulletList}
+	if first.ordered {
+		list.Type = TypeOrderedList
+		if first.start != 1 {
+			list.Attrs = map[string]interface{}{"order": first.start}
+		}
+	}
+
+	for i < len(lines) {
+		marker, ok := parseMarker(lines[i])
+		if !ok || marker.ordered != first.ordered || marker.indent != first.indent {
+			break
+		}
+
+		body := []string{marker.text}
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" {
+				// A blank line ends the list unless an indented line follows
+				if i+1 < len(lines) && leadingSpaces(lines[i+1]) >= marker.width && strings.TrimSpace(lines[i+1]) != "" {
+					body = append(body, "")
+					continue
+				}
+				break
+			}
+			if leadingSpaces(line) >= marker.width {
+				body = append(body, line[marker.width:])
+				continue
+			}
+			if _, isItem := parseMarker(line); isItem || startsBlock(lines, i) {
+				break
+			}
+			body = append(body, trimmed)
+		}
+
+		list.Content = append(list.Content, &Node{Type: TypeListItem, Content: parseBlocks(body)})
+
+		// Skip a single blank line between items of a loose list
+		if i+1 < len(lines) && strings.TrimSpace(lines[i]) == "" {
+			if next, ok := parseMarker(lines[i+1]); ok && next.ordered == first.ordered && next.indent == first.indent {
+				i++
+			}
+		}
+	}
+	return list, i
+}
+
+// leadingSpaces counts the spaces a line starts with, with tabs as four
+func leadingSpaces(line string) int {
+	n := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			n++
+		case '\t':
+			n += 4
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// parseInline parses inline Markdown into text, hard break, mention and
+// emoji nodes
+func parseInline(text string) []*Node {
+	return mergeText(parseInlineMarks(text, nil))
+}
+
+// parseInlineMarks parses inline Markdown, adding marks to every text node
+func parseInlineMarks(text string, marks []Mark) []*Node {
+	var nodes []*Node
+	var plain strings.Builder
+	flush := func() {
+		if plain.Len() > 0 {
+			nodes = append(nodes, &Node{Type: TypeText, Text: plain.String(), Marks: marks})
+			plain.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_~[]()#|>!-+.", rune(rest[1])):
+			plain.WriteByte(rest[1])
+			i += 2
+			continue
+		case rest[0] == '\n':
+			flush()
+			nodes = append(nodes, &Node{Type: TypeHardBreak})
+			i++
+			continue
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end >= 0 {
+				flush()
+				nodes = append(nodes, &Node{Type: TypeText, Text: rest[1 : end+1], Marks: withMark(marks, Mark{Type: MarkCode})})
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if end := strings.Index(rest[2:], rest[:2]); end > 0 {
+				flush()
+				nodes = append(nodes, parseInlineMarks(rest[2:end+2], withMark(marks, Mark{Type: MarkStrong}))...)
+				i += end + 4
+				continue
+			}
+		case strings.HasPrefix(rest, "~~"):
+			if end := strings.Index(rest[2:], "~~"); end > 0 {
+				flush()
+				nodes = append(nodes, parseInlineMarks(rest[2:end+2], withMark(marks, Mark{Type: MarkStrike}))...)
+				i += end + 4
+				continue
+			}
+		case rest[0] == '*' || (rest[0] == '_' && (i == 0 || !isWordByte(text[i-1]))):
+			if end := closingEmphasis(rest); end > 0 {
+				flush()
+				nodes = append(nodes, parseInlineMarks(rest[1:end], withMark(marks, Mark{Type: MarkEm}))...)
+				i += end + 1
+				continue
+			}
+		case rest[0] == '[':
+			if m := mentionPattern.FindStringSubmatch(rest); m != nil {
+				flush()
+				nodes = append(nodes, &Node{Type: TypeMention, Attrs: map[string]interface{}{"id": m[1]}})
+				i += len(m[0])
+				continue
+			}
+			if m := linkPattern.FindStringSubmatch(rest); m != nil {
+				flush()
+				link := Mark{Type: MarkLink, Attrs: map[string]interface{}{"href": m[2]}}
+				label := m[1]
+				if label == "" {
+					label = m[2]
+				}
+				nodes = append(nodes, parseInlineMarks(label, withMark(marks, link))...)
+				i += len(m[0])
+				continue
+			}
+		case rest[0] == '<':
+			if end := strings.IndexByte(rest, '>'); end > 0 && urlPattern.MatchString(rest[1:end]) {
+				flush()
+				url := rest[1:end]
+				nodes = append(nodes, &Node{Type: TypeText, Text: url, Marks: withMark(marks, Mark{Type: MarkLink, Attrs: map[string]interface{}{"href": url}})})
+				i += end + 1
+				continue
+			}
+		case rest[0] == 'h' && (i == 0 || !isWordByte(text[i-1])):
+			if url := urlPattern.FindString(rest); url != "" {
+				flush()
+				nodes = append(nodes, &Node{Type: TypeText, Text: url, Marks: withMark(marks, Mark{Type: MarkLink, Attrs: map[string]interface{}{"href": url}})})
+				i += len(url)
+				continue
+			}
+		case rest[0] == ':':
+			if end := strings.IndexByte(rest[1:], ':'); end > 0 {
+				shortName := rest[:end+2]
+				if emoji, ok := emojis[shortName]; ok {
+					flush()
+					nodes = append(nodes, &Node{Type: TypeEmoji, Attrs: map[string]interface{}{"shortName": shortName, "text": emoji}})
+					i += len(shortName)
+					continue
+				}
+			}
+		}
+
+		plain.WriteByte(rest[0])
+		i++
+	}
+	flush()
+	return nodes
+}
+
+// closingEmphasis returns the index in s of the delimiter closing the single
+// * or _ that s starts with, or -1
+func closingEmphasis(s string) int {
+	delim := s[0]
+	if len(s) < 3 || s[1] == ' ' || s[1] == delim {
+		return -1
+	}
+	for j := 2; j < len(s); j++ {
+		if s[j] != delim || s[j-1] == ' ' {
+			continue
+		}
+		if delim == '_' && j+1 < len(s) && isWordByte(s[j+1]) {
+			continue
+		}
+		return j
+	}
+	return -1
+}
+
+// isWordByte reports whether b is an ASCII letter, digit or underscore
+func isWordByte(b byte) bool {
+	return b == '_' || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// withMark returns marks with mark added, leaving marks unchanged
+func withMark(marks []Mark, mark Mark) []Mark {
+	result := make([]Mark, 0, len(marks)+1)
+	result = append(result, marks...)
+	return append(result, mark)
+}
+
+// mergeText joins adjacent text nodes that have the same marks
+func mergeText(nodes []*Node) []*Node {
+	var merged []*Node
+	for _, node := range nodes {
+		if len(merged) > 0 {
+			last := merged[len(merged)-1]
+			if last.Type == TypeText && node.Type == TypeText && sameMarks(last.Marks, node.Marks) {
+				last.Text += node.Text
+				continue
+			}
+		}
+		merged = append(merged, node)
+	}
+	return merged
+}
+
+// sameMarks reports whether two mark lists are equal
+func sameMarks(a, b []Mark) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Type != b[i].Type {
+			return false
+		}
+		if a[i].Type == MarkLink && a[i].Attrs["href"] != b[i].Attrs["href"] {
+			return false
+		}
+	}
+	return true
+}
@@ -2,11 +2,20 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/clintrovert/khitomer/internal/artifacts"
+	"github.com/clintrovert/khitomer/internal/auth"
+	"github.com/clintrovert/khitomer/internal/leader"
 	"github.com/clintrovert/khitomer/internal/temporal"
+	"github.com/clintrovert/khitomer/internal/temporal/workflows"
+	"github.com/clintrovert/khitomer/internal/usage"
 	"github.com/clintrovert/khitomer/pkg/types"
 	pb "github.com/clintrovert/khitomer/proto"
 )
@@ -15,13 +24,19 @@ import (
 type Server struct {
 	pb.UnimplementedLeaderServiceServer
 	temporalClient *temporal.Client
+	trigger        *leader.Trigger
+	previewer      *leader.Previewer
+	budget         *leader.Budget
 	logger         *zap.Logger
 }
 
 // NewServer creates a new gRPC server
-func NewServer(temporalClient *temporal.Client, logger *zap.Logger) *Server {
+func NewServer(temporalClient *temporal.Client, trigger *leader.Trigger, previewer *leader.Previewer, budget *leader.Budget, logger *zap.Logger) *Server {
 	return &Server{
 		temporalClient: temporalClient,
+		trigger:        trigger,
+		previewer:      previewer,
+		budget:         budget,
 		logger:         logger,
 	}
 }
@@ -31,29 +46,46 @@ func (s *Server) Register(grpcServer *grpc.Server) {
 	pb.RegisterLeaderServiceServer(grpcServer, s)
 }
 
+// Policies are the roles required for each LeaderService method. Calls that
+// change workflows are audited.
+var Policies = map[string]auth.Policy{
+	pb.LeaderService_GetWorkflowStatus_FullMethodName: {Role: auth.RoleViewer},
+	pb.LeaderService_GetProcessedTasks_FullMethodName: {Role: auth.RoleViewer},
+	pb.LeaderService_ListWorkflows_FullMethodName:     {Role: auth.RoleViewer},
+	pb.LeaderService_WatchWorkflow_FullMethodName:     {Role: auth.RoleViewer},
+	pb.LeaderService_GetWorkflowUsage_FullMethodName:  {Role: auth.RoleViewer},
+	pb.LeaderService_GetUsage_FullMethodName:          {Role: auth.RoleViewer},
+	pb.LeaderService_ListArtifacts_FullMethodName:     {Role: auth.RoleViewer},
+	pb.LeaderService_GetArtifact_FullMethodName:       {Role: auth.RoleViewer},
+	pb.LeaderService_StartWorkflow_FullMethodName:     {Role: auth.RoleOperator, Audit: true},
+	pb.LeaderService_CancelWorkflow_FullMethodName:    {Role: auth.RoleOperator, Audit: true},
+	pb.LeaderService_SubmitReview_FullMethodName:      {Role: auth.RoleOperator, Audit: true},
+	pb.LeaderService_PreviewPlan_FullMethodName:       {Role: auth.RoleOperator, Audit: true},
+}
+
 // StartWorkflow starts a workflow
 func (s *Server) StartWorkflow(ctx context.Context, req *pb.StartWorkflowRequest) (*pb.StartWorkflowResponse, error) {
-	task := &types.Task{
-		JiraTicketID:    req.JiraTicketId,
-		RepositoryOwner: req.RepositoryOwner,
-		RepositoryName:  req.RepositoryName,
-		BaseBranch:      req.BaseBranch,
+	startReq := leader.StartRequest{
+		TaskRequest: leader.TaskRequest{
+			JiraTicketID:    req.JiraTicketId,
+			RepositoryOwner: req.RepositoryOwner,
+			RepositoryName:  req.RepositoryName,
+			BaseBranch:      req.BaseBranch,
+		},
 	}
-
-	plan := &types.ImplementationPlan{
-		Summary: "Manual workflow trigger",
-		Steps:   []types.PlanStep{},
+	if req.Plan != nil {
+		startReq.Plan = planFromProto(req.Plan)
 	}
 
-	repo := &types.RepositoryInfo{
-		Owner:      req.RepositoryOwner,
-		Name:       req.RepositoryName,
-		BaseBranch: req.BaseBranch,
-		CloneURL:   "https://github.com/" + req.RepositoryOwner + "/" + req.RepositoryName,
+	workflowID, err := s.trigger.Start(ctx, startReq)
+	if errors.Is(err, leader.ErrInvalidRequest) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, usage.ErrBudgetExceeded) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
 	}
-
-	workflowID, err := s.temporalClient.StartWorkflow(ctx, task, plan, repo)
 	if err != nil {
+		s.logger.Error("failed to start workflow", zap.Error(err))
 		return nil, err
 	}
 
@@ -105,3 +137,309 @@ func (s *Server) GetProcessedTasks(ctx context.Context, req *pb.GetProcessedTask
 		Total: 0,
 	}, nil
 }
+
+// SubmitReview approves or rejects a review step of a workflow
+func (s *Server) SubmitReview(ctx context.Context, req *pb.SubmitReviewRequest) (*pb.SubmitReviewResponse, error) {
+	decision := workflows.ReviewDecision{
+		Approved: req.Approved,
+		Reviewer: req.Reviewer,
+		Comment:  req.Comment,
+	}
+
+	err := s.temporalClient.SubmitReview(ctx, req.WorkflowId, int(req.StepOrder), decision)
+	if err != nil {
+		return &pb.SubmitReviewResponse{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+
+	return &pb.SubmitReviewResponse{
+		Success: true,
+		Message: "review submitted",
+	}, nil
+}
+
+// ListWorkflows lists workflows filtered by search attributes
+func (s *Server) ListWorkflows(ctx context.Context, req *pb.ListWorkflowsRequest) (*pb.ListWorkflowsResponse, error) {
+	filter := temporal.ListFilter{
+		JiraTicket: req.JiraTicket,
+		Repository: req.Repository,
+		Assignee:   req.Assignee,
+		Complexity: req.Complexity,
+		Outcome:    req.Outcome,
+		Status:     req.Status,
+	}
+
+	summaries, next, err := s.temporalClient.ListWorkflows(ctx, filter, int(req.PageSize), req.PageToken)
+	if errors.Is(err, temporal.ErrInvalidPageToken) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListWorkflowsResponse{
+		Workflows:     make([]*pb.WorkflowSummary, 0, len(summaries)),
+		NextPageToken: next,
+	}
+	for _, sum := range summaries {
+		resp.Workflows = append(resp.Workflows, &pb.WorkflowSummary{
+			WorkflowId:       sum.WorkflowID,
+			RunId:            sum.RunID,
+			Status:           sum.Status,
+			JiraTicket:       sum.JiraTicket,
+			Repository:       sum.Repository,
+			Assignee:         sum.Assignee,
+			Complexity:       sum.Complexity,
+			PrNumber:         sum.PRNumber,
+			Outcome:          sum.Outcome,
+			StartTime:        formatTime(sum.StartTime),
+			CloseTime:        formatTime(sum.CloseTime),
+			PromptTokens:     int64(sum.Usage.PromptTokens),
+			CompletionTokens: int64(sum.Usage.CompletionTokens),
+			Cost:             sum.Usage.Cost,
+		})
+	}
+
+	return resp, nil
+}
+
+// WatchWorkflow streams a workflow's progress events, resuming after
+// last_event_id, until the workflow closes
+func (s *Server) WatchWorkflow(req *pb.WatchWorkflowRequest, stream pb.LeaderService_WatchWorkflowServer) error {
+	err := s.temporalClient.WatchWorkflow(stream.Context(), req.WorkflowId, req.LastEventId, func(event workflows.ProgressEvent) error {
+		return stream.Send(&pb.WorkflowEvent{
+			Id:        event.ID,
+			Type:      event.Type,
+			Time:      formatTime(event.Time),
+			Step:      event.Step,
+			StepOrder: int32(event.StepOrder),
+			Message:   event.Message,
+			Data:      event.Data,
+		})
+	}, nil)
+	if errors.Is(err, temporal.ErrWorkflowNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	if err != nil {
+		s.logger.Error("failed to watch workflow", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// PreviewPlan plans a ticket, and optionally generates its diff, without
+// touching the repository or Jira
+func (s *Server) PreviewPlan(ctx context.Context, req *pb.PreviewPlanRequest) (*pb.PreviewPlanResponse, error) {
+	preview, err := s.previewer.Preview(ctx, leader.PreviewRequest{
+		TaskRequest: leader.TaskRequest{
+			JiraTicketID:    req.JiraTicketId,
+			Title:           req.Title,
+			Description:     req.Description,
+			RepositoryOwner: req.RepositoryOwner,
+			RepositoryName:  req.RepositoryName,
+			BaseBranch:      req.BaseBranch,
+		},
+		IncludeDiff: req.IncludeDiff,
+	})
+	if errors.Is(err, leader.ErrInvalidRequest) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, usage.ErrBudgetExceeded) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if err != nil {
+		s.logger.Error("failed to preview plan", zap.Error(err))
+		return nil, err
+	}
+
+	resp := &pb.PreviewPlanResponse{
+		Task: &pb.TaskMetadata{
+			JiraTicketId:    preview.Task.JiraTicketID,
+			Title:           preview.Task.Title,
+			Description:     preview.Task.Description,
+			Status:          preview.Task.Status,
+			Assignee:        preview.Task.Assignee,
+			RepositoryOwner: preview.Task.RepositoryOwner,
+			RepositoryName:  preview.Task.RepositoryName,
+			RepositoryUrl:   preview.Task.RepositoryURL,
+			BaseBranch:      preview.Task.BaseBranch,
+		},
+		Plan:  planToProto(preview.Plan),
+		Usage: usageToProto(preview.Usage),
+	}
+	if result := preview.Result; result != nil {
+		resp.Codegen = &pb.CodeGenerationOutput{
+			ModifiedFiles: result.Codegen.ModifiedFiles,
+			CreatedFiles:  result.Codegen.CreatedFiles,
+			Summary:       result.Codegen.Summary,
+		}
+		resp.Diff = result.Diff.Diff
+		resp.DiffTruncated = result.Diff.Truncated
+		resp.FilesChanged = int32(result.Diff.FilesChanged)
+	}
+
+	return resp, nil
+}
+
+// GetWorkflowUsage returns the LLM usage of a workflow run
+func (s *Server) GetWorkflowUsage(ctx context.Context, req *pb.GetWorkflowUsageRequest) (*pb.LLMUsageTotals, error) {
+	totals, err := s.temporalClient.GetUsage(ctx, req.WorkflowId)
+	if errors.Is(err, temporal.ErrWorkflowNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		s.logger.Error("failed to get workflow usage", zap.Error(err))
+		return nil, err
+	}
+	return usageToProto(totals), nil
+}
+
+// GetUsage returns today's LLM spend and the configured budgets
+func (s *Server) GetUsage(ctx context.Context, req *pb.GetUsageRequest) (*pb.GetUsageResponse, error) {
+	spent, err := s.budget.SpentToday(ctx)
+	if err != nil {
+		s.logger.Error("failed to get usage", zap.Error(err))
+		return nil, err
+	}
+	config := s.budget.Config()
+	return &pb.GetUsageResponse{
+		SpentToday:   spent,
+		DailyBudget:  config.DailyBudget,
+		TicketBudget: config.TicketBudget,
+	}, nil
+}
+
+// ListArtifacts lists the artifacts archived for a workflow run
+func (s *Server) ListArtifacts(ctx context.Context, req *pb.ListArtifactsRequest) (*pb.ListArtifactsResponse, error) {
+	run, list, err := s.temporalClient.ListArtifacts(ctx, req.WorkflowId, req.RunId)
+	if err != nil {
+		return nil, s.artifactError(err)
+	}
+
+	resp := &pb.ListArtifactsResponse{
+		WorkflowId: run.WorkflowID,
+		RunId:      run.RunID,
+		Artifacts:  make([]*pb.Artifact, 0, len(list)),
+	}
+	for _, artifact := range list {
+		resp.Artifacts = append(resp.Artifacts, &pb.Artifact{
+			Name:       artifact.Name,
+			Size:       artifact.Size,
+			ModifiedAt: formatTime(artifact.ModTime),
+		})
+	}
+	return resp, nil
+}
+
+// GetArtifact returns an archived artifact of a workflow run
+func (s *Server) GetArtifact(ctx context.Context, req *pb.GetArtifactRequest) (*pb.GetArtifactResponse, error) {
+	data, err := s.temporalClient.GetArtifact(ctx, req.WorkflowId, req.RunId, req.Name)
+	if err != nil {
+		return nil, s.artifactError(err)
+	}
+	return &pb.GetArtifactResponse{
+		ContentType: artifacts.ContentType(req.Name),
+		Content:     data,
+	}, nil
+}
+
+// artifactError converts a failed artifact request to a gRPC status
+func (s *Server) artifactError(err error) error {
+	switch {
+	case errors.Is(err, artifacts.ErrNotConfigured):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, artifacts.ErrInvalidName):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, temporal.ErrWorkflowNotFound), errors.Is(err, artifacts.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		s.logger.Error("failed to read artifacts", zap.Error(err))
+		return err
+	}
+}
+
+// usageToProto converts usage totals to their protobuf message
+func usageToProto(totals *types.UsageTotals) *pb.LLMUsageTotals {
+	if totals == nil {
+		return nil
+	}
+	msg := &pb.LLMUsageTotals{
+		PromptTokens:     int64(totals.PromptTokens),
+		CompletionTokens: int64(totals.CompletionTokens),
+		Cost:             totals.Cost,
+		Calls:            make([]*pb.LLMUsage, 0, len(totals.Calls)),
+	}
+	for _, call := range totals.Calls {
+		msg.Calls = append(msg.Calls, &pb.LLMUsage{
+			Phase:            call.Phase,
+			Model:            call.Model,
+			PromptTokens:     int64(call.PromptTokens),
+			CompletionTokens: int64(call.CompletionTokens),
+			Cost:             call.Cost,
+			StepOrder:        int32(call.StepOrder),
+		})
+	}
+	return msg
+}
+
+// planToProto converts an implementation plan to its protobuf message
+func planToProto(plan *types.ImplementationPlan) *pb.ImplementationPlan {
+	msg := &pb.ImplementationPlan{
+		Summary:             plan.Summary,
+		FilesToModify:       plan.FilesToModify,
+		FilesToCreate:       plan.FilesToCreate,
+		EstimatedComplexity: plan.EstimatedComplexity,
+	}
+	for _, step := range plan.Steps {
+		dependsOn := make([]int32, 0, len(step.DependsOn))
+		for _, order := range step.DependsOn {
+			dependsOn = append(dependsOn, int32(order))
+		}
+		msg.Steps = append(msg.Steps, &pb.PlanStep{
+			Order:        int32(step.Order),
+			Description:  step.Description,
+			ActivityType: step.ActivityType,
+			Parameters:   step.Parameters,
+			DependsOn:    dependsOn,
+		})
+	}
+	return msg
+}
+
+// planFromProto converts a caller-supplied plan from its protobuf message
+func planFromProto(msg *pb.ImplementationPlan) *types.ImplementationPlan {
+	plan := &types.ImplementationPlan{
+		Summary:             msg.Summary,
+		Steps:               make([]types.PlanStep, 0, len(msg.Steps)),
+		FilesToModify:       msg.FilesToModify,
+		FilesToCreate:       msg.FilesToCreate,
+		EstimatedComplexity: msg.EstimatedComplexity,
+	}
+	for _, step := range msg.Steps {
+		dependsOn := make([]int, 0, len(step.DependsOn))
+		for _, order := range step.DependsOn {
+			dependsOn = append(dependsOn, int(order))
+		}
+		plan.Steps = append(plan.Steps, types.PlanStep{
+			Order:        int(step.Order),
+			Description:  step.Description,
+			ActivityType: step.ActivityType,
+			Parameters:   step.Parameters,
+			DependsOn:    dependsOn,
+		})
+	}
+	return plan
+}
+
+// formatTime formats t as RFC 3339, or returns "" for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -1,36 +1,56 @@
 package rest
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 
+	"github.com/clintrovert/khitomer/internal/artifacts"
+	"github.com/clintrovert/khitomer/internal/auth"
+	"github.com/clintrovert/khitomer/internal/leader"
 	"github.com/clintrovert/khitomer/internal/temporal"
+	"github.com/clintrovert/khitomer/internal/temporal/workflows"
+	"github.com/clintrovert/khitomer/internal/usage"
 	"github.com/clintrovert/khitomer/pkg/types"
 )
 
 // Handler handles REST API requests
 type Handler struct {
 	temporalClient *temporal.Client
+	trigger        *leader.Trigger
+	previewer      *leader.Previewer
+	budget         *leader.Budget
 	logger         *zap.Logger
 }
 
 // NewHandler creates a new REST handler
-func NewHandler(temporalClient *temporal.Client, logger *zap.Logger) *Handler {
+func NewHandler(temporalClient *temporal.Client, trigger *leader.Trigger, previewer *leader.Previewer, budget *leader.Budget, logger *zap.Logger) *Handler {
 	return &Handler{
 		temporalClient: temporalClient,
-		logger:          logger,
+		trigger:        trigger,
+		previewer:      previewer,
+		budget:         budget,
+		logger:         logger,
 	}
 }
 
-// StartWorkflowRequest represents a request to start a workflow
+// StartWorkflowRequest represents a request to start a workflow. The
+// repository defaults to the ticket's, and the planner is run unless a plan
+// is given.
 type StartWorkflowRequest struct {
-	JiraTicketID   string `json:"jira_ticket_id"`
-	RepositoryOwner string `json:"repository_owner"`
-	RepositoryName  string `json:"repository_name"`
-	BaseBranch     string `json:"base_branch"`
+	JiraTicketID    string              `json:"jira_ticket_id"`
+	RepositoryOwner string              `json:"repository_owner,omitempty"`
+	RepositoryName  string              `json:"repository_name,omitempty"`
+	BaseBranch      string              `json:"base_branch,omitempty"`
+	Plan            *ImplementationPlan `json:"plan,omitempty"`
 }
 
 // StartWorkflowResponse represents the response from starting a workflow
@@ -48,6 +68,136 @@ type GetWorkflowStatusResponse struct {
 	ErrorMessage string `json:"error_message,omitempty"`
 }
 
+// SubmitReviewRequest represents a decision on a review step
+type SubmitReviewRequest struct {
+	Approved bool   `json:"approved"`
+	Reviewer string `json:"reviewer"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// WorkflowSummary describes a workflow run in a listing
+type WorkflowSummary struct {
+	WorkflowID       string  `json:"workflow_id"`
+	RunID            string  `json:"run_id"`
+	Status           string  `json:"status"`
+	JiraTicket       string  `json:"jira_ticket,omitempty"`
+	Repository       string  `json:"repository,omitempty"`
+	Assignee         string  `json:"assignee,omitempty"`
+	Complexity       string  `json:"complexity,omitempty"`
+	PRNumber         int64   `json:"pr_number,omitempty"`
+	Outcome          string  `json:"outcome,omitempty"`
+	StartTime        string  `json:"start_time,omitempty"`
+	CloseTime        string  `json:"close_time,omitempty"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// ListWorkflowsResponse represents a page of workflows
+type ListWorkflowsResponse struct {
+	Workflows     []WorkflowSummary `json:"workflows"`
+	NextPageToken string            `json:"next_page_token,omitempty"`
+}
+
+// WorkflowEvent represents a workflow progress event
+type WorkflowEvent struct {
+	ID        int64             `json:"id"`
+	Type      string            `json:"type"`
+	Time      string            `json:"time"`
+	Step      string            `json:"step,omitempty"`
+	StepOrder int               `json:"step_order,omitempty"`
+	Message   string            `json:"message,omitempty"`
+	Data      map[string]string `json:"data,omitempty"`
+}
+
+// PreviewPlanRequest identifies a ticket to preview, by Jira key or inline
+type PreviewPlanRequest struct {
+	JiraTicketID    string `json:"jira_ticket_id,omitempty"`
+	Title           string `json:"title,omitempty"`
+	Description     string `json:"description,omitempty"`
+	RepositoryOwner string `json:"repository_owner,omitempty"`
+	RepositoryName  string `json:"repository_name,omitempty"`
+	BaseBranch      string `json:"base_branch,omitempty"`
+	IncludeDiff     bool   `json:"include_diff,omitempty"`
+}
+
+// PlanStep represents a step of an implementation plan
+type PlanStep struct {
+	Order        int               `json:"order"`
+	Description  string            `json:"description"`
+	ActivityType string            `json:"activity_type"`
+	Parameters   map[string]string `json:"parameters,omitempty"`
+	DependsOn    []int             `json:"depends_on,omitempty"`
+}
+
+// ImplementationPlan represents a plan generated by the planner
+type ImplementationPlan struct {
+	Summary             string     `json:"summary"`
+	Steps               []PlanStep `json:"steps"`
+	FilesToModify       []string   `json:"files_to_modify,omitempty"`
+	FilesToCreate       []string   `json:"files_to_create,omitempty"`
+	EstimatedComplexity string     `json:"estimated_complexity,omitempty"`
+}
+
+// PreviewPlanResponse represents what Khitomer would do for a ticket. The
+// codegen and diff fields are only set when the diff was requested.
+type PreviewPlanResponse struct {
+	JiraTicketID    string             `json:"jira_ticket_id,omitempty"`
+	Title           string             `json:"title"`
+	RepositoryOwner string             `json:"repository_owner"`
+	RepositoryName  string             `json:"repository_name"`
+	BaseBranch      string             `json:"base_branch"`
+	Plan            ImplementationPlan `json:"plan"`
+	CodegenSummary  string             `json:"codegen_summary,omitempty"`
+	ModifiedFiles   []string           `json:"modified_files,omitempty"`
+	CreatedFiles    []string           `json:"created_files,omitempty"`
+	Diff            string             `json:"diff,omitempty"`
+	DiffTruncated   bool               `json:"diff_truncated,omitempty"`
+	FilesChanged    int                `json:"files_changed,omitempty"`
+	Usage           *UsageTotals       `json:"usage,omitempty"`
+}
+
+// LLMUsage represents one LLM call made for a ticket
+type LLMUsage struct {
+	Phase            string  `json:"phase"`
+	Model            string  `json:"model"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+	StepOrder        int     `json:"step_order,omitempty"`
+}
+
+// UsageTotals represents the LLM usage of a workflow run or preview. Costs
+// are in US dollars.
+type UsageTotals struct {
+	PromptTokens     int        `json:"prompt_tokens"`
+	CompletionTokens int        `json:"completion_tokens"`
+	Cost             float64    `json:"cost"`
+	Calls            []LLMUsage `json:"calls"`
+}
+
+// GetUsageResponse represents today's LLM spend and the configured budgets.
+// Budgets of 0 are unlimited.
+type GetUsageResponse struct {
+	SpentToday   float64 `json:"spent_today"`
+	DailyBudget  float64 `json:"daily_budget"`
+	TicketBudget float64 `json:"ticket_budget"`
+}
+
+// Artifact describes an archived artifact of a workflow run
+type Artifact struct {
+	Name       string `json:"name"`
+	Size       int64  `json:"size"`
+	ModifiedAt string `json:"modified_at,omitempty"`
+}
+
+// ListArtifactsResponse lists the artifacts of a workflow run
+type ListArtifactsResponse struct {
+	WorkflowID string     `json:"workflow_id"`
+	RunID      string     `json:"run_id"`
+	Artifacts  []Artifact `json:"artifacts"`
+}
+
 // StartWorkflow handles POST /workflows
 func (h *Handler) StartWorkflow(w http.ResponseWriter, r *http.Request) {
 	var req StartWorkflowRequest
@@ -56,28 +206,27 @@ func (h *Handler) StartWorkflow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create task from request
-	task := &types.Task{
-		JiraTicketID:     req.JiraTicketID,
-		RepositoryOwner: req.RepositoryOwner,
-		RepositoryName:  req.RepositoryName,
-		BaseBranch:      req.BaseBranch,
+	startReq := leader.StartRequest{
+		TaskRequest: leader.TaskRequest{
+			JiraTicketID:    req.JiraTicketID,
+			RepositoryOwner: req.RepositoryOwner,
+			RepositoryName:  req.RepositoryName,
+			BaseBranch:      req.BaseBranch,
+		},
 	}
-
-	// Create minimal plan (for manual triggers, planning would be done separately)
-	plan := &types.ImplementationPlan{
-		Summary: "Manual workflow trigger",
-		Steps:   []types.PlanStep{},
+	if req.Plan != nil {
+		startReq.Plan = planFromRequest(req.Plan)
 	}
 
-	repo := &types.RepositoryInfo{
-		Owner:      req.RepositoryOwner,
-		Name:       req.RepositoryName,
-		BaseBranch: req.BaseBranch,
-		CloneURL:   "https://github.com/" + req.RepositoryOwner + "/" + req.RepositoryName,
+	workflowID, err := h.trigger.Start(r.Context(), startReq)
+	if errors.Is(err, leader.ErrInvalidRequest) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, usage.ErrBudgetExceeded) {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
 	}
-
-	workflowID, err := h.temporalClient.StartWorkflow(r.Context(), task, plan, repo)
 	if err != nil {
 		h.logger.Error("failed to start workflow", zap.Error(err))
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -93,6 +242,68 @@ func (h *Handler) StartWorkflow(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// ListWorkflows handles GET /workflows. Results can be filtered with the
+// jira_ticket, repository, assignee, complexity, outcome and status query
+// parameters and paged with page_size and page_token.
+func (h *Handler) ListWorkflows(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := temporal.ListFilter{
+		JiraTicket: query.Get("jira_ticket"),
+		Repository: query.Get("repository"),
+		Assignee:   query.Get("assignee"),
+		Complexity: query.Get("complexity"),
+		Outcome:    query.Get("outcome"),
+		Status:     query.Get("status"),
+	}
+
+	pageSize := 0
+	if value := query.Get("page_size"); value != "" {
+		var err error
+		pageSize, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "invalid page_size", http.StatusBadRequest)
+			return
+		}
+	}
+
+	summaries, next, err := h.temporalClient.ListWorkflows(r.Context(), filter, pageSize, query.Get("page_token"))
+	if errors.Is(err, temporal.ErrInvalidPageToken) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to list workflows", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := ListWorkflowsResponse{
+		Workflows:     make([]WorkflowSummary, 0, len(summaries)),
+		NextPageToken: next,
+	}
+	for _, s := range summaries {
+		resp.Workflows = append(resp.Workflows, WorkflowSummary{
+			WorkflowID:       s.WorkflowID,
+			RunID:            s.RunID,
+			Status:           s.Status,
+			JiraTicket:       s.JiraTicket,
+			Repository:       s.Repository,
+			Assignee:         s.Assignee,
+			Complexity:       s.Complexity,
+			PRNumber:         s.PRNumber,
+			Outcome:          s.Outcome,
+			StartTime:        formatTime(s.StartTime),
+			CloseTime:        formatTime(s.CloseTime),
+			PromptTokens:     s.Usage.PromptTokens,
+			CompletionTokens: s.Usage.CompletionTokens,
+			Cost:             s.Usage.Cost,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
 // GetWorkflowStatus handles GET /workflows/{id}
 func (h *Handler) GetWorkflowStatus(w http.ResponseWriter, r *http.Request) {
 	workflowID := chi.URLParam(r, "id")
@@ -126,10 +337,370 @@ func (h *Handler) CancelWorkflow(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"success": true}`))
 }
 
-// RegisterRoutes registers REST API routes
+// SubmitReview handles POST /workflows/{id}/steps/{order}/review
+func (h *Handler) SubmitReview(w http.ResponseWriter, r *http.Request) {
+	workflowID := chi.URLParam(r, "id")
+	order, err := strconv.Atoi(chi.URLParam(r, "order"))
+	if err != nil {
+		http.Error(w, "invalid step order", http.StatusBadRequest)
+		return
+	}
+
+	var req SubmitReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	decision := workflows.ReviewDecision{
+		Approved: req.Approved,
+		Reviewer: req.Reviewer,
+		Comment:  req.Comment,
+	}
+	if err := h.temporalClient.SubmitReview(r.Context(), workflowID, order, decision); err != nil {
+		h.logger.Error("failed to submit review", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"success": true}`))
+}
+
+// WatchWorkflow handles GET /workflows/{id}/events, streaming progress events
+// as Server-Sent Events until the workflow closes. Clients resume after the
+// Last-Event-ID header, or the last_event_id query parameter.
+func (h *Handler) WatchWorkflow(w http.ResponseWriter, r *http.Request) {
+	workflowID := chi.URLParam(r, "id")
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var afterID int64
+	if lastEventID != "" {
+		var err error
+		afterID, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || afterID < 0 {
+			http.Error(w, "invalid last event ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Headers are sent once the workflow is known to exist, with its first
+	// event or idle poll, so a missing workflow can still get a 404
+	started := false
+	start := func() {
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusOK)
+			flusher.Flush()
+			started = true
+		}
+	}
+	err := h.temporalClient.WatchWorkflow(r.Context(), workflowID, afterID, func(event workflows.ProgressEvent) error {
+		start()
+
+		data, err := json.Marshal(WorkflowEvent{
+			ID:        event.ID,
+			Type:      event.Type,
+			Time:      formatTime(event.Time),
+			Step:      event.Step,
+			StepOrder: event.StepOrder,
+			Message:   event.Message,
+			Data:      event.Data,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}, func() error {
+		// A comment keeps proxies from timing out the idle stream
+		start()
+		if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+	if err == nil {
+		// A closed workflow with no newer events gets an empty stream
+		start()
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if started {
+		// The stream has begun, so the error can only be logged
+		h.logger.Warn("workflow event stream ended", zap.String("workflow_id", workflowID), zap.Error(err))
+		return
+	}
+	if errors.Is(err, temporal.ErrWorkflowNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	h.logger.Error("failed to watch workflow", zap.Error(err))
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// PreviewPlan handles POST /plans, returning the plan for a ticket and
+// optionally its diff without touching the repository or Jira
+func (h *Handler) PreviewPlan(w http.ResponseWriter, r *http.Request) {
+	var req PreviewPlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	preview, err := h.previewer.Preview(r.Context(), leader.PreviewRequest{
+		TaskRequest: leader.TaskRequest{
+			JiraTicketID:    req.JiraTicketID,
+			Title:           req.Title,
+			Description:     req.Description,
+			RepositoryOwner: req.RepositoryOwner,
+			RepositoryName:  req.RepositoryName,
+			BaseBranch:      req.BaseBranch,
+		},
+		IncludeDiff: req.IncludeDiff,
+	})
+	if errors.Is(err, leader.ErrInvalidRequest) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, usage.ErrBudgetExceeded) {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to preview plan", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := PreviewPlanResponse{
+		JiraTicketID:    preview.Task.JiraTicketID,
+		Title:           preview.Task.Title,
+		RepositoryOwner: preview.Task.RepositoryOwner,
+		RepositoryName:  preview.Task.RepositoryName,
+		BaseBranch:      preview.Task.BaseBranch,
+		Plan:            planResponse(preview.Plan),
+		Usage:           usageResponse(preview.Usage),
+	}
+	if result := preview.Result; result != nil {
+		resp.CodegenSummary = result.Codegen.Summary
+		resp.ModifiedFiles = result.Codegen.ModifiedFiles
+		resp.CreatedFiles = result.Codegen.CreatedFiles
+		resp.Diff = result.Diff.Diff
+		resp.DiffTruncated = result.Diff.Truncated
+		resp.FilesChanged = result.Diff.FilesChanged
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetWorkflowUsage handles GET /workflows/{id}/usage
+func (h *Handler) GetWorkflowUsage(w http.ResponseWriter, r *http.Request) {
+	workflowID := chi.URLParam(r, "id")
+
+	totals, err := h.temporalClient.GetUsage(r.Context(), workflowID)
+	if errors.Is(err, temporal.ErrWorkflowNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to get workflow usage", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usageResponse(totals))
+}
+
+// GetUsage handles GET /usage, returning today's LLM spend and the budgets
+func (h *Handler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	spent, err := h.budget.SpentToday(r.Context())
+	if err != nil {
+		h.logger.Error("failed to get usage", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	config := h.budget.Config()
+	resp := GetUsageResponse{
+		SpentToday:   spent,
+		DailyBudget:  config.DailyBudget,
+		TicketBudget: config.TicketBudget,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ListArtifacts handles GET /workflows/{id}/artifacts, listing the artifacts
+// of the run given by the run_id query parameter or of the latest run
+func (h *Handler) ListArtifacts(w http.ResponseWriter, r *http.Request) {
+	workflowID := chi.URLParam(r, "id")
+
+	run, list, err := h.temporalClient.ListArtifacts(r.Context(), workflowID, r.URL.Query().Get("run_id"))
+	if err != nil {
+		h.artifactError(w, err)
+		return
+	}
+
+	resp := ListArtifactsResponse{
+		WorkflowID: run.WorkflowID,
+		RunID:      run.RunID,
+		Artifacts:  make([]Artifact, 0, len(list)),
+	}
+	for _, artifact := range list {
+		resp.Artifacts = append(resp.Artifacts, Artifact{
+			Name:       artifact.Name,
+			Size:       artifact.Size,
+			ModifiedAt: formatTime(artifact.ModTime),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetArtifact handles GET /workflows/{id}/artifacts/{name}, where the name
+// may contain slashes, returning the artifact's content
+func (h *Handler) GetArtifact(w http.ResponseWriter, r *http.Request) {
+	workflowID := chi.URLParam(r, "id")
+	name := chi.URLParam(r, "*")
+
+	data, err := h.temporalClient.GetArtifact(r.Context(), workflowID, r.URL.Query().Get("run_id"), name)
+	if err != nil {
+		h.artifactError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", artifacts.ContentType(name))
+	w.Write(data)
+}
+
+// artifactError writes the response for a failed artifact request
+func (h *Handler) artifactError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, artifacts.ErrNotConfigured):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+	case errors.Is(err, artifacts.ErrInvalidName):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, temporal.ErrWorkflowNotFound), errors.Is(err, artifacts.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		h.logger.Error("failed to read artifacts", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// usageResponse converts usage totals to their response
+func usageResponse(totals *types.UsageTotals) *UsageTotals {
+	if totals == nil {
+		return nil
+	}
+	resp := &UsageTotals{
+		PromptTokens:     totals.PromptTokens,
+		CompletionTokens: totals.CompletionTokens,
+		Cost:             totals.Cost,
+		Calls:            make([]LLMUsage, 0, len(totals.Calls)),
+	}
+	for _, call := range totals.Calls {
+		resp.Calls = append(resp.Calls, LLMUsage{
+			Phase:            call.Phase,
+			Model:            call.Model,
+			PromptTokens:     call.PromptTokens,
+			CompletionTokens: call.CompletionTokens,
+			Cost:             call.Cost,
+			StepOrder:        call.StepOrder,
+		})
+	}
+	return resp
+}
+
+// RegisterRoutes registers REST API routes. Callers must already be
+// authenticated; viewers can read workflows and operators can change them.
 func (h *Handler) RegisterRoutes(r chi.Router) {
-	r.Post("/workflows", h.StartWorkflow)
-	r.Get("/workflows/{id}", h.GetWorkflowStatus)
-	r.Delete("/workflows/{id}", h.CancelWorkflow)
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireRole(auth.RoleViewer))
+		r.Get("/workflows", h.ListWorkflows)
+		r.Get("/workflows/{id}", h.GetWorkflowStatus)
+		r.Get("/workflows/{id}/events", h.WatchWorkflow)
+		r.Get("/workflows/{id}/usage", h.GetWorkflowUsage)
+		r.Get("/workflows/{id}/artifacts", h.ListArtifacts)
+		r.Get("/workflows/{id}/artifacts/*", h.GetArtifact)
+		r.Get("/usage", h.GetUsage)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireRole(auth.RoleOperator))
+		r.Post("/workflows", h.StartWorkflow)
+		r.Delete("/workflows/{id}", h.CancelWorkflow)
+		r.Post("/workflows/{id}/steps/{order}/review", h.SubmitReview)
+		r.Post("/plans", h.PreviewPlan)
+	})
 }
 
+// formatTime formats t as RFC 3339, or returns "" for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// planResponse converts an implementation plan to its JSON representation
+func planResponse(plan *types.ImplementationPlan) ImplementationPlan {
+	resp := ImplementationPlan{
+		Summary:             plan.Summary,
+		Steps:               make([]PlanStep, 0, len(plan.Steps)),
+		FilesToModify:       plan.FilesToModify,
+		FilesToCreate:       plan.FilesToCreate,
+		EstimatedComplexity: plan.EstimatedComplexity,
+	}
+	for _, step := range plan.Steps {
+		resp.Steps = append(resp.Steps, PlanStep{
+			Order:        step.Order,
+			Description:  step.Description,
+			ActivityType: step.ActivityType,
+			Parameters:   step.Parameters,
+			DependsOn:    step.DependsOn,
+		})
+	}
+	return resp
+}
+
+// planFromRequest converts a caller-supplied plan from its JSON representation
+func planFromRequest(req *ImplementationPlan) *types.ImplementationPlan {
+	plan := &types.ImplementationPlan{
+		Summary:             req.Summary,
+		Steps:               make([]types.PlanStep, 0, len(req.Steps)),
+		FilesToModify:       req.FilesToModify,
+		FilesToCreate:       req.FilesToCreate,
+		EstimatedComplexity: req.EstimatedComplexity,
+	}
+	for _, step := range req.Steps {
+		plan.Steps = append(plan.Steps, types.PlanStep{
+			Order:        step.Order,
+			Description:  step.Description,
+			ActivityType: step.ActivityType,
+			Parameters:   step.Parameters,
+			DependsOn:    step.DependsOn,
+		})
+	}
+	return plan
+}
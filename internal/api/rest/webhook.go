@@ -0,0 +1,59 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/jira"
+	"github.com/clintrovert/khitomer/internal/leader"
+)
+
+// maxWebhookBody caps the size of webhook deliveries
+const maxWebhookBody = 1 << 20
+
+// JiraWebhookHandler receives Jira comment webhooks and runs the commands in
+// them
+type JiraWebhookHandler struct {
+	secret    string
+	commander *leader.Commander
+	logger    *zap.Logger
+}
+
+// NewJiraWebhookHandler creates a handler verifying deliveries with secret
+func NewJiraWebhookHandler(secret string, commander *leader.Commander, logger *zap.Logger) *JiraWebhookHandler {
+	return &JiraWebhookHandler{
+		secret:    secret,
+		commander: commander,
+		logger:    logger,
+	}
+}
+
+// ServeHTTP handles POST /webhooks/jira. Commands are run after the delivery
+// is acknowledged, so Jira doesn't time out waiting for them.
+func (h *JiraWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err := jira.VerifySignature(h.secret, body, r.Header.Get(jira.SignatureHeader)); err != nil {
+		h.logger.Warn("rejected jira webhook", zap.String("remote_addr", r.RemoteAddr), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	comment, err := jira.ParseWebhook(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if comment != nil {
+		go h.commander.Handle(context.WithoutCancel(r.Context()), comment)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,249 @@
+// Package artifacts archives what a ticket run was built from, so a bad pull
+// request can be traced back to its cause: the plan, every LLM prompt and
+// response, the diff made by each codegen attempt and every test report.
+//
+// Artifacts are kept in a blob store under <workflow ID>/<run ID>/<name>. The
+// leader archives the plan when it starts a run and workers archive what
+// their activities produce, so both must use the same store.
+package artifacts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// Backends
+const (
+	// BackendNone disables the archive
+	BackendNone = ""
+	// BackendFile keeps artifacts in a local directory
+	BackendFile = "file"
+	// BackendS3 keeps artifacts in an S3 bucket or an S3-compatible store
+	BackendS3 = "s3"
+)
+
+// ErrNotFound is returned for artifacts that don't exist
+var ErrNotFound = errors.New("artifact not found")
+
+// ErrNotConfigured is returned when no archive is configured
+var ErrNotConfigured = errors.New("artifact archive not configured")
+
+// ErrInvalidName is returned for artifact names and run IDs that can't be
+// used as keys
+var ErrInvalidName = errors.New("invalid artifact name")
+
+// PlanName is the name of the plan artifact
+const PlanName = "plan.json"
+
+// Object is a blob in a store
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Store is a blob store. Keys are slash-separated paths.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns ErrNotFound for missing keys
+	Get(ctx context.Context, key string) ([]byte, error)
+	// List returns the objects under prefix, which ends in a slash, ordered
+	// by key
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// Config configures the archive's store
+type Config struct {
+	// Backend is one of the Backend constants
+	Backend string
+	// Dir holds the artifacts of the file backend
+	Dir string
+	S3  S3Config
+}
+
+// Run identifies a workflow run
+type Run struct {
+	WorkflowID string
+	RunID      string
+}
+
+// prefix returns the key prefix of the run's artifacts
+func (r Run) prefix() (string, error) {
+	for _, segment := range []string{r.WorkflowID, r.RunID} {
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsAny(segment, "/\\") {
+			return "", fmt.Errorf("%w: run %s/%s", ErrInvalidName, r.WorkflowID, r.RunID)
+		}
+	}
+	return r.WorkflowID + "/" + r.RunID + "/", nil
+}
+
+// key returns the key of a run's artifact
+func (r Run) key(name string) (string, error) {
+	prefix, err := r.prefix()
+	if err != nil {
+		return "", err
+	}
+	if name == "" || path.Clean(name) != name || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") || strings.Contains(name, "\\") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return prefix + name, nil
+}
+
+// Artifact describes an archived artifact of a run
+type Artifact struct {
+	// Name is the artifact's path within the run, e.g. "diffs/step-01-attempt-1.diff"
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Archive reads and writes the artifacts of workflow runs
+type Archive struct {
+	store Store
+}
+
+// New creates the archive configured by cfg. It returns nil with
+// BackendNone.
+func New(ctx context.Context, cfg Config) (*Archive, error) {
+	var store Store
+	var err error
+	switch cfg.Backend {
+	case BackendNone:
+		return nil, nil
+	case BackendFile:
+		store, err = NewFileStore(cfg.Dir)
+	case BackendS3:
+		store, err = NewS3Store(ctx, cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown artifact backend %q", cfg.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewArchive(store), nil
+}
+
+// NewArchive creates an archive backed by store
+func NewArchive(store Store) *Archive {
+	return &Archive{store: store}
+}
+
+// Put stores a run's artifact, replacing any artifact of the same name
+func (a *Archive) Put(ctx context.Context, run Run, name string, data []byte) error {
+	key, err := run.key(name)
+	if err != nil {
+		return err
+	}
+	if err := a.store.Put(ctx, key, data); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	return nil
+}
+
+// PutJSON stores v as an indented JSON artifact
+func (a *Archive) PutJSON(ctx context.Context, run Run, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return a.Put(ctx, run, name, data)
+}
+
+// PutPlan stores a plan and, if it was generated, the exchange it was
+// generated from
+func (a *Archive) PutPlan(ctx context.Context, run Run, plan *types.ImplementationPlan) error {
+	if err := a.PutJSON(ctx, run, PlanName, plan); err != nil {
+		return err
+	}
+	if plan.Exchange == nil {
+		return nil
+	}
+	return a.PutJSON(ctx, run, ExchangeName(plan.Exchange.Usage, 0, 0), plan.Exchange)
+}
+
+// Get returns a run's artifact
+func (a *Archive) Get(ctx context.Context, run Run, name string) ([]byte, error) {
+	key, err := run.key(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := a.store.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// List returns a run's artifacts ordered by name
+func (a *Archive) List(ctx context.Context, run Run) ([]Artifact, error) {
+	prefix, err := run.prefix()
+	if err != nil {
+		return nil, err
+	}
+	objects, err := a.store.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+
+	artifacts := make([]Artifact, 0, len(objects))
+	for _, object := range objects {
+		artifacts = append(artifacts, Artifact{
+			Name:    strings.TrimPrefix(object.Key, prefix),
+			Size:    object.Size,
+			ModTime: object.ModTime,
+		})
+	}
+	return artifacts, nil
+}
+
+// ExchangeName names the artifact of an LLM exchange. Planning exchanges
+// are "llm/plan.json"; codegen and repair exchanges are numbered from 1
+// within their step's activity attempt.
+func ExchangeName(usage types.LLMUsage, attempt int32, index int) string {
+	if usage.Phase == types.UsagePhasePlan {
+		return "llm/plan.json"
+	}
+	return fmt.Sprintf("llm/%s-%s-%d.json", usage.Phase, stepAttempt(usage.StepOrder, attempt), index)
+}
+
+// DiffName names the diff made by a codegen step's activity attempt
+func DiffName(stepOrder int, attempt int32) string {
+	return "diffs/" + stepAttempt(stepOrder, attempt) + ".diff"
+}
+
+// TestReportName names the report of a test run. Step 0 is the final test
+// run after the plan's steps.
+func TestReportName(stepOrder int, attempt int32) string {
+	return "tests/" + stepAttempt(stepOrder, attempt) + ".json"
+}
+
+// stepAttempt names a step's activity attempt, padding the step so steps
+// sort in order
+func stepAttempt(stepOrder int, attempt int32) string {
+	if stepOrder == 0 {
+		return fmt.Sprintf("final-attempt-%d", attempt)
+	}
+	return fmt.Sprintf("step-%02d-attempt-%d", stepOrder, attempt)
+}
+
+// ContentType returns the media type artifacts named name are served with
+func ContentType(name string) string {
+	switch path.Ext(name) {
+	case ".json":
+		return "application/json"
+	case ".diff":
+		return "text/x-diff; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
@@ -0,0 +1,31 @@
+package artifacts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "artifacts"))
+	require.NoError(t, err)
+	testStore(t, store)
+}
+
+func TestFileStoreListSkipsPartialWrites(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(context.Background(), "run/plan.json", []byte("{}")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "run", tempPrefix+"123"), []byte("partial"), 0o644))
+
+	objects, err := store.List(context.Background(), "run")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, "run/plan.json", objects[0].Key)
+}
@@ -0,0 +1,203 @@
+package artifacts
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is a stand-in for a path-style S3 endpoint serving one bucket. It
+// supports just the PutObject, GetObject and ListObjectsV2 calls S3Store
+// makes, and lists pageSize objects per page so pagination is exercised.
+type fakeS3 struct {
+	bucket   string
+	pageSize int
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+type listBucketResult struct {
+	XMLName               xml.Name     `xml:"ListBucketResult"`
+	Name                  string       `xml:"Name"`
+	Prefix                string       `xml:"Prefix"`
+	KeyCount              int          `xml:"KeyCount"`
+	IsTruncated           bool         `xml:"IsTruncated"`
+	NextContinuationToken string       `xml:"NextContinuationToken,omitempty"`
+	Contents              []listObject `xml:"Contents"`
+}
+
+type listObject struct {
+	Key          string `xml:"Key"`
+	Size         int64  `xml:"Size"`
+	LastModified string `xml:"LastModified"`
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		s3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPut && key != "":
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			s3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		if strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") {
+			data = decodeAWSChunked(data)
+		}
+		f.objects[key] = data
+	case r.Method == http.MethodGet && key != "":
+		data, ok := f.objects[key]
+		if !ok {
+			s3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		f.list(w, r.URL.Query().Get("prefix"), r.URL.Query().Get("continuation-token"))
+	default:
+		s3Error(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// list writes the page of keys under prefix that starts at the offset in
+// token
+func (f *fakeS3) list(w http.ResponseWriter, prefix, token string) {
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	start, _ := strconv.Atoi(token)
+	end := min(start+f.pageSize, len(keys))
+	result := listBucketResult{Name: f.bucket, Prefix: prefix, KeyCount: end - start}
+	for _, key := range keys[start:end] {
+		result.Contents = append(result.Contents, listObject{
+			Key:          key,
+			Size:         int64(len(f.objects[key])),
+			LastModified: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC).Format(time.RFC3339),
+		})
+	}
+	if end < len(keys) {
+		result.IsTruncated = true
+		result.NextContinuationToken = strconv.Itoa(end)
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func s3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	io.WriteString(w, "<Error><Code>"+code+"</Code><Message>"+code+"</Message></Error>")
+}
+
+// decodeAWSChunked strips the chunk headers and trailers of an aws-chunked
+// body
+func decodeAWSChunked(data []byte) []byte {
+	var out []byte
+	rest := string(data)
+	for {
+		header, body, ok := strings.Cut(rest, "\r\n")
+		if !ok {
+			return out
+		}
+		sizeHex, _, _ := strings.Cut(header, ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil || size == 0 {
+			return out
+		}
+		out = append(out, body[:size]...)
+		rest = strings.TrimPrefix(body[size:], "\r\n")
+	}
+}
+
+func newTestS3Store(t *testing.T) *S3Store {
+	t.Helper()
+	server := httptest.NewServer(&fakeS3{bucket: "artifacts", pageSize: 2, objects: map[string][]byte{}})
+	t.Cleanup(server.Close)
+
+	store, err := NewS3Store(context.Background(), S3Config{
+		Bucket:          "artifacts",
+		Prefix:          "khitomer/",
+		Region:          "us-east-1",
+		Endpoint:        server.URL,
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+		UsePathStyle:    true,
+	})
+	require.NoError(t, err)
+	return store
+}
+
+func TestS3Store(t *testing.T) {
+	testStore(t, newTestS3Store(t))
+}
+
+// testStore checks the Store contract shared by every backend
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	_, err := store.Get(ctx, "implementation-PROJ-1/run/plan.json")
+	assert.True(t, errors.Is(err, ErrNotFound), "Get of a missing key returned %v", err)
+
+	blobs := map[string]string{
+		"implementation-PROJ-1/run-1/plan.json":         `{"Summary":"plan"}`,
+		"implementation-PROJ-1/run-1/diffs/step-1.diff": "diff --git a/x b/x\n",
+		"implementation-PROJ-1/run-1/tests/final.json":  `{"Passed":true}`,
+		"implementation-PROJ-1/run-2/plan.json":         `{"Summary":"replan"}`,
+		"implementation-PROJ-2/run-1/plan.json":         `{"Summary":"other"}`,
+	}
+	for key, data := range blobs {
+		require.NoError(t, store.Put(ctx, key, []byte(data)))
+	}
+
+	for key, data := range blobs {
+		got, err := store.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, data, string(got), key)
+	}
+
+	objects, err := store.List(ctx, "implementation-PROJ-1/run-1/")
+	require.NoError(t, err)
+	var keys []string
+	for _, object := range objects {
+		keys = append(keys, object.Key)
+		assert.Equal(t, int64(len(blobs[object.Key])), object.Size, object.Key)
+		assert.False(t, object.ModTime.IsZero(), object.Key)
+	}
+	sort.Strings(keys)
+	assert.Equal(t, []string{
+		"implementation-PROJ-1/run-1/diffs/step-1.diff",
+		"implementation-PROJ-1/run-1/plan.json",
+		"implementation-PROJ-1/run-1/tests/final.json",
+	}, keys)
+
+	objects, err = store.List(ctx, "implementation-PROJ-3/")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+)
+
+// APIKey is a static key and the identity it authenticates as
+type APIKey struct {
+	Name string
+	Role Role
+	Key  string
+}
+
+// ParseAPIKeys parses a comma-separated list of name:role:key entries
+func ParseAPIKeys(value string) ([]APIKey, error) {
+	var keys []APIKey
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid API key entry %q: expected name:role:key", parts[0])
+		}
+		role, err := ParseRole(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid API key %q: %w", parts[0], err)
+		}
+		keys = append(keys, APIKey{Name: parts[0], Role: role, Key: parts[2]})
+	}
+	return keys, nil
+}
+
+type hashedAPIKey struct {
+	name string
+	role Role
+	hash [sha256.Size]byte
+}
+
+// apiKeyAuthenticator matches keys by hash so comparisons take the same time
+// whatever the key
+type apiKeyAuthenticator struct {
+	keys []hashedAPIKey
+}
+
+func newAPIKeyAuthenticator(keys []APIKey) *apiKeyAuthenticator {
+	a := &apiKeyAuthenticator{}
+	for _, key := range keys {
+		a.keys = append(a.keys, hashedAPIKey{
+			name: key.Name,
+			role: key.Role,
+			hash: sha256.Sum256([]byte(key.Key)),
+		})
+	}
+	return a
+}
+
+func (a *apiKeyAuthenticator) authenticate(key string) (*Identity, error) {
+	hash := sha256.Sum256([]byte(key))
+
+	var match *hashedAPIKey
+	for i := range a.keys {
+		if subtle.ConstantTimeCompare(hash[:], a.keys[i].hash[:]) == 1 {
+			match = &a.keys[i]
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w: unknown API key", ErrUnauthenticated)
+	}
+
+	return &Identity{Subject: match.name, Role: match.role, Method: MethodAPIKey}, nil
+}
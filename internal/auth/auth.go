@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrUnauthenticated indicates the caller presented no credentials, or
+	// credentials that could not be verified
+	ErrUnauthenticated = errors.New("unauthenticated")
+
+	// ErrPermissionDenied indicates the caller's role does not allow the call
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// Role is what a caller is allowed to do. Each role includes the
+// permissions of the roles below it.
+type Role string
+
+const (
+	// RoleViewer can read workflow status, events and listings
+	RoleViewer Role = "viewer"
+	// RoleOperator can also start, cancel and review workflows and preview
+	// plans
+	RoleOperator Role = "operator"
+	// RoleAdmin has every permission
+	RoleAdmin Role = "admin"
+)
+
+// roleRanks orders roles from least to most privileged
+var roleRanks = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ParseRole parses a role name
+func ParseRole(name string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := roleRanks[role]; !ok {
+		return "", fmt.Errorf("unknown role %q", name)
+	}
+	return role, nil
+}
+
+// Allows reports whether r includes the permissions of required
+func (r Role) Allows(required Role) bool {
+	return roleRanks[r] >= roleRanks[required]
+}
+
+const (
+	// MethodAPIKey identifies callers authenticated by a static API key
+	MethodAPIKey = "api_key"
+	// MethodJWT identifies callers authenticated by an OIDC bearer token
+	MethodJWT = "jwt"
+	// MethodCertificate identifies callers authenticated by a TLS client
+	// certificate
+	MethodCertificate = "certificate"
+	// MethodNone identifies callers let through because no authentication is
+	// configured
+	MethodNone = "none"
+)
+
+// Identity is an authenticated caller
+type Identity struct {
+	Subject string
+	Role    Role
+	// Method is how the caller authenticated
+	Method string
+}
+
+// Credentials are what a caller presented with a request
+type Credentials struct {
+	// APIKey is taken from the X-API-Key header or x-api-key metadata
+	APIKey string
+	// BearerToken is taken from the Authorization header or metadata
+	BearerToken string
+	// Certificate is the caller's verified TLS client certificate
+	Certificate *x509.Certificate
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the caller's identity
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the caller's identity, if ctx carries one
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok
+}
+
+// Config configures an Authenticator. Each method is enabled by setting its
+// fields; with none enabled every caller is let through as an admin.
+type Config struct {
+	// APIKeys are the static keys accepted in the X-API-Key header
+	APIKeys []APIKey
+
+	// OIDCIssuer and OIDCAudience are required of bearer tokens
+	OIDCIssuer   string
+	OIDCAudience string
+	// JWKS is a file path or http(s) URL serving the issuer's signing keys
+	JWKS string
+	// RoleClaim names the token claim holding the caller's roles
+	RoleClaim string
+
+	// CertificateRoles maps TLS client certificate common names to roles
+	CertificateRoles map[string]Role
+}
+
+// Authenticator identifies callers from their credentials
+type Authenticator struct {
+	apiKeys *apiKeyAuthenticator
+	jwt     *jwtAuthenticator
+	certs   *certAuthenticator
+	logger  *zap.Logger
+}
+
+// NewAuthenticator creates an authenticator for the methods enabled in cfg
+func NewAuthenticator(cfg Config, logger *zap.Logger) (*Authenticator, error) {
+	a := &Authenticator{logger: logger}
+
+	if len(cfg.APIKeys) > 0 {
+		a.apiKeys = newAPIKeyAuthenticator(cfg.APIKeys)
+	}
+
+	if cfg.JWKS != "" {
+		if cfg.OIDCIssuer == "" || cfg.OIDCAudience == "" {
+			return nil, errors.New("OIDC issuer and audience are required with a JWKS")
+		}
+		var err error
+		a.jwt, err = newJWTAuthenticator(cfg.OIDCIssuer, cfg.OIDCAudience, cfg.JWKS, cfg.RoleClaim, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cfg.CertificateRoles) > 0 {
+		a.certs = &certAuthenticator{roles: cfg.CertificateRoles}
+	}
+
+	if !a.Enabled() {
+		logger.Warn("no authentication configured; all API callers are admins")
+	}
+
+	return a, nil
+}
+
+// Enabled reports whether any authentication method is configured
+func (a *Authenticator) Enabled() bool {
+	return a.apiKeys != nil || a.jwt != nil || a.certs != nil
+}
+
+// Authenticate identifies the caller presenting creds. An API key or bearer
+// token that fails verification is rejected even if a valid client
+// certificate was also presented.
+func (a *Authenticator) Authenticate(ctx context.Context, creds Credentials) (*Identity, error) {
+	if !a.Enabled() {
+		return &Identity{Subject: "anonymous", Role: RoleAdmin, Method: MethodNone}, nil
+	}
+
+	switch {
+	case creds.APIKey != "":
+		if a.apiKeys == nil {
+			return nil, fmt.Errorf("%w: API keys are not accepted", ErrUnauthenticated)
+		}
+		return a.apiKeys.authenticate(creds.APIKey)
+	case creds.BearerToken != "":
+		if a.jwt == nil {
+			return nil, fmt.Errorf("%w: bearer tokens are not accepted", ErrUnauthenticated)
+		}
+		return a.jwt.authenticate(ctx, creds.BearerToken)
+	case creds.Certificate != nil && a.certs != nil:
+		return a.certs.authenticate(creds.Certificate)
+	}
+	return nil, fmt.Errorf("%w: no credentials", ErrUnauthenticated)
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ParseCertificateRoles parses a comma-separated list of commonName:role
+// entries
+func ParseCertificateRoles(value string) (map[string]Role, error) {
+	roles := make(map[string]Role)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.LastIndex(entry, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid certificate role entry %q: expected commonName:role", entry)
+		}
+		role, err := ParseRole(entry[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate role for %q: %w", entry[:i], err)
+		}
+		roles[entry[:i]] = role
+	}
+	return roles, nil
+}
+
+// ServerTLSConfig loads a server certificate and, if clientCAFile is set,
+// verifies client certificates against it. Clients without a certificate
+// are still accepted so they can authenticate another way.
+func ServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in client CA %s", clientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return cfg, nil
+}
+
+// certAuthenticator identifies callers by the common name of a client
+// certificate the TLS handshake has already verified
+type certAuthenticator struct {
+	roles map[string]Role
+}
+
+func (a *certAuthenticator) authenticate(cert *x509.Certificate) (*Identity, error) {
+	name := cert.Subject.CommonName
+	role, ok := a.roles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: no role for certificate %q", ErrUnauthenticated, name)
+	}
+	return &Identity{Subject: name, Role: role, Method: MethodCertificate}, nil
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseGroupRoles parses comma-separated group:role entries, which grant the
+// members of a Jira group a role
+func ParseGroupRoles(value string) (map[string]Role, error) {
+	roles := make(map[string]Role)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.LastIndex(entry, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid group role entry %q: expected group:role", entry)
+		}
+		role, err := ParseRole(entry[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid role for group %q: %w", entry[:i], err)
+		}
+		roles[entry[:i]] = role
+	}
+	return roles, nil
+}
+
+// GroupRole returns the most privileged role granted to any of groups
+func GroupRole(groups []string, roles map[string]Role) (Role, bool) {
+	var best Role
+	for _, group := range groups {
+		role, ok := roles[group]
+		if ok && roleRanks[role] > roleRanks[best] {
+			best = role
+		}
+	}
+	return best, best != ""
+}
@@ -0,0 +1,305 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultRoleClaim is the token claim read for roles if none is configured
+	DefaultRoleClaim = "roles"
+
+	// jwksRefreshInterval is how long fetched signing keys are trusted before
+	// they are fetched again
+	jwksRefreshInterval = time.Hour
+	// jwksMinRefreshInterval limits refetches triggered by unknown key IDs
+	jwksMinRefreshInterval = time.Minute
+	jwksFetchTimeout       = 10 * time.Second
+)
+
+// signingMethods are the algorithms accepted for bearer tokens
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// jwtAuthenticator verifies OIDC bearer tokens against the issuer's JWKS
+type jwtAuthenticator struct {
+	issuer    string
+	audience  string
+	roleClaim string
+	jwks      *jwks
+}
+
+func newJWTAuthenticator(issuer, audience, source, roleClaim string, logger *zap.Logger) (*jwtAuthenticator, error) {
+	if roleClaim == "" {
+		roleClaim = DefaultRoleClaim
+	}
+
+	keys := &jwks{source: source, logger: logger}
+	if err := keys.refresh(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return &jwtAuthenticator{
+		issuer:    issuer,
+		audience:  audience,
+		roleClaim: roleClaim,
+		jwks:      keys,
+	}, nil
+}
+
+func (a *jwtAuthenticator) authenticate(ctx context.Context, raw string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(signingMethods))
+	_, err := parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return a.jwks.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid bearer token: %w", ErrUnauthenticated, err)
+	}
+
+	if !claims.VerifyIssuer(a.issuer, true) {
+		return nil, fmt.Errorf("%w: unexpected token issuer", ErrUnauthenticated)
+	}
+	if !claims.VerifyAudience(a.audience, true) {
+		return nil, fmt.Errorf("%w: unexpected token audience", ErrUnauthenticated)
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, fmt.Errorf("%w: token has no expiry", ErrUnauthenticated)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrUnauthenticated)
+	}
+
+	role, ok := highestRole(claims[a.roleClaim])
+	if !ok {
+		return nil, fmt.Errorf("%w: token for %q carries no known role in %q", ErrPermissionDenied, subject, a.roleClaim)
+	}
+
+	return &Identity{Subject: subject, Role: role, Method: MethodJWT}, nil
+}
+
+// highestRole returns the most privileged known role in a claim holding a
+// role name or a list of them
+func highestRole(claim interface{}) (Role, bool) {
+	var names []string
+	switch v := claim.(type) {
+	case string:
+		names = strings.Fields(v)
+	case []interface{}:
+		for _, name := range v {
+			if s, ok := name.(string); ok {
+				names = append(names, s)
+			}
+		}
+	}
+
+	var best Role
+	for _, name := range names {
+		role, err := ParseRole(name)
+		if err != nil {
+			continue
+		}
+		if best == "" || !best.Allows(role) {
+			best = role
+		}
+	}
+	return best, best != ""
+}
+
+// jwks holds the signing keys from a JSON Web Key Set file or URL. Keys are
+// reloaded periodically and when a token names a key that is not known yet.
+// While the source is unavailable, known keys keep being served and reloads
+// are attempted at most once per jwksMinRefreshInterval.
+type jwks struct {
+	source string
+	logger *zap.Logger
+
+	mu          sync.Mutex
+	keys        map[string]interface{}
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+func (j *jwks) key(ctx context.Context, kid string) (interface{}, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	key, ok := j.keys[kid]
+	if ok && time.Since(j.fetchedAt) < jwksRefreshInterval {
+		return key, nil
+	}
+	if time.Since(j.lastAttempt) >= jwksMinRefreshInterval {
+		if err := j.refreshLocked(ctx); err != nil {
+			j.logger.Warn("failed to refresh JWKS", zap.String("source", j.source), zap.Error(err))
+		} else {
+			key, ok = j.keys[kid]
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+func (j *jwks) refresh(ctx context.Context) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.refreshLocked(ctx)
+}
+
+func (j *jwks) refreshLocked(ctx context.Context) error {
+	j.lastAttempt = time.Now()
+	data, err := j.load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load JWKS: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	return nil
+}
+
+func (j *jwks) load(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(j.source, "https://") && !strings.HasPrefix(j.source, "http://") {
+		return os.ReadFile(j.source)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS returns the RSA and EC signature keys in a key set by key ID.
+// Keys of other types or for encryption are skipped.
+func parseJWKS(data []byte) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{})
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		var key interface{}
+		var err error
+		switch jwk.Kty {
+		case "RSA":
+			key, err = jwk.rsaKey()
+		case "EC":
+			key, err = jwk.ecKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys found")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jsonWebKey) ecKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x coordinate: %w", err)
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y coordinate: %w", err)
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on the curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"github.com/clintrovert/khitomer/internal/artifacts"
+)
+
+// Artifacts configures the run artifact archive. The leader and workers must
+// use the same store.
+type Artifacts struct {
+	// Backend is empty to disable the archive, "file" or "s3"
+	Backend string `yaml:"backend" env:"ARTIFACTS_BACKEND"`
+	// Dir holds the file backend's artifacts
+	Dir string      `yaml:"dir" env:"ARTIFACTS_DIR"`
+	S3  ArtifactsS3 `yaml:"s3"`
+}
+
+// ArtifactsS3 configures the S3 backend. Endpoint and PathStyle point it at
+// an S3-compatible store such as MinIO; unset credentials and region come
+// from the AWS SDK's usual sources.
+type ArtifactsS3 struct {
+	Bucket          string `yaml:"bucket" env:"ARTIFACTS_S3_BUCKET"`
+	Prefix          string `yaml:"prefix" env:"ARTIFACTS_S3_PREFIX"`
+	Region          string `yaml:"region" env:"ARTIFACTS_S3_REGION"`
+	Endpoint        string `yaml:"endpoint" env:"ARTIFACTS_S3_ENDPOINT"`
+	AccessKeyID     string `yaml:"access_key_id" env:"ARTIFACTS_S3_ACCESS_KEY_ID"`
+	SecretAccessKey Secret `yaml:"secret_access_key" env:"ARTIFACTS_S3_SECRET_ACCESS_KEY"`
+	PathStyle       bool   `yaml:"path_style" env:"ARTIFACTS_S3_PATH_STYLE"`
+}
+
+// Config returns the archive configuration
+func (a Artifacts) Config() artifacts.Config {
+	return artifacts.Config{
+		Backend: a.Backend,
+		Dir:     a.Dir,
+		S3: artifacts.S3Config{
+			Bucket:          a.S3.Bucket,
+			Prefix:          a.S3.Prefix,
+			Region:          a.S3.Region,
+			Endpoint:        a.S3.Endpoint,
+			AccessKeyID:     a.S3.AccessKeyID,
+			SecretAccessKey: a.S3.SecretAccessKey.Value,
+			UsePathStyle:    a.S3.PathStyle,
+		},
+	}
+}
+
+func (a Artifacts) validate(errs *[]error) {
+	oneOf(errs, "artifacts.backend", a.Backend, artifacts.BackendNone, artifacts.BackendFile, artifacts.BackendS3)
+	switch a.Backend {
+	case artifacts.BackendFile:
+		required(errs, "artifacts.dir", a.Dir)
+	case artifacts.BackendS3:
+		required(errs, "artifacts.s3.bucket", a.S3.Bucket)
+		if a.S3.AccessKeyID != "" {
+			required(errs, "artifacts.s3.secret_access_key", a.S3.SecretAccessKey.Value)
+		}
+	}
+}
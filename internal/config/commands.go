@@ -0,0 +1,47 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/clintrovert/khitomer/internal/auth"
+)
+
+// Commands configures /khitomer commands in Jira ticket comments
+type Commands struct {
+	Enabled bool `yaml:"enabled" env:"JIRA_COMMANDS"`
+	// PollInterval is how often the elected leader reads new comments on the
+	// polled projects' tickets; 0 relies on the webhook alone
+	PollInterval time.Duration `yaml:"poll_interval" env:"JIRA_COMMANDS_POLL_INTERVAL"`
+	// WebhookSecret enables POST /webhooks/jira, which verifies each
+	// delivery's signature with it
+	WebhookSecret Secret `yaml:"webhook_secret" env:"JIRA_WEBHOOK_SECRET"`
+	// GroupRoles are group:role entries giving the members of Jira groups
+	// the API role their commands are checked against
+	GroupRoles []string `yaml:"group_roles" env:"JIRA_GROUP_ROLES" reload:"true"`
+}
+
+// ParseGroupRoles parses the group role entries
+func (c Commands) ParseGroupRoles() (map[string]auth.Role, error) {
+	return auth.ParseGroupRoles(strings.Join(c.GroupRoles, ","))
+}
+
+func (c Commands) validate(errs *[]error) {
+	if !c.Enabled {
+		return
+	}
+	if c.PollInterval < 0 {
+		*errs = append(*errs, errors.New("commands.poll_interval must not be negative"))
+	}
+	if c.PollInterval == 0 && c.WebhookSecret.Value == "" {
+		*errs = append(*errs, errors.New("commands requires commands.poll_interval or commands.webhook_secret"))
+	}
+	roles, err := c.ParseGroupRoles()
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("commands.group_roles: %w", err))
+	} else if len(roles) == 0 {
+		*errs = append(*errs, errors.New("commands.group_roles must grant at least one group a role"))
+	}
+}
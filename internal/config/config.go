@@ -0,0 +1,290 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// PathEnv names the environment variable holding the config file path when
+// no -config flag is given
+const PathEnv = "KHITOMER_CONFIG"
+
+// Validator is a configuration that can check itself
+type Validator interface {
+	Validate() error
+}
+
+// completer is implemented by configurations with settings that don't map
+// to a single field, which are filled in after environment overrides
+type completer interface {
+	complete() error
+}
+
+// Load fills cfg, which should hold the defaults, from the YAML file at path
+// (if path is not empty) and then from environment variables, reads secrets
+// from files and validates the result. Unknown keys in the file are errors.
+//
+// A field tagged env:"NAME" is overridden by the environment variable NAME
+// when it is set. Lists are comma-separated.
+func Load(path string, cfg Validator) error {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	// Report every problem at once rather than one per restart
+	v := reflect.ValueOf(cfg).Elem()
+	errs := []error{applyEnv(v, ""), resolveSecrets(v, "")}
+	if c, ok := cfg.(completer); ok {
+		errs = append(errs, c.complete())
+	}
+	errs = append(errs, cfg.Validate())
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return nil
+}
+
+// Secret is a sensitive value that is never printed. In YAML it is given
+// either inline or as {file: path}; for an environment variable NAME it is
+// read from NAME or from the file named by NAME_FILE. Trailing newlines are
+// trimmed from files.
+type Secret struct {
+	Value string
+	File  string
+}
+
+// String redacts the value
+func (s Secret) String() string {
+	if s.Value == "" {
+		return ""
+	}
+	return "[redacted]"
+}
+
+// UnmarshalYAML accepts a plain value or a mapping with a file key
+func (s *Secret) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		s.Value, s.File = node.Value, ""
+		return nil
+	}
+
+	var ref struct {
+		File string `yaml:"file"`
+	}
+	if err := node.Decode(&ref); err != nil {
+		return err
+	}
+	s.Value, s.File = "", ref.File
+	return nil
+}
+
+var (
+	secretType   = reflect.TypeOf(Secret{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// applyEnv overrides fields from the environment variables named by their
+// env tags
+func applyEnv(v reflect.Value, prefix string) error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+		name := fieldName(prefix, field)
+
+		if field.Type.Kind() == reflect.Struct && field.Type != secretType {
+			if err := applyEnv(value, name); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		if field.Type == secretType {
+			if file := os.Getenv(key + "_FILE"); file != "" {
+				value.Set(reflect.ValueOf(Secret{File: file}))
+			}
+		}
+		raw := os.Getenv(key)
+		if raw == "" {
+			continue
+		}
+		if err := setFromString(value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s (from %s): %w", name, key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setFromString parses raw into a field of a supported type
+func setFromString(value reflect.Value, raw string) error {
+	switch {
+	case value.Type() == secretType:
+		value.Set(reflect.ValueOf(Secret{Value: raw}))
+	case value.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+	case value.Kind() == reflect.String:
+		value.SetString(raw)
+	case value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case value.Kind() == reflect.Int || value.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case value.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String:
+		value.Set(reflect.ValueOf(splitList(raw)))
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+// resolveSecrets reads secrets given as files
+func resolveSecrets(v reflect.Value, prefix string) error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+		name := fieldName(prefix, field)
+
+		switch {
+		case field.Type == secretType:
+			secret := value.Addr().Interface().(*Secret)
+			if secret.File == "" {
+				continue
+			}
+			data, err := os.ReadFile(secret.File)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: failed to read secret file: %w", name, err))
+				continue
+			}
+			secret.Value = strings.TrimRight(string(data), "\r\n")
+		case field.Type.Kind() == reflect.Struct:
+			if err := resolveSecrets(value, name); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RestartRequired lists the settings that differ between two configurations
+// of the same type and cannot be changed without a restart, i.e. those not
+// tagged reload:"true"
+func RestartRequired(old, updated interface{}) []string {
+	return changedFields(reflect.ValueOf(old).Elem(), reflect.ValueOf(updated).Elem(), "")
+}
+
+func changedFields(old, updated reflect.Value, prefix string) []string {
+	var changed []string
+	t := old.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("reload") == "true" {
+			continue
+		}
+		name := fieldName(prefix, field)
+
+		if field.Type.Kind() == reflect.Struct && field.Type != secretType {
+			changed = append(changed, changedFields(old.Field(i), updated.Field(i), name)...)
+			continue
+		}
+		if field.Type == secretType {
+			if old.Field(i).Interface().(Secret).Value != updated.Field(i).Interface().(Secret).Value {
+				changed = append(changed, name)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(old.Field(i).Interface(), updated.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// fieldName is the dotted YAML path of a field
+func fieldName(prefix string, field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// splitList splits a comma-separated setting, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// required reports an empty setting
+func required(errs *[]error, name, value string) {
+	if value == "" {
+		*errs = append(*errs, fmt.Errorf("%s is required", name))
+	}
+}
+
+// oneOf reports a setting that is not one of the allowed values
+func oneOf(errs *[]error, name, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	*errs = append(*errs, fmt.Errorf("%s must be one of %s, got %q", name, strings.Join(allowed, ", "), value))
+}
+
+// validateLogLevel reports an unknown log level
+func validateLogLevel(errs *[]error, level string) {
+	if _, err := zapcore.ParseLevel(level); err != nil {
+		*errs = append(*errs, fmt.Errorf("log_level: %w", err))
+	}
+}
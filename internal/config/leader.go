@@ -0,0 +1,354 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/clintrovert/khitomer/internal/auth"
+	"github.com/clintrovert/khitomer/internal/election"
+	"github.com/clintrovert/khitomer/internal/jira"
+	"github.com/clintrovert/khitomer/internal/leader"
+	"github.com/clintrovert/khitomer/internal/planner"
+	"github.com/clintrovert/khitomer/internal/temporal/workflows"
+)
+
+// defaultJiraStatus is polled for projects that list no statuses or JQL
+const defaultJiraStatus = "Ready for Development"
+
+// Leader configures the leader service
+type Leader struct {
+	LogLevel string `yaml:"log_level" env:"LOG_LEVEL" reload:"true"`
+
+	Temporal     Temporal     `yaml:"temporal"`
+	Jira         Jira         `yaml:"jira"`
+	Commands     Commands     `yaml:"commands"`
+	OpenAI       OpenAI       `yaml:"openai"`
+	API          API          `yaml:"api"`
+	Auth         Auth         `yaml:"auth"`
+	Workflow     Workflow     `yaml:"workflow"`
+	Orchestrator Orchestrator `yaml:"orchestrator" reload:"true"`
+	Election     Election     `yaml:"leader_election"`
+	Tracing      Tracing      `yaml:"tracing"`
+	Usage        Usage        `yaml:"usage" reload:"true"`
+	Artifacts    Artifacts    `yaml:"artifacts"`
+}
+
+// Temporal configures the Temporal connection
+type Temporal struct {
+	Address   string `yaml:"address" env:"TEMPORAL_ADDRESS"`
+	Namespace string `yaml:"namespace" env:"TEMPORAL_NAMESPACE"`
+	TaskQueue string `yaml:"task_queue" env:"TASK_QUEUE"`
+}
+
+// Jira configures the Jira connection and, for the leader, which tickets
+// are polled
+type Jira struct {
+	BaseURL  string `yaml:"base_url" env:"JIRA_BASE_URL"`
+	Username string `yaml:"username" env:"JIRA_USERNAME"`
+	Token    Secret `yaml:"token" env:"JIRA_TOKEN"`
+	// RepositoryField names the custom field holding a ticket's repository
+	RepositoryField string `yaml:"repository_field" env:"JIRA_CUSTOM_FIELD"`
+	// APIVersion is 2, or 3 on Jira Cloud to read and write descriptions
+	// and comments as rich text
+	APIVersion int `yaml:"api_version" env:"JIRA_API_VERSION"`
+
+	// Projects are polled for tickets in any of their statuses. The
+	// JIRA_PROJECT_KEY and JIRA_STATUS_FILTER variables, both
+	// comma-separated, replace the projects and their statuses, and
+	// JIRA_JQL and JIRA_ORDER_BY replace every project's filter and order.
+	Projects     []JiraProject `yaml:"projects" reload:"true"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"JIRA_POLL_INTERVAL" reload:"true"`
+}
+
+// JiraProject is a Jira project polled for ready tickets
+type JiraProject struct {
+	Key      string   `yaml:"key"`
+	Statuses []string `yaml:"statuses"`
+	// JQL further filters the project's tickets, e.g. by label, component
+	// or sprint. Without it, statuses default to Ready for Development.
+	JQL string `yaml:"jql"`
+	// OrderBy orders the project's tickets, e.g. "priority DESC"
+	OrderBy string `yaml:"order_by"`
+}
+
+// OpenAI configures the planner's model
+type OpenAI struct {
+	APIKey Secret `yaml:"api_key" env:"OPENAI_API_KEY"`
+	Model  string `yaml:"model" env:"OPENAI_MODEL"`
+}
+
+// API configures the REST and gRPC servers
+type API struct {
+	RESTPort string `yaml:"rest_port" env:"REST_PORT"`
+	GRPCPort string `yaml:"grpc_port" env:"GRPC_PORT"`
+	// GRPCTLSCert and GRPCTLSKey enable TLS on the gRPC server
+	GRPCTLSCert string `yaml:"grpc_tls_cert" env:"GRPC_TLS_CERT"`
+	GRPCTLSKey  string `yaml:"grpc_tls_key" env:"GRPC_TLS_KEY"`
+	// GRPCClientCA verifies gRPC client certificates
+	GRPCClientCA string `yaml:"grpc_client_ca" env:"GRPC_CLIENT_CA"`
+	// PublicURL is the REST server's external base URL, which pull requests
+	// link to for their run's artifacts
+	PublicURL string `yaml:"public_url" env:"API_PUBLIC_URL"`
+}
+
+// Auth configures API authentication
+type Auth struct {
+	// APIKeys are comma-separated name:role:key entries
+	APIKeys      Secret `yaml:"api_keys" env:"AUTH_API_KEYS"`
+	OIDCIssuer   string `yaml:"oidc_issuer" env:"AUTH_OIDC_ISSUER"`
+	OIDCAudience string `yaml:"oidc_audience" env:"AUTH_OIDC_AUDIENCE"`
+	OIDCJWKS     string `yaml:"oidc_jwks" env:"AUTH_OIDC_JWKS"`
+	RoleClaim    string `yaml:"oidc_role_claim" env:"AUTH_OIDC_ROLE_CLAIM"`
+	// CertificateRoles are commonName:role entries
+	CertificateRoles []string `yaml:"certificate_roles" env:"AUTH_CERT_ROLES"`
+}
+
+// Workflow configures the workflows the leader starts
+type Workflow struct {
+	CommitPerStep      bool   `yaml:"commit_per_step" env:"COMMIT_PER_STEP"`
+	FailedBranchPolicy string `yaml:"failed_branch_policy" env:"FAILED_BRANCH_POLICY"`
+	TemporalUIURL      string `yaml:"temporal_ui_url" env:"TEMPORAL_UI_URL"`
+}
+
+// Orchestrator bounds how polled tickets are processed
+type Orchestrator struct {
+	Workers          int           `yaml:"workers" env:"ORCHESTRATOR_WORKERS"`
+	MaxPerRepository int           `yaml:"max_per_repository" env:"ORCHESTRATOR_MAX_PER_REPOSITORY"`
+	TaskTimeout      time.Duration `yaml:"task_timeout" env:"ORCHESTRATOR_TASK_TIMEOUT"`
+	DrainTimeout     time.Duration `yaml:"drain_timeout" env:"ORCHESTRATOR_DRAIN_TIMEOUT"`
+}
+
+// Election configures leader election
+type Election struct {
+	// Backend is empty for a single leader, "postgres" or "file"
+	Backend     string        `yaml:"backend" env:"LEADER_ELECTION"`
+	PostgresURL Secret        `yaml:"postgres_url" env:"LEADER_ELECTION_POSTGRES_URL"`
+	LockID      int64         `yaml:"lock_id" env:"LEADER_ELECTION_LOCK_ID"`
+	File        string        `yaml:"file" env:"LEADER_ELECTION_FILE"`
+	Interval    time.Duration `yaml:"interval" env:"LEADER_ELECTION_INTERVAL"`
+}
+
+// DefaultLeader returns the leader configuration used for unset settings
+func DefaultLeader() *Leader {
+	return &Leader{
+		LogLevel: "info",
+		Temporal: Temporal{
+			Address:   "localhost:7233",
+			Namespace: "default",
+			TaskQueue: "implementation-queue",
+		},
+		Jira: Jira{
+			RepositoryField: "Repository",
+			APIVersion:      jira.APIVersion2,
+			PollInterval:    5 * time.Minute,
+		},
+		API: API{
+			RESTPort: "8080",
+			GRPCPort: "9090",
+		},
+		Auth: Auth{
+			RoleClaim: auth.DefaultRoleClaim,
+		},
+		Workflow: Workflow{
+			FailedBranchPolicy: workflows.BranchPolicyDelete,
+		},
+		Orchestrator: Orchestrator{
+			Workers:          leader.DefaultWorkers,
+			MaxPerRepository: leader.DefaultMaxPerRepository,
+			TaskTimeout:      leader.DefaultTaskTimeout,
+			DrainTimeout:     leader.DefaultDrainTimeout,
+		},
+		Election: Election{
+			LockID:   election.DefaultLockID,
+			File:     "/tmp/khitomer-leader.lock",
+			Interval: election.DefaultRetryInterval,
+		},
+		Tracing: DefaultTracing(),
+	}
+}
+
+// complete applies the Jira project variables and default statuses
+func (c *Leader) complete() error {
+	if keys := os.Getenv("JIRA_PROJECT_KEY"); keys != "" {
+		c.Jira.Projects = nil
+		for _, key := range splitList(keys) {
+			c.Jira.Projects = append(c.Jira.Projects, JiraProject{Key: key})
+		}
+	}
+	if statuses := os.Getenv("JIRA_STATUS_FILTER"); statuses != "" {
+		for i := range c.Jira.Projects {
+			c.Jira.Projects[i].Statuses = splitList(statuses)
+		}
+	}
+	if jql := os.Getenv("JIRA_JQL"); jql != "" {
+		for i := range c.Jira.Projects {
+			c.Jira.Projects[i].JQL = jql
+		}
+	}
+	if orderBy := os.Getenv("JIRA_ORDER_BY"); orderBy != "" {
+		for i := range c.Jira.Projects {
+			c.Jira.Projects[i].OrderBy = orderBy
+		}
+	}
+	for i := range c.Jira.Projects {
+		if len(c.Jira.Projects[i].Statuses) == 0 && c.Jira.Projects[i].JQL == "" {
+			c.Jira.Projects[i].Statuses = []string{defaultJiraStatus}
+		}
+	}
+	return nil
+}
+
+// Validate reports every invalid or missing setting
+func (c *Leader) Validate() error {
+	var errs []error
+
+	validateLogLevel(&errs, c.LogLevel)
+	c.Temporal.validate(&errs)
+
+	required(&errs, "jira.base_url", c.Jira.BaseURL)
+	required(&errs, "jira.username", c.Jira.Username)
+	required(&errs, "jira.token", c.Jira.Token.Value)
+	required(&errs, "jira.repository_field", c.Jira.RepositoryField)
+	c.Jira.validateAPIVersion(&errs)
+	if len(c.Jira.Projects) == 0 {
+		errs = append(errs, errors.New("jira.projects must list at least one project"))
+	}
+	for i, project := range c.Jira.Projects {
+		required(&errs, fmt.Sprintf("jira.projects[%d].key", i), project.Key)
+		if orderByClause.MatchString(project.JQL) {
+			errs = append(errs, fmt.Errorf("jira.projects[%d].jql must not order tickets, use order_by instead", i))
+		}
+	}
+	if c.Jira.PollInterval <= 0 {
+		errs = append(errs, errors.New("jira.poll_interval must be positive"))
+	}
+
+	required(&errs, "openai.api_key", c.OpenAI.APIKey.Value)
+	c.Usage.validate(&errs, c.OpenAI.model())
+
+	required(&errs, "api.rest_port", c.API.RESTPort)
+	required(&errs, "api.grpc_port", c.API.GRPCPort)
+	if (c.API.GRPCTLSCert == "") != (c.API.GRPCTLSKey == "") {
+		errs = append(errs, errors.New("api.grpc_tls_cert and api.grpc_tls_key must be set together"))
+	}
+	if c.API.GRPCClientCA != "" && c.API.GRPCTLSCert == "" {
+		errs = append(errs, errors.New("api.grpc_client_ca requires api.grpc_tls_cert"))
+	}
+
+	if _, err := auth.ParseAPIKeys(c.Auth.APIKeys.Value); err != nil {
+		errs = append(errs, fmt.Errorf("auth.api_keys: %w", err))
+	}
+	if c.Auth.OIDCJWKS != "" {
+		required(&errs, "auth.oidc_issuer", c.Auth.OIDCIssuer)
+		required(&errs, "auth.oidc_audience", c.Auth.OIDCAudience)
+	}
+	if len(c.Auth.CertificateRoles) > 0 {
+		if _, err := c.Auth.ParseCertificateRoles(); err != nil {
+			errs = append(errs, fmt.Errorf("auth.certificate_roles: %w", err))
+		}
+		if c.API.GRPCClientCA == "" {
+			errs = append(errs, errors.New("auth.certificate_roles requires api.grpc_client_ca"))
+		}
+	}
+
+	oneOf(&errs, "workflow.failed_branch_policy", c.Workflow.FailedBranchPolicy, workflows.BranchPolicyDelete, workflows.BranchPolicyKeep)
+
+	c.Orchestrator.validate(&errs)
+
+	oneOf(&errs, "leader_election.backend", c.Election.Backend, "", "postgres", "file")
+	switch c.Election.Backend {
+	case "postgres":
+		required(&errs, "leader_election.postgres_url", c.Election.PostgresURL.Value)
+	case "file":
+		required(&errs, "leader_election.file", c.Election.File)
+	}
+	if c.Election.Interval <= 0 {
+		errs = append(errs, errors.New("leader_election.interval must be positive"))
+	}
+
+	c.Commands.validate(&errs)
+	c.Tracing.validate(&errs)
+	c.Artifacts.validate(&errs)
+
+	return errors.Join(errs...)
+}
+
+// model returns the planner's model
+func (o OpenAI) model() string {
+	if o.Model == "" {
+		return planner.DefaultModel
+	}
+	return o.Model
+}
+
+// ParseCertificateRoles parses the certificate role entries
+func (a Auth) ParseCertificateRoles() (map[string]auth.Role, error) {
+	return auth.ParseCertificateRoles(strings.Join(a.CertificateRoles, ","))
+}
+
+func (j Jira) validateAPIVersion(errs *[]error) {
+	if j.APIVersion != jira.APIVersion2 && j.APIVersion != jira.APIVersion3 {
+		*errs = append(*errs, fmt.Errorf("jira.api_version must be %d or %d", jira.APIVersion2, jira.APIVersion3))
+	}
+}
+
+// orderByClause matches a JQL ORDER BY clause
+var orderByClause = regexp.MustCompile(`(?i)\border\s+by\b`)
+
+// PollerProjects returns the projects to poll
+func (j Jira) PollerProjects() []jira.Project {
+	projects := make([]jira.Project, 0, len(j.Projects))
+	for _, project := range j.Projects {
+		projects = append(projects, jira.Project{
+			Key:      project.Key,
+			Statuses: project.Statuses,
+			JQL:      project.JQL,
+			OrderBy:  project.OrderBy,
+		})
+	}
+	return projects
+}
+
+// ProjectKeys returns the keys of the polled projects
+func (j Jira) ProjectKeys() []string {
+	keys := make([]string, 0, len(j.Projects))
+	for _, project := range j.Projects {
+		keys = append(keys, project.Key)
+	}
+	return keys
+}
+
+// Config returns the orchestrator limits
+func (o Orchestrator) Config() leader.OrchestratorConfig {
+	return leader.OrchestratorConfig{
+		Workers:          o.Workers,
+		MaxPerRepository: o.MaxPerRepository,
+		TaskTimeout:      o.TaskTimeout,
+		DrainTimeout:     o.DrainTimeout,
+	}
+}
+
+func (o Orchestrator) validate(errs *[]error) {
+	if o.Workers <= 0 {
+		*errs = append(*errs, errors.New("orchestrator.workers must be positive"))
+	}
+	if o.MaxPerRepository <= 0 {
+		*errs = append(*errs, errors.New("orchestrator.max_per_repository must be positive"))
+	}
+	if o.TaskTimeout <= 0 {
+		*errs = append(*errs, errors.New("orchestrator.task_timeout must be positive"))
+	}
+	if o.DrainTimeout <= 0 {
+		*errs = append(*errs, errors.New("orchestrator.drain_timeout must be positive"))
+	}
+}
+
+func (t Temporal) validate(errs *[]error) {
+	required(errs, "temporal.address", t.Address)
+	required(errs, "temporal.namespace", t.Namespace)
+	required(errs, "temporal.task_queue", t.TaskQueue)
+}
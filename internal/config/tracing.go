@@ -0,0 +1,47 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/clintrovert/khitomer/internal/tracing"
+)
+
+// Tracing configures OpenTelemetry span export. The standard
+// OTEL_EXPORTER_OTLP_* variables also configure the OTLP exporter.
+type Tracing struct {
+	// Exporter is empty to disable tracing, "otlp", "stdout" or "file"
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER"`
+	// Endpoint is the OTLP gRPC collector address
+	Endpoint string `yaml:"endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	Insecure bool   `yaml:"insecure" env:"TRACING_OTLP_INSECURE"`
+	// File is written by the file exporter
+	File string `yaml:"file" env:"TRACING_FILE"`
+	// SampleRatio is the fraction of traces recorded
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// DefaultTracing returns the tracing configuration used for unset settings
+func DefaultTracing() Tracing {
+	return Tracing{SampleRatio: 1}
+}
+
+// Config returns the tracing configuration
+func (t Tracing) Config() tracing.Config {
+	return tracing.Config{
+		Exporter:    t.Exporter,
+		Endpoint:    t.Endpoint,
+		Insecure:    t.Insecure,
+		File:        t.File,
+		SampleRatio: t.SampleRatio,
+	}
+}
+
+func (t Tracing) validate(errs *[]error) {
+	oneOf(errs, "tracing.exporter", t.Exporter, tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout, tracing.ExporterFile)
+	if t.Exporter == tracing.ExporterFile {
+		required(errs, "tracing.file", t.File)
+	}
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		*errs = append(*errs, errors.New("tracing.sample_ratio must be between 0 and 1"))
+	}
+}
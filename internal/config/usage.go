@@ -0,0 +1,52 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/clintrovert/khitomer/internal/usage"
+)
+
+// Usage configures LLM cost accounting and budgets
+type Usage struct {
+	// Prices are model:prompt:completion entries in US dollars per million
+	// tokens. Calls to models without a price cost nothing.
+	Prices []string `yaml:"prices" env:"LLM_PRICES"`
+	// TicketBudget stops a ticket's run once its runs have spent this many
+	// US dollars; 0 is unlimited
+	TicketBudget float64 `yaml:"ticket_budget" env:"LLM_TICKET_BUDGET"`
+	// DailyBudget stops new and running runs once the UTC day's calls have
+	// cost this many US dollars; 0 is unlimited
+	DailyBudget float64 `yaml:"daily_budget" env:"LLM_DAILY_BUDGET"`
+}
+
+// Config returns the prices and budgets. The prices must have been checked
+// by Validate.
+func (u Usage) Config() usage.Config {
+	prices, _ := usage.ParsePrices(u.Prices)
+	return usage.Config{
+		Prices:       prices,
+		TicketBudget: u.TicketBudget,
+		DailyBudget:  u.DailyBudget,
+	}
+}
+
+func (u Usage) validate(errs *[]error, model string) {
+	prices, err := usage.ParsePrices(u.Prices)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("usage.prices: %w", err))
+		return
+	}
+	if u.TicketBudget < 0 {
+		*errs = append(*errs, errors.New("usage.ticket_budget must not be negative"))
+	}
+	if u.DailyBudget < 0 {
+		*errs = append(*errs, errors.New("usage.daily_budget must not be negative"))
+	}
+	// A budget can't be enforced if the planner's calls are free
+	if u.TicketBudget > 0 || u.DailyBudget > 0 {
+		if _, ok := prices[model]; !ok {
+			*errs = append(*errs, fmt.Errorf("usage.prices must price the planner model %q when a budget is set", model))
+		}
+	}
+}
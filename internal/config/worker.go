@@ -0,0 +1,194 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/clintrovert/khitomer/internal/github"
+	"github.com/clintrovert/khitomer/internal/guardrails"
+	"github.com/clintrovert/khitomer/internal/jira"
+	"github.com/clintrovert/khitomer/internal/scan"
+)
+
+// Worker configures the worker service
+type Worker struct {
+	LogLevel string `yaml:"log_level" env:"LOG_LEVEL" reload:"true"`
+
+	Temporal   Temporal   `yaml:"temporal"`
+	Metrics    Metrics    `yaml:"metrics"`
+	GitHub     GitHub     `yaml:"github"`
+	Jira       Jira       `yaml:"jira"`
+	Testing    Testing    `yaml:"testing" reload:"true"`
+	Scan       Scan       `yaml:"scan"`
+	Guardrails Guardrails `yaml:"guardrails" reload:"true"`
+	Commit     Commit     `yaml:"commit"`
+	Deploy     Deploy     `yaml:"deploy"`
+	Tracing    Tracing    `yaml:"tracing"`
+	Artifacts  Artifacts  `yaml:"artifacts"`
+}
+
+// Metrics configures the Prometheus metrics server
+type Metrics struct {
+	// Port serves /metrics; empty disables the server
+	Port string `yaml:"port" env:"METRICS_PORT"`
+}
+
+// GitHub configures repository access
+type GitHub struct {
+	Token        Secret `yaml:"token" env:"GITHUB_TOKEN"`
+	WorkspaceDir string `yaml:"workspace_dir" env:"WORKSPACE_DIR"`
+}
+
+// Testing configures test runs
+type Testing struct {
+	// CoverageThreshold is the minimum coverage of changed lines, in percent;
+	// zero disables the check
+	CoverageThreshold float64 `yaml:"coverage_threshold" env:"COVERAGE_THRESHOLD"`
+}
+
+// Scan configures the pre-commit scanner. DenyPaths are denied in addition
+// to the scanner's defaults unless ReplaceDenyPaths is set.
+type Scan struct {
+	DenyPaths        []string `yaml:"deny_paths" env:"SCAN_DENY_PATHS"`
+	ReplaceDenyPaths bool     `yaml:"replace_deny_paths" env:"SCAN_REPLACE_DENY_PATHS"`
+	MaxBinarySize    int64    `yaml:"max_binary_size" env:"SCAN_MAX_BINARY_SIZE"`
+	EntropyThreshold float64  `yaml:"entropy_threshold" env:"SCAN_ENTROPY_THRESHOLD"`
+}
+
+// Guardrails configures the change size and protected path limits. Zero
+// limits are disabled and unset lists use the checker's defaults.
+type Guardrails struct {
+	MaxFiles        int      `yaml:"max_files" env:"GUARDRAIL_MAX_FILES"`
+	MaxLinesAdded   int      `yaml:"max_lines_added" env:"GUARDRAIL_MAX_LINES_ADDED"`
+	MaxLinesRemoved int      `yaml:"max_lines_removed" env:"GUARDRAIL_MAX_LINES_REMOVED"`
+	ProtectedPaths  []string `yaml:"protected_paths" env:"GUARDRAIL_PROTECTED_PATHS"`
+	ProtectedOwners []string `yaml:"protected_owners" env:"GUARDRAIL_PROTECTED_OWNERS"`
+	Action          string   `yaml:"action" env:"GUARDRAIL_ACTION"`
+	ReviewLabel     string   `yaml:"review_label" env:"GUARDRAIL_REVIEW_LABEL"`
+}
+
+// Commit configures commit identity, messages and signing
+type Commit struct {
+	AuthorName     string `yaml:"author_name" env:"COMMIT_AUTHOR_NAME"`
+	AuthorEmail    string `yaml:"author_email" env:"COMMIT_AUTHOR_EMAIL"`
+	CommitterName  string `yaml:"committer_name" env:"COMMITTER_NAME"`
+	CommitterEmail string `yaml:"committer_email" env:"COMMITTER_EMAIL"`
+	// MessageTemplate is the path of a commit message template
+	MessageTemplate string `yaml:"message_template" env:"COMMIT_MESSAGE_TEMPLATE"`
+	// SigningFormat is empty to leave commits unsigned, "openpgp" or "ssh"
+	SigningFormat     string `yaml:"signing_format" env:"COMMIT_SIGNING_FORMAT"`
+	SigningKey        string `yaml:"signing_key" env:"COMMIT_SIGNING_KEY"`
+	SigningPassphrase Secret `yaml:"signing_passphrase" env:"COMMIT_SIGNING_PASSPHRASE"`
+}
+
+// Deploy configures deployment steps
+type Deploy struct {
+	// Script is run for deployment steps; empty disables them
+	Script string `yaml:"script" env:"DEPLOY_SCRIPT"`
+}
+
+// DefaultWorker returns the worker configuration used for unset settings
+func DefaultWorker() *Worker {
+	return &Worker{
+		LogLevel: "info",
+		Temporal: Temporal{
+			Address:   "localhost:7233",
+			Namespace: "default",
+			TaskQueue: "implementation-queue",
+		},
+		Metrics: Metrics{
+			Port: "9091",
+		},
+		Jira: Jira{
+			APIVersion: jira.APIVersion2,
+		},
+		GitHub: GitHub{
+			WorkspaceDir: "/tmp/khitomer-workspace",
+		},
+		Scan: Scan{
+			MaxBinarySize:    scan.DefaultMaxBinarySize,
+			EntropyThreshold: scan.DefaultEntropyThreshold,
+		},
+		Guardrails: Guardrails{
+			MaxFiles:        50,
+			MaxLinesAdded:   2000,
+			MaxLinesRemoved: 1000,
+			Action:          guardrails.ActionDraft,
+			ReviewLabel:     guardrails.DefaultReviewLabel,
+		},
+		Commit: Commit{
+			AuthorName:  "Khitomer Bot",
+			AuthorEmail: "khitomer@example.com",
+		},
+		Tracing: DefaultTracing(),
+	}
+}
+
+// Validate reports every invalid or missing setting
+func (c *Worker) Validate() error {
+	var errs []error
+
+	validateLogLevel(&errs, c.LogLevel)
+	c.Temporal.validate(&errs)
+
+	required(&errs, "github.token", c.GitHub.Token.Value)
+	required(&errs, "github.workspace_dir", c.GitHub.WorkspaceDir)
+
+	// Jira is optional for workers, but must be complete if configured
+	if c.Jira.BaseURL != "" || c.Jira.Username != "" || c.Jira.Token.Value != "" {
+		required(&errs, "jira.base_url", c.Jira.BaseURL)
+		required(&errs, "jira.username", c.Jira.Username)
+		required(&errs, "jira.token", c.Jira.Token.Value)
+		c.Jira.validateAPIVersion(&errs)
+	}
+
+	if c.Testing.CoverageThreshold < 0 || c.Testing.CoverageThreshold > 100 {
+		errs = append(errs, errors.New("testing.coverage_threshold must be between 0 and 100"))
+	}
+
+	if c.Scan.MaxBinarySize <= 0 {
+		errs = append(errs, errors.New("scan.max_binary_size must be positive"))
+	}
+	if c.Scan.EntropyThreshold < 0 {
+		errs = append(errs, errors.New("scan.entropy_threshold must not be negative"))
+	}
+
+	if c.Guardrails.MaxFiles < 0 || c.Guardrails.MaxLinesAdded < 0 || c.Guardrails.MaxLinesRemoved < 0 {
+		errs = append(errs, errors.New("guardrails limits must not be negative"))
+	}
+	oneOf(&errs, "guardrails.action", c.Guardrails.Action, guardrails.ActionDraft, guardrails.ActionFail)
+
+	required(&errs, "commit.author_name", c.Commit.AuthorName)
+	required(&errs, "commit.author_email", c.Commit.AuthorEmail)
+	oneOf(&errs, "commit.signing_format", c.Commit.SigningFormat, "", github.SigningFormatOpenPGP, github.SigningFormatSSH)
+	if c.Commit.SigningFormat != "" {
+		required(&errs, "commit.signing_key", c.Commit.SigningKey)
+	}
+
+	c.Tracing.validate(&errs)
+	c.Artifacts.validate(&errs)
+
+	return errors.Join(errs...)
+}
+
+// Config returns the pre-commit scanner configuration
+func (s Scan) Config() scan.Config {
+	return scan.Config{
+		DenyPaths:               s.DenyPaths,
+		ReplaceDefaultDenyPaths: s.ReplaceDenyPaths,
+		MaxBinarySize:           s.MaxBinarySize,
+		EntropyThreshold:        s.EntropyThreshold,
+	}
+}
+
+// Config returns the guardrail checker configuration
+func (g Guardrails) Config() guardrails.Config {
+	return guardrails.Config{
+		MaxFilesChanged: g.MaxFiles,
+		MaxLinesAdded:   g.MaxLinesAdded,
+		MaxLinesRemoved: g.MaxLinesRemoved,
+		ProtectedPaths:  g.ProtectedPaths,
+		ProtectedOwners: g.ProtectedOwners,
+		Action:          g.Action,
+		ReviewLabel:     g.ReviewLabel,
+	}
+}
@@ -0,0 +1,88 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ChangedLines maps repository-relative file paths to the set of lines that
+// were added or modified
+type ChangedLines map[string]map[int]bool
+
+// Add marks a line as changed
+func (c ChangedLines) Add(file string, line int) {
+	lines, ok := c[file]
+	if !ok {
+		lines = make(map[int]bool)
+		c[file] = lines
+	}
+	lines[line] = true
+}
+
+// ParseUnifiedDiff extracts added and modified lines from a unified diff.
+// The diff is expected to be generated with --unified=0 so that every line
+// in a hunk's new range is a changed line.
+func ParseUnifiedDiff(r io.Reader) (ChangedLines, error) {
+	changed := make(ChangedLines)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var file string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			file = strings.TrimPrefix(line, "+++ ")
+			if file == "/dev/null" {
+				file = ""
+				continue
+			}
+			file = strings.TrimPrefix(file, "b/")
+		case strings.HasPrefix(line, "@@ "):
+			if file == "" {
+				continue
+			}
+			start, count, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			for l := start; l < start+count; l++ {
+				changed.Add(file, l)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read diff: %w", err)
+	}
+
+	return changed, nil
+}
+
+// parseHunkHeader returns the start line and line count of the new-file
+// range in a hunk header such as "@@ -10,2 +12,3 @@"
+func parseHunkHeader(header string) (int, int, error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, fmt.Errorf("invalid hunk header: %q", header)
+	}
+
+	startStr, countStr, hasCount := strings.Cut(strings.TrimPrefix(fields[2], "+"), ",")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid hunk header %q: %w", header, err)
+	}
+
+	count := 1
+	if hasCount {
+		count, err = strconv.Atoi(countStr)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid hunk header %q: %w", header, err)
+		}
+	}
+
+	return start, count, nil
+}
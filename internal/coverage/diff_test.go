@@ -0,0 +1,103 @@
+package coverage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		diff    string
+		want    ChangedLines
+		wantErr bool
+	}{
+		{
+			name: "modified lines",
+			diff: `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -10,2 +10,3 @@ func main() {
+-	old()
+-	older()
++	updated()
++	added()
++	another()
+`,
+			want: ChangedLines{"main.go": {10: true, 11: true, 12: true}},
+		},
+		{
+			name: "pure add hunk",
+			diff: `--- a/main.go
++++ b/main.go
+@@ -4,0 +5,2 @@ import (
++	"fmt"
++	"os"
+`,
+			want: ChangedLines{"main.go": {5: true, 6: true}},
+		},
+		{
+			name: "single line hunk without count",
+			diff: `--- a/main.go
++++ b/main.go
+@@ -7 +7 @@
+-	a := 1
++	a := 2
+`,
+			want: ChangedLines{"main.go": {7: true}},
+		},
+		{
+			name: "pure delete hunk",
+			diff: `--- a/main.go
++++ b/main.go
+@@ -3,2 +2,0 @@
+-	unused()
+-	unused()
+`,
+			want: ChangedLines{},
+		},
+		{
+			name: "new and deleted files",
+			diff: `diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,3 +0,0 @@
+-package main
+-
+-func old() {}
+diff --git a/internal/new.go b/internal/new.go
+new file mode 100644
+--- /dev/null
++++ b/internal/new.go
+@@ -0,0 +1,2 @@
++package internal
++
+`,
+			want: ChangedLines{"internal/new.go": {1: true, 2: true}},
+		},
+		{
+			name: "invalid hunk header",
+			diff: `--- a/main.go
++++ b/main.go
+@@ -1,2 +x,3 @@
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, err := ParseUnifiedDiff(strings.NewReader(tt.diff))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, changed)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Profile maps repository-relative file paths to per-line coverage. A line
+// present in the map is instrumented; its value reports whether it was hit.
+type Profile map[string]map[int]bool
+
+// add records coverage for a single line, keeping it covered if any block hit it
+func (p Profile) add(file string, line int, covered bool) {
+	lines, ok := p[file]
+	if !ok {
+		lines = make(map[int]bool)
+		p[file] = lines
+	}
+	lines[line] = lines[line] || covered
+}
+
+// ParseGoProfile parses a profile written by `go test -coverprofile`. Import
+// paths are rewritten relative to the repository using modulePath.
+func ParseGoProfile(r io.Reader, modulePath string) (Profile, error) {
+	profile := make(Profile)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		// Format: name.go:startLine.startCol,endLine.endCol numStmts count
+		colon := strings.LastIndex(line, ":")
+		if colon == -1 {
+			return nil, fmt.Errorf("invalid profile line %d: %q", lineNo, line)
+		}
+		file := line[:colon]
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid profile line %d: %q", lineNo, line)
+		}
+
+		span := strings.Split(fields[0], ",")
+		if len(span) != 2 {
+			return nil, fmt.Errorf("invalid block span on line %d: %q", lineNo, fields[0])
+		}
+		start, err := parsePosition(span[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid block start on line %d: %w", lineNo, err)
+		}
+		end, err := parsePosition(span[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid block end on line %d: %w", lineNo, err)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid block count on line %d: %w", lineNo, err)
+		}
+
+		file = relativeToModule(file, modulePath)
+		for l := start; l <= end; l++ {
+			profile.add(file, l, count > 0)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// ParseLCOV parses an LCOV tracefile as produced by jest, c8, pytest-cov and
+// most other non-Go coverage tools. Absolute paths are made relative to
+// repoPath, and records for files outside it are skipped.
+func ParseLCOV(r io.Reader, repoPath string) (Profile, error) {
+	profile := make(Profile)
+	scanner := bufio.NewScanner(r)
+	var file string
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			file = strings.TrimPrefix(line, "SF:")
+			if filepath.IsAbs(file) {
+				rel, err := filepath.Rel(repoPath, file)
+				if err != nil || !filepath.IsLocal(rel) {
+					file = ""
+					continue
+				}
+				file = rel
+			}
+			file = filepath.ToSlash(file)
+		case strings.HasPrefix(line, "DA:"):
+			if file == "" {
+				continue
+			}
+			// Format: DA:<line>,<hits>[,<checksum>]
+			parts := strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if len(parts) < 2 {
+				continue
+			}
+			l, err := strconv.Atoi(parts[0])
+			if err != nil {
+				continue
+			}
+			hits, err := strconv.Atoi(parts[1])
+			if err != nil {
+				continue
+			}
+			profile.add(file, l, hits > 0)
+		case line == "end_of_record":
+			file = ""
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read lcov file: %w", err)
+	}
+
+	return profile, nil
+}
+
+// ReadModulePath returns the module path declared in the repository's go.mod
+func ReadModulePath(repoPath string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(repoPath, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read go.mod: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "module") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module")), `"`), nil
+		}
+	}
+
+	return "", fmt.Errorf("module directive not found in go.mod")
+}
+
+func parsePosition(s string) (int, error) {
+	line, _, _ := strings.Cut(s, ".")
+	return strconv.Atoi(line)
+}
+
+func relativeToModule(file, modulePath string) string {
+	if modulePath != "" && strings.HasPrefix(file, modulePath+"/") {
+		return strings.TrimPrefix(file, modulePath+"/")
+	}
+	return file
+}
@@ -0,0 +1,134 @@
+package coverage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGoProfile(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    string
+		modulePath string
+		want       Profile
+		wantErr    bool
+	}{
+		{
+			name: "module path stripped",
+			profile: `mode: set
+github.com/acme/api/main.go:3.13,5.2 1 1
+github.com/acme/api/internal/db/db.go:10.20,11.3 1 0
+`,
+			modulePath: "github.com/acme/api",
+			want: Profile{
+				"main.go":           {3: true, 4: true, 5: true},
+				"internal/db/db.go": {10: false, 11: false},
+			},
+		},
+		{
+			name: "other modules kept",
+			profile: `mode: count
+github.com/acme/apiserver/main.go:1.1,1.10 1 2
+`,
+			modulePath: "github.com/acme/api",
+			want:       Profile{"github.com/acme/apiserver/main.go": {1: true}},
+		},
+		{
+			name: "line covered by any block",
+			profile: `mode: atomic
+github.com/acme/api/main.go:3.13,4.2 1 0
+github.com/acme/api/main.go:4.5,5.2 1 3
+github.com/acme/api/main.go:5.3,6.2 1 0
+`,
+			modulePath: "github.com/acme/api",
+			want:       Profile{"main.go": {3: false, 4: true, 5: true, 6: false}},
+		},
+		{
+			name:       "invalid count",
+			profile:    "github.com/acme/api/main.go:3.13,5.2 1 x\n",
+			modulePath: "github.com/acme/api",
+			wantErr:    true,
+		},
+		{
+			name:       "missing fields",
+			profile:    "github.com/acme/api/main.go:3.13,5.2 1\n",
+			modulePath: "github.com/acme/api",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := ParseGoProfile(strings.NewReader(tt.profile), tt.modulePath)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, profile)
+		})
+	}
+}
+
+func TestParseLCOV(t *testing.T) {
+	tests := []struct {
+		name string
+		lcov string
+		want Profile
+	}{
+		{
+			name: "absolute paths made relative",
+			lcov: `TN:
+SF:/workspace/acme/web/src/app.js
+DA:1,1
+DA:2,0
+DA:3,4,abc123
+end_of_record
+SF:src/util.js
+DA:7,0
+end_of_record
+`,
+			want: Profile{
+				"src/app.js":  {1: true, 2: false, 3: true},
+				"src/util.js": {7: false},
+			},
+		},
+		{
+			name: "paths outside the repository skipped",
+			lcov: `SF:/workspace/acme/web-other/src/app.js
+DA:1,1
+end_of_record
+SF:/usr/lib/node_modules/lib/index.js
+DA:1,0
+end_of_record
+SF:/workspace/acme/web/src/app.js
+DA:5,1
+end_of_record
+`,
+			want: Profile{"src/app.js": {5: true}},
+		},
+		{
+			name: "malformed lines ignored",
+			lcov: `DA:1,1
+SF:/workspace/acme/web/src/app.js
+DA:x,1
+DA:2
+DA:3,1
+end_of_record
+DA:9,1
+`,
+			want: Profile{"src/app.js": {3: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := ParseLCOV(strings.NewReader(tt.lcov), "/workspace/acme/web")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, profile)
+		})
+	}
+}
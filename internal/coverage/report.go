@@ -0,0 +1,86 @@
+package coverage
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Report summarizes coverage for the whole repository and for the lines
+// touched by a change
+type Report struct {
+	Format string
+
+	// TotalCoverage is the line coverage percentage across all instrumented lines
+	TotalCoverage float64
+	TotalLines    int
+	CoveredLines  int
+
+	// DeltaCoverage is the line coverage percentage of instrumented lines that
+	// were added or modified by the change
+	DeltaCoverage     float64
+	DeltaLines        int
+	DeltaCoveredLines int
+	UncoveredChanges  []string
+
+	// Threshold is the minimum DeltaCoverage required; zero disables the check
+	Threshold      float64
+	BelowThreshold bool
+}
+
+// NewReport computes a coverage report from a profile and the set of changed
+// lines. A threshold of zero disables threshold checking.
+func NewReport(format string, profile Profile, changed ChangedLines, threshold float64) *Report {
+	report := &Report{
+		Format:    format,
+		Threshold: threshold,
+	}
+
+	for file, lines := range profile {
+		for line, covered := range lines {
+			report.TotalLines++
+			if covered {
+				report.CoveredLines++
+			}
+
+			if !changed[file][line] {
+				continue
+			}
+			report.DeltaLines++
+			if covered {
+				report.DeltaCoveredLines++
+			} else {
+				report.UncoveredChanges = append(report.UncoveredChanges, fmt.Sprintf("%s:%d", file, line))
+			}
+		}
+	}
+	sort.Strings(report.UncoveredChanges)
+
+	report.TotalCoverage = percent(report.CoveredLines, report.TotalLines)
+	report.DeltaCoverage = percent(report.DeltaCoveredLines, report.DeltaLines)
+
+	// Changes without instrumented lines (docs, config) can't fall below the threshold
+	if threshold > 0 && report.DeltaLines > 0 && report.DeltaCoverage < threshold {
+		report.BelowThreshold = true
+	}
+
+	return report
+}
+
+// Summary returns a one-line human readable summary of the report
+func (r *Report) Summary() string {
+	summary := fmt.Sprintf("total %.1f%% (%d/%d lines), changed lines %.1f%% (%d/%d lines)",
+		r.TotalCoverage, r.CoveredLines, r.TotalLines,
+		r.DeltaCoverage, r.DeltaCoveredLines, r.DeltaLines,
+	)
+	if r.BelowThreshold {
+		summary += fmt.Sprintf(", below threshold of %.1f%%", r.Threshold)
+	}
+	return summary
+}
+
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
@@ -0,0 +1,78 @@
+package coverage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReport(t *testing.T) {
+	profile := Profile{
+		"main.go":    {1: true, 2: true, 3: false, 4: false},
+		"handler.go": {10: true, 11: false},
+	}
+
+	tests := []struct {
+		name      string
+		changed   ChangedLines
+		threshold float64
+		want      Report
+	}{
+		{
+			name:      "below threshold",
+			changed:   ChangedLines{"main.go": {2: true, 3: true}, "handler.go": {11: true}},
+			threshold: 50,
+			want: Report{
+				DeltaCoverage:     100.0 / 3,
+				DeltaLines:        3,
+				DeltaCoveredLines: 1,
+				UncoveredChanges:  []string{"handler.go:11", "main.go:3"},
+				BelowThreshold:    true,
+			},
+		},
+		{
+			name:      "meets threshold",
+			changed:   ChangedLines{"main.go": {1: true, 2: true, 3: true, 4: true}},
+			threshold: 50,
+			want: Report{
+				DeltaCoverage:     50,
+				DeltaLines:        4,
+				DeltaCoveredLines: 2,
+				UncoveredChanges:  []string{"main.go:3", "main.go:4"},
+			},
+		},
+		{
+			name:      "threshold disabled",
+			changed:   ChangedLines{"main.go": {3: true}},
+			threshold: 0,
+			want: Report{
+				DeltaLines:       1,
+				UncoveredChanges: []string{"main.go:3"},
+			},
+		},
+		{
+			name:      "no changed lines",
+			changed:   ChangedLines{},
+			threshold: 80,
+		},
+		{
+			name:      "only uninstrumented lines changed",
+			changed:   ChangedLines{"README.md": {1: true}, "main.go": {99: true}},
+			threshold: 80,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewReport("go", profile, tt.changed, tt.threshold)
+
+			want := tt.want
+			want.Format = "go"
+			want.Threshold = tt.threshold
+			want.TotalLines = 6
+			want.CoveredLines = 3
+			want.TotalCoverage = 50
+			assert.Equal(t, &want, report)
+		})
+	}
+}
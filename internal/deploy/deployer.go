@@ -0,0 +1,29 @@
+package deploy
+
+import (
+	"context"
+)
+
+// Request describes a change to deploy
+type Request struct {
+	JiraKey   string
+	Owner     string
+	Repo      string
+	Branch    string
+	RepoPath  string
+	StepOrder int
+	// Parameters are the plan step's parameters
+	Parameters map[string]string
+}
+
+// Result describes a completed deployment
+type Result struct {
+	// URL is where the deployment can be reached, if it exposes one
+	URL    string
+	Output string
+}
+
+// Deployer deploys a change, e.g. to a preview environment
+type Deployer interface {
+	Deploy(ctx context.Context, req Request) (*Result, error)
+}
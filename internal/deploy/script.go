@@ -0,0 +1,83 @@
+package deploy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ScriptDeployer deploys by running a local script from the repository's
+// worktree. The request is passed in KHITOMER_* environment variables and
+// step parameters as KHITOMER_PARAM_<NAME>. The last line of standard output
+// that starts with http:// or https:// is reported as the deployment URL.
+type ScriptDeployer struct {
+	path string
+}
+
+// NewScriptDeployer creates a deployer that runs the script at path
+func NewScriptDeployer(path string) *ScriptDeployer {
+	return &ScriptDeployer{path: path}
+}
+
+// Deploy runs the script and waits for it to exit
+func (d *ScriptDeployer) Deploy(ctx context.Context, req Request) (*Result, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, d.path)
+	cmd.Dir = req.RepoPath
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(),
+		"KHITOMER_JIRA_KEY="+req.JiraKey,
+		"KHITOMER_REPO_OWNER="+req.Owner,
+		"KHITOMER_REPO_NAME="+req.Repo,
+		"KHITOMER_BRANCH="+req.Branch,
+		"KHITOMER_REPO_PATH="+req.RepoPath,
+		"KHITOMER_STEP="+strconv.Itoa(req.StepOrder),
+	)
+	for name, value := range req.Parameters {
+		cmd.Env = append(cmd.Env, "KHITOMER_PARAM_"+envName(name)+"="+value)
+	}
+
+	err := cmd.Run()
+	output := stdout.String() + stderr.String()
+	if err != nil {
+		return &Result{Output: output}, fmt.Errorf("deploy script failed: %w", err)
+	}
+
+	return &Result{
+		URL:    lastURL(stdout.String()),
+		Output: output,
+	}, nil
+}
+
+// lastURL returns the last line of output that is an http(s) URL
+func lastURL(output string) string {
+	var url string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
+			url = line
+		}
+	}
+	return url
+}
+
+// envName converts a parameter name to an environment variable suffix
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
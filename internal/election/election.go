@@ -0,0 +1,86 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultRetryInterval is how often a follower tries to take leadership,
+// and how often a leader checks it still holds it
+const DefaultRetryInterval = 2 * time.Second
+
+// ErrNotLeader is returned when leadership is released without being held
+var ErrNotLeader = errors.New("not leader")
+
+// Elector grants leadership to one replica at a time
+type Elector interface {
+	// Acquire blocks until this replica is the leader or ctx is done. The
+	// returned channel is closed if leadership is lost.
+	Acquire(ctx context.Context) (<-chan struct{}, error)
+
+	// Release gives up leadership so another replica can take over
+	Release() error
+}
+
+// Run calls lead each time this replica becomes the leader, with a context
+// that is cancelled when leadership is lost or ctx is done. Leadership is
+// released once lead returns. Run returns when ctx is done.
+func Run(ctx context.Context, elector Elector, lead func(ctx context.Context) error, logger *zap.Logger) {
+	for {
+		lost, err := elector.Acquire(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Error("failed to acquire leadership", zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(DefaultRetryInterval):
+			}
+			continue
+		}
+
+		logger.Info("acquired leadership")
+		leadCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-lost:
+				logger.Warn("lost leadership")
+				cancel()
+			case <-leadCtx.Done():
+			}
+		}()
+
+		if err := lead(leadCtx); err != nil && leadCtx.Err() == nil {
+			logger.Error("leader stopped", zap.Error(err))
+		}
+		cancel()
+
+		if err := elector.Release(); err != nil {
+			logger.Warn("failed to release leadership", zap.Error(err))
+		} else {
+			logger.Info("released leadership")
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// Always is an elector for a single replica, which is always the leader
+type Always struct{}
+
+// Acquire returns immediately
+func (Always) Acquire(ctx context.Context) (<-chan struct{}, error) {
+	return make(chan struct{}), nil
+}
+
+// Release does nothing
+func (Always) Release() error {
+	return nil
+}
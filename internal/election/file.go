@@ -0,0 +1,89 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// FileElector holds leadership with an exclusive lock on a local file, for
+// replicas sharing a host or a filesystem with working locks. The operating
+// system releases the lock when the leader exits.
+type FileElector struct {
+	path     string
+	interval time.Duration
+	logger   *zap.Logger
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileElector creates an elector contending for the lock file at path.
+// Followers retry every interval.
+func NewFileElector(path string, interval time.Duration, logger *zap.Logger) *FileElector {
+	if interval <= 0 {
+		interval = DefaultRetryInterval
+	}
+	return &FileElector{
+		path:     path,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Acquire blocks until the file is locked or ctx is done. The lock is held
+// until Release, so the returned channel is never closed.
+func (e *FileElector) Acquire(ctx context.Context) (<-chan struct{}, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for {
+		file, err := os.OpenFile(e.path, os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open lock file: %w", err)
+		}
+
+		acquired, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock file: %w", err)
+		}
+		if acquired {
+			// Record the holder for anyone inspecting the file
+			hostname, _ := os.Hostname()
+			if err := file.Truncate(0); err == nil {
+				fmt.Fprintf(file, "%s %d\n", hostname, os.Getpid())
+			}
+			e.file = file
+			return make(chan struct{}), nil
+		}
+		file.Close()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(e.interval):
+		}
+	}
+}
+
+// Release unlocks and closes the lock file
+func (e *FileElector) Release() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.file == nil {
+		return ErrNotLeader
+	}
+	err := unlockFile(e.file)
+	e.file.Close()
+	e.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to unlock file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package election
+
+import (
+	"errors"
+	"os"
+)
+
+var errFileLockUnsupported = errors.New("file locks are not supported on this platform")
+
+func tryLockFile(file *os.File) (bool, error) {
+	return false, errFileLockUnsupported
+}
+
+func unlockFile(file *os.File) error {
+	return errFileLockUnsupported
+}
@@ -0,0 +1,151 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// DefaultLockID is the advisory lock key used if none is configured
+const DefaultLockID int64 = 0x6b6869746f6d6572 // "khitomer"
+
+// PostgresElector holds leadership with a session-level Postgres advisory
+// lock. If the leader dies its connection closes and Postgres releases the
+// lock, so a follower takes over on its next attempt.
+type PostgresElector struct {
+	url      string
+	lockID   int64
+	interval time.Duration
+	logger   *zap.Logger
+
+	mu   sync.Mutex
+	conn *pgx.Conn
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewPostgresElector creates an elector contending for lockID in the
+// database at url. Followers retry, and the leader checks its connection,
+// every interval.
+func NewPostgresElector(url string, lockID int64, interval time.Duration, logger *zap.Logger) *PostgresElector {
+	if interval <= 0 {
+		interval = DefaultRetryInterval
+	}
+	return &PostgresElector{
+		url:      url,
+		lockID:   lockID,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Acquire blocks until the advisory lock is held or ctx is done
+func (e *PostgresElector) Acquire(ctx context.Context) (<-chan struct{}, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for {
+		acquired, err := e.tryLock(ctx)
+		if err != nil {
+			e.logger.Warn("failed to try leader lock", zap.Error(err))
+			e.closeConn()
+		}
+		if acquired {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			e.closeConn()
+			return nil, ctx.Err()
+		case <-time.After(e.interval):
+		}
+	}
+
+	lost := make(chan struct{})
+	e.stop = make(chan struct{})
+	e.done = make(chan struct{})
+	go e.monitor(e.conn, lost, e.stop, e.done)
+	return lost, nil
+}
+
+// tryLock connects if needed and makes one attempt at the lock
+func (e *PostgresElector) tryLock(ctx context.Context) (bool, error) {
+	if e.conn == nil {
+		conn, err := pgx.Connect(ctx, e.url)
+		if err != nil {
+			return false, fmt.Errorf("failed to connect: %w", err)
+		}
+		e.conn = conn
+	}
+
+	var acquired bool
+	err := e.conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", e.lockID).Scan(&acquired)
+	if err != nil {
+		return false, fmt.Errorf("failed to query lock: %w", err)
+	}
+	return acquired, nil
+}
+
+// monitor checks the connection holding the lock until stopped, closing
+// lost if it fails. A broken connection means Postgres may already have
+// given the lock to another replica.
+func (e *PostgresElector) monitor(conn *pgx.Conn, lost, stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+			err := conn.Ping(ctx)
+			cancel()
+			if err != nil {
+				e.logger.Warn("leader lock connection failed", zap.Error(err))
+				close(lost)
+				return
+			}
+		}
+	}
+}
+
+// Release unlocks the advisory lock and closes the connection
+func (e *PostgresElector) Release() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.stop == nil {
+		return ErrNotLeader
+	}
+	close(e.stop)
+	<-e.done
+	e.stop, e.done = nil, nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+	defer cancel()
+	_, err := e.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", e.lockID)
+	// Closing the session releases the lock even if unlocking failed
+	e.closeConn()
+	if err != nil {
+		return fmt.Errorf("failed to unlock: %w", err)
+	}
+	return nil
+}
+
+func (e *PostgresElector) closeConn() {
+	if e.conn == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+	defer cancel()
+	e.conn.Close(ctx)
+	e.conn = nil
+}
@@ -2,30 +2,46 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/google/go-github/v57/github"
-	"golang.org/x/oauth2"
 	"go.uber.org/zap"
+	"golang.org/x/oauth2"
 
+	"github.com/clintrovert/khitomer/internal/metrics"
+	"github.com/clintrovert/khitomer/internal/tracing"
 	"github.com/clintrovert/khitomer/pkg/types"
 )
 
+// previewDirName is the workspace subdirectory holding temporary clones
+const previewDirName = ".preview"
+
 // Client wraps GitHub API and Git operations
 type Client struct {
 	apiClient    *github.Client
 	logger       *zap.Logger
 	accessToken  string
 	workspaceDir string
+	commitConfig CommitConfig
 }
 
 // NewClient creates a new GitHub client
-func NewClient(accessToken, workspaceDir string, logger *zap.Logger) *Client {
-	ctx := context.Background()
+func NewClient(accessToken, workspaceDir string, commitConfig CommitConfig, logger *zap.Logger) *Client {
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{
+		Transport: metrics.InstrumentTransport("github", tracing.InstrumentTransport("github", nil)),
+	})
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: accessToken},
 	)
@@ -36,13 +52,15 @@ func NewClient(accessToken, workspaceDir string, logger *zap.Logger) *Client {
 		logger:       logger,
 		accessToken:  accessToken,
 		workspaceDir: workspaceDir,
+		commitConfig: commitConfig,
 	}
 }
 
-// CloneRepository clones a GitHub repository to the workspace
-func (c *Client) CloneRepository(ctx context.Context, owner, repo, branch string) (string, error) {
+// CloneRepository clones a GitHub repository to the workspace, writing git
+// progress messages to progress
+func (c *Client) CloneRepository(ctx context.Context, owner, repo, branch string, progress io.Writer) (string, error) {
 	repoPath := filepath.Join(c.workspaceDir, owner, repo)
-	
+
 	// Remove existing directory if it exists
 	if _, err := os.Stat(repoPath); err == nil {
 		os.RemoveAll(repoPath)
@@ -53,16 +71,45 @@ func (c *Client) CloneRepository(ctx context.Context, owner, repo, branch string
 		return "", fmt.Errorf("failed to create directory: %w", err)
 	}
 
+	if err := c.clone(ctx, repoPath, owner, repo, branch, progress); err != nil {
+		return "", err
+	}
+	return repoPath, nil
+}
+
+// CloneRepositoryTemp clones a GitHub repository into a new directory under
+// the workspace's preview directory, leaving the shared clone used by
+// implementation runs alone. The caller removes it with RemoveTempRepository.
+func (c *Client) CloneRepositoryTemp(ctx context.Context, owner, repo, branch string, progress io.Writer) (string, error) {
+	previewDir := filepath.Join(c.workspaceDir, previewDirName)
+	if err := os.MkdirAll(previewDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	repoPath, err := os.MkdirTemp(previewDir, owner+"-"+repo+"-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := c.clone(ctx, repoPath, owner, repo, branch, progress); err != nil {
+		os.RemoveAll(repoPath)
+		return "", err
+	}
+	return repoPath, nil
+}
+
+// clone clones a GitHub repository's branch into repoPath
+func (c *Client) clone(ctx context.Context, repoPath, owner, repo, branch string, progress io.Writer) error {
 	cloneURL := fmt.Sprintf("https://%s@github.com/%s/%s.git", c.accessToken, owner, repo)
-	
+
 	_, err := git.PlainCloneContext(ctx, repoPath, false, &git.CloneOptions{
 		URL:           cloneURL,
 		ReferenceName: plumbing.NewBranchReferenceName(branch),
 		SingleBranch:  true,
-		Progress:      os.Stdout,
+		Progress:      progress,
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to clone repository: %w", err)
+		return fmt.Errorf("failed to clone repository: %w", classifyGitError(err))
 	}
 
 	c.logger.Info("cloned repository",
@@ -71,7 +118,7 @@ func (c *Client) CloneRepository(ctx context.Context, owner, repo, branch string
 		zap.String("path", repoPath),
 	)
 
-	return repoPath, nil
+	return nil
 }
 
 // GetRepositoryPath returns the path to a cloned repository
@@ -79,6 +126,40 @@ func (c *Client) GetRepositoryPath(owner, repo string) string {
 	return filepath.Join(c.workspaceDir, owner, repo)
 }
 
+// RemoveRepository deletes a cloned repository from the workspace
+func (c *Client) RemoveRepository(owner, repo string) error {
+	repoPath := c.GetRepositoryPath(owner, repo)
+	if err := os.RemoveAll(repoPath); err != nil {
+		return fmt.Errorf("failed to remove repository: %w", err)
+	}
+
+	c.logger.Info("removed repository",
+		zap.String("path", repoPath),
+	)
+
+	return nil
+}
+
+// RemoveTempRepository deletes a clone made by CloneRepositoryTemp. Paths
+// outside the preview directory are refused.
+func (c *Client) RemoveTempRepository(repoPath string) error {
+	previewDir := filepath.Join(c.workspaceDir, previewDirName)
+	rel, err := filepath.Rel(previewDir, repoPath)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("refusing to remove %s: not a temporary clone", repoPath)
+	}
+
+	if err := os.RemoveAll(repoPath); err != nil {
+		return fmt.Errorf("failed to remove repository: %w", err)
+	}
+
+	c.logger.Info("removed repository",
+		zap.String("path", repoPath),
+	)
+
+	return nil
+}
+
 // CreateBranch creates a new branch from the base branch
 func (c *Client) CreateBranch(repoPath, baseBranch, newBranch string) error {
 	r, err := git.PlainOpen(repoPath)
@@ -116,41 +197,83 @@ func (c *Client) CreateBranch(repoPath, baseBranch, newBranch string) error {
 	return nil
 }
 
-// CommitChanges commits changes to the repository
-func (c *Client) CommitChanges(repoPath, message string) error {
+// CommitChanges stages all non-ignored changes, scans them for sensitive
+// content and commits them using the configured identity, message template
+// and signer. It returns the new commit's SHA, or an empty SHA if there was
+// nothing to commit. Scan findings are returned as a *scan.FindingsError.
+func (c *Client) CommitChanges(repoPath string, msg types.CommitMessage) (string, error) {
+	message, err := c.RenderCommitMessage(msg)
+	if err != nil {
+		return "", err
+	}
+
 	r, err := git.PlainOpen(repoPath)
 	if err != nil {
-		return fmt.Errorf("failed to open repository: %w", err)
+		return "", fmt.Errorf("failed to open repository: %w", err)
 	}
 
 	w, err := r.Worktree()
 	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
+		return "", fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	// Add all changes
-	_, err = w.Add(".")
+	// Add all changes, honoring .gitignore files throughout the worktree
+	patterns, err := gitignore.ReadPatterns(w.Filesystem, nil)
 	if err != nil {
-		return fmt.Errorf("failed to add changes: %w", err)
+		return "", fmt.Errorf("failed to read gitignore patterns: %w", err)
+	}
+	w.Excludes = append(w.Excludes, patterns...)
+
+	err = w.AddWithOptions(&git.AddOptions{All: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to add changes: %w", err)
+	}
+
+	if c.commitConfig.Scanner != nil {
+		if err := c.scanStagedChanges(r, w, repoPath); err != nil {
+			return "", err
+		}
 	}
 
 	// Commit
-	_, err = w.Commit(message, &git.CommitOptions{
-		Author: &git.Signature{
-			Name:  "Khitomer Bot",
-			Email: "khitomer@example.com",
-		},
+	now := time.Now()
+	author := &object.Signature{
+		Name:  c.commitConfig.Author.Name,
+		Email: c.commitConfig.Author.Email,
+		When:  now,
+	}
+	committer := author
+	if c.commitConfig.Committer.Email != "" {
+		committer = &object.Signature{
+			Name:  c.commitConfig.Committer.Name,
+			Email: c.commitConfig.Committer.Email,
+			When:  now,
+		}
+	}
+
+	hash, err := w.Commit(message, &git.CommitOptions{
+		Author:    author,
+		Committer: committer,
+		Signer:    c.commitConfig.Signer,
 	})
+	if errors.Is(err, git.ErrEmptyCommit) {
+		c.logger.Info("no changes to commit",
+			zap.String("repo_path", repoPath),
+		)
+		return "", nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to commit: %w", err)
+		return "", fmt.Errorf("failed to commit: %w", err)
 	}
 
 	c.logger.Info("committed changes",
 		zap.String("message", message),
 		zap.String("repo_path", repoPath),
+		zap.String("sha", hash.String()),
+		zap.Bool("signed", c.commitConfig.Signer != nil),
 	)
 
-	return nil
+	return hash.String(), nil
 }
 
 // PushBranch pushes a branch to GitHub
@@ -166,11 +289,11 @@ func (c *Client) PushBranch(ctx context.Context, repoPath, branch string) error
 	}
 
 	err = remote.PushContext(ctx, &git.PushOptions{
-		RefSpecs: []git.RefSpec{git.RefSpec(fmt.Sprintf("refs/heads/%s:refs/heads/%s", branch, branch))},
+		RefSpecs: []config.RefSpec{config.RefSpec(fmt.Sprintf("refs/heads/%s:refs/heads/%s", branch, branch))},
 		Auth:     nil, // Will use token from URL
 	})
 	if err != nil {
-		return fmt.Errorf("failed to push branch: %w", err)
+		return fmt.Errorf("failed to push branch: %w", classifyGitError(err))
 	}
 
 	c.logger.Info("pushed branch",
@@ -181,18 +304,44 @@ func (c *Client) PushBranch(ctx context.Context, repoPath, branch string) error
 	return nil
 }
 
-// CreatePullRequest creates a pull request
-func (c *Client) CreatePullRequest(ctx context.Context, owner, repo, baseBranch, headBranch, title, body string) (*types.PRInfo, error) {
+// DeleteBranch deletes a branch from GitHub. Deleting a branch that does not
+// exist succeeds.
+func (c *Client) DeleteBranch(ctx context.Context, owner, repo, branch string) error {
+	resp, err := c.apiClient.Git.DeleteRef(ctx, owner, repo, "heads/"+branch)
+	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnprocessableEntity) {
+			c.logger.Info("branch already deleted",
+				zap.String("owner", owner),
+				zap.String("repo", repo),
+				zap.String("branch", branch),
+			)
+			return nil
+		}
+		return fmt.Errorf("failed to delete branch: %w", classifyAPIError(err))
+	}
+
+	c.logger.Info("deleted branch",
+		zap.String("owner", owner),
+		zap.String("repo", repo),
+		zap.String("branch", branch),
+	)
+
+	return nil
+}
+
+// CreatePullRequest creates a pull request, optionally as a draft
+func (c *Client) CreatePullRequest(ctx context.Context, owner, repo, baseBranch, headBranch, title, body string, draft bool) (*types.PRInfo, error) {
 	newPR := &github.NewPullRequest{
 		Title: github.String(title),
 		Head:  github.String(headBranch),
 		Base:  github.String(baseBranch),
 		Body:  github.String(body),
+		Draft: github.Bool(draft),
 	}
 
 	pr, _, err := c.apiClient.PullRequests.Create(ctx, owner, repo, newPR)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create pull request: %w", err)
+		return nil, fmt.Errorf("failed to create pull request: %w", classifyAPIError(err))
 	}
 
 	prInfo := &types.PRInfo{
@@ -201,6 +350,7 @@ func (c *Client) CreatePullRequest(ctx context.Context, owner, repo, baseBranch,
 		Title:       pr.GetTitle(),
 		Description: pr.GetBody(),
 		Status:      pr.GetState(),
+		Draft:       pr.GetDraft(),
 	}
 
 	c.logger.Info("created pull request",
@@ -208,8 +358,25 @@ func (c *Client) CreatePullRequest(ctx context.Context, owner, repo, baseBranch,
 		zap.String("repo", repo),
 		zap.Int64("pr_number", prInfo.PRNumber),
 		zap.String("pr_url", prInfo.PRURL),
+		zap.Bool("draft", prInfo.Draft),
 	)
 
 	return prInfo, nil
 }
 
+// AddLabels adds labels to an issue or pull request
+func (c *Client) AddLabels(ctx context.Context, owner, repo string, number int, labels []string) error {
+	_, _, err := c.apiClient.Issues.AddLabelsToIssue(ctx, owner, repo, number, labels)
+	if err != nil {
+		return fmt.Errorf("failed to add labels: %w", classifyAPIError(err))
+	}
+
+	c.logger.Info("added labels",
+		zap.String("owner", owner),
+		zap.String("repo", repo),
+		zap.Int("number", number),
+		zap.Strings("labels", labels),
+	)
+
+	return nil
+}
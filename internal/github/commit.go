@@ -0,0 +1,125 @@
+package github
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/go-git/go-git/v5"
+
+	"github.com/clintrovert/khitomer/internal/scan"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// DefaultCommitMessageTemplate renders "type(KEY): subject" followed by the
+// optional body and the plan summary
+const DefaultCommitMessageTemplate = `{{.Type}}{{if .JiraKey}}({{.JiraKey}}){{end}}: {{.Subject}}
+{{- if .Body}}
+
+{{.Body}}
+{{- end}}
+{{- if .PlanSummary}}
+
+{{.PlanSummary}}
+{{- end}}`
+
+// issueTypeCommitTypes maps Jira issue types to conventional-commit types
+var issueTypeCommitTypes = map[string]string{
+	"bug":           "fix",
+	"defect":        "fix",
+	"documentation": "docs",
+	"chore":         "chore",
+	"tech debt":     "refactor",
+	"improvement":   "feat",
+	"story":         "feat",
+	"task":          "feat",
+	"sub-task":      "feat",
+	"subtask":       "feat",
+	"feature":       "feat",
+}
+
+// Identity is a git author or committer identity
+type Identity struct {
+	Name  string
+	Email string
+}
+
+// String formats the identity as "Name <email>"
+func (i Identity) String() string {
+	return fmt.Sprintf("%s <%s>", i.Name, i.Email)
+}
+
+// CommitConfig configures how the client creates commits
+type CommitConfig struct {
+	Author Identity
+	// Committer defaults to Author when empty
+	Committer Identity
+
+	// Signer signs commits; nil leaves commits unsigned
+	Signer git.Signer
+
+	// MessageTemplate renders a types.CommitMessage; nil uses DefaultCommitMessageTemplate
+	MessageTemplate *template.Template
+
+	// Scanner scans staged changes before committing; nil disables scanning
+	Scanner *scan.Scanner
+}
+
+// ParseCommitMessageTemplate parses a commit message template
+func ParseCommitMessageTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("commit").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse commit message template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// LoadCommitMessageTemplate reads and parses a commit message template file
+func LoadCommitMessageTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit message template: %w", err)
+	}
+	return ParseCommitMessageTemplate(string(data))
+}
+
+// ConventionalCommitType returns the conventional-commit type for a Jira issue type
+func ConventionalCommitType(issueType string) string {
+	if t, ok := issueTypeCommitTypes[strings.ToLower(strings.TrimSpace(issueType))]; ok {
+		return t
+	}
+	return "feat"
+}
+
+// RenderCommitMessage renders msg through the configured template and
+// appends Co-authored-by trailers
+func (c *Client) RenderCommitMessage(msg types.CommitMessage) (string, error) {
+	if msg.Type == "" {
+		msg.Type = ConventionalCommitType(msg.IssueType)
+	}
+
+	tmpl := c.commitConfig.MessageTemplate
+	if tmpl == nil {
+		var err error
+		tmpl, err = ParseCommitMessageTemplate(DefaultCommitMessageTemplate)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, msg); err != nil {
+		return "", fmt.Errorf("failed to render commit message: %w", err)
+	}
+
+	message := strings.TrimSpace(sb.String())
+	if len(msg.CoAuthors) > 0 {
+		message += "\n\n"
+		for _, coAuthor := range msg.CoAuthors {
+			message += "Co-authored-by: " + coAuthor + "\n"
+		}
+	}
+
+	return message, nil
+}
@@ -0,0 +1,59 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/google/go-github/v57/github"
+)
+
+var (
+	// ErrAuthFailed indicates the access token was rejected or lacks the
+	// permissions needed for an operation
+	ErrAuthFailed = errors.New("github authentication failed")
+
+	// ErrRepositoryNotFound indicates the repository does not exist or is not
+	// visible to the access token
+	ErrRepositoryNotFound = errors.New("github repository not found")
+
+	// ErrPullRequestExists indicates an open pull request already exists for
+	// the head branch
+	ErrPullRequestExists = errors.New("pull request already exists")
+)
+
+// classifyGitError tags go-git transport errors that retrying cannot fix
+func classifyGitError(err error) error {
+	switch {
+	case errors.Is(err, transport.ErrAuthenticationRequired), errors.Is(err, transport.ErrAuthorizationFailed):
+		return fmt.Errorf("%w: %w", ErrAuthFailed, err)
+	case errors.Is(err, transport.ErrRepositoryNotFound):
+		return fmt.Errorf("%w: %w", ErrRepositoryNotFound, err)
+	}
+	return err
+}
+
+// classifyAPIError tags GitHub API errors that retrying cannot fix. Rate
+// limit errors have their own types and stay retryable.
+func classifyAPIError(err error) error {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return err
+	}
+
+	switch errResp.Response.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %w", ErrAuthFailed, err)
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrRepositoryNotFound, err)
+	case http.StatusUnprocessableEntity:
+		for _, e := range errResp.Errors {
+			if strings.Contains(e.Message, "already exists") {
+				return fmt.Errorf("%w: %w", ErrPullRequestExists, err)
+			}
+		}
+	}
+	return err
+}
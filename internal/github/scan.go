@@ -0,0 +1,136 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+
+	"github.com/clintrovert/khitomer/internal/scan"
+)
+
+// scanStagedChanges scans every staged addition or modification and returns a
+// *scan.FindingsError if any sensitive content is found
+func (c *Client) scanStagedChanges(r *git.Repository, w *git.Worktree, repoPath string) error {
+	status, err := w.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+
+	headTree, err := headTree(r)
+	if err != nil {
+		return err
+	}
+
+	var findings []scan.Finding
+	for file, fileStatus := range status {
+		switch fileStatus.Staging {
+		case git.Added, git.Modified, git.Renamed, git.Copied:
+		default:
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(repoPath, file))
+		if err != nil {
+			return fmt.Errorf("failed to read staged file %s: %w", file, err)
+		}
+
+		addedLines, err := addedLinesSinceHead(headTree, file, string(content))
+		if err != nil {
+			return err
+		}
+
+		findings = append(findings, c.commitConfig.Scanner.ScanFile(file, content, addedLines)...)
+	}
+
+	if len(findings) == 0 {
+		return nil
+	}
+
+	scan.SortFindings(findings)
+	return &scan.FindingsError{Findings: findings}
+}
+
+// headTree returns the tree of the current HEAD commit, or nil for an empty repository
+func headTree(r *git.Repository) (*object.Tree, error) {
+	head, err := r.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	commit, err := r.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD tree: %w", err)
+	}
+
+	return tree, nil
+}
+
+// addedLinesSinceHead returns the 1-based line numbers in content that differ
+// from the file at HEAD. A nil result means the file is new and every line
+// should be treated as added.
+func addedLinesSinceHead(tree *object.Tree, file, content string) (map[int]bool, error) {
+	if tree == nil {
+		return nil, nil
+	}
+
+	previous, err := tree.File(file)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at HEAD: %w", file, err)
+	}
+
+	previousContent, err := previous.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at HEAD: %w", file, err)
+	}
+
+	added := make(map[int]bool)
+	line := 1
+	for _, d := range diff.Do(previousContent, content) {
+		lines := countLines(d.Text)
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			line += lines
+		case diffmatchpatch.DiffInsert:
+			for i := 0; i < lines; i++ {
+				added[line+i] = true
+			}
+			line += lines
+		}
+	}
+
+	return added, nil
+}
+
+func countLines(s string) int {
+	if s == "" {
+		return 0
+	}
+	n := 0
+	for _, r := range s {
+		if r == '\n' {
+			n++
+		}
+	}
+	if s[len(s)-1] != '\n' {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,159 @@
+package github
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// SigningFormatOpenPGP signs commits with a GPG key
+	SigningFormatOpenPGP = "openpgp"
+	// SigningFormatSSH signs commits with an SSH key, as with gpg.format=ssh
+	SigningFormatSSH = "ssh"
+
+	sshSigNamespace = "git"
+	sshSigHashAlg   = "sha512"
+)
+
+// NewSigner creates a commit signer for the given format from a private key file
+func NewSigner(format, keyPath, passphrase string) (git.Signer, error) {
+	switch format {
+	case SigningFormatOpenPGP:
+		return NewOpenPGPSigner(keyPath, passphrase)
+	case SigningFormatSSH:
+		return NewSSHSigner(keyPath, passphrase)
+	default:
+		return nil, fmt.Errorf("unsupported signing format %q", format)
+	}
+}
+
+type openPGPSigner struct {
+	entity *openpgp.Entity
+}
+
+// NewOpenPGPSigner loads an armored OpenPGP private key, decrypting it with
+// passphrase if it is encrypted
+func NewOpenPGPSigner(keyPath, passphrase string) (git.Signer, error) {
+	f, err := os.Open(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open signing key: %w", err)
+	}
+	defer f.Close()
+
+	entities, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	if len(entities) == 0 || entities[0].PrivateKey == nil {
+		return nil, fmt.Errorf("no private key found in %s", keyPath)
+	}
+
+	entity := entities[0]
+	if entity.PrivateKey.Encrypted {
+		if err := entity.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+			return nil, fmt.Errorf("failed to decrypt signing key: %w", err)
+		}
+	}
+
+	return &openPGPSigner{entity: entity}, nil
+}
+
+// Sign returns an armored detached signature of message
+func (s *openPGPSigner) Sign(message io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&buf, s.entity, message, nil); err != nil {
+		return nil, fmt.Errorf("failed to sign commit: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type sshSigner struct {
+	signer ssh.Signer
+}
+
+// NewSSHSigner loads an OpenSSH private key, decrypting it with passphrase
+// if one is given
+func NewSSHSigner(keyPath, passphrase string) (git.Signer, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	var signer ssh.Signer
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+
+	return &sshSigner{signer: signer}, nil
+}
+
+// Sign returns an armored SSHSIG signature of message in the "git" namespace.
+// See https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig
+func (s *sshSigner) Sign(message io.Reader) ([]byte, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, fmt.Errorf("failed to hash commit: %w", err)
+	}
+
+	var signed bytes.Buffer
+	signed.WriteString("SSHSIG")
+	writeSSHString(&signed, []byte(sshSigNamespace))
+	writeSSHString(&signed, nil)
+	writeSSHString(&signed, []byte(sshSigHashAlg))
+	writeSSHString(&signed, h.Sum(nil))
+
+	sig, err := s.sign(signed.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign commit: %w", err)
+	}
+
+	var blob bytes.Buffer
+	blob.WriteString("SSHSIG")
+	binary.Write(&blob, binary.BigEndian, uint32(1))
+	writeSSHString(&blob, s.signer.PublicKey().Marshal())
+	writeSSHString(&blob, []byte(sshSigNamespace))
+	writeSSHString(&blob, nil)
+	writeSSHString(&blob, []byte(sshSigHashAlg))
+	writeSSHString(&blob, ssh.Marshal(sig))
+
+	encoded := base64.StdEncoding.EncodeToString(blob.Bytes())
+	var armored strings.Builder
+	armored.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	for len(encoded) > 70 {
+		armored.WriteString(encoded[:70] + "\n")
+		encoded = encoded[70:]
+	}
+	armored.WriteString(encoded + "\n")
+	armored.WriteString("-----END SSH SIGNATURE-----\n")
+
+	return []byte(armored.String()), nil
+}
+
+// sign uses rsa-sha2-512 for RSA keys, since ssh-rsa (SHA-1) signatures are rejected by git
+func (s *sshSigner) sign(data []byte) (*ssh.Signature, error) {
+	if algSigner, ok := s.signer.(ssh.AlgorithmSigner); ok && s.signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		return algSigner.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA512)
+	}
+	return s.signer.Sign(rand.Reader, data)
+}
+
+func writeSSHString(buf *bytes.Buffer, s []byte) {
+	binary.Write(buf, binary.BigEndian, uint32(len(s)))
+	buf.Write(s)
+}
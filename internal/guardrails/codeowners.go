@@ -0,0 +1,78 @@
+package guardrails
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// codeownersLocations are the paths GitHub checks for a CODEOWNERS file, in order
+var codeownersLocations = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+type codeownersRule struct {
+	pattern gitignore.Pattern
+	owners  []string
+}
+
+// Codeowners resolves file ownership from a CODEOWNERS file
+type Codeowners struct {
+	rules []codeownersRule
+}
+
+// LoadCodeowners reads the repository's CODEOWNERS file. It returns nil if
+// the repository doesn't have one.
+func LoadCodeowners(repoPath string) (*Codeowners, error) {
+	for _, location := range codeownersLocations {
+		f, err := os.Open(filepath.Join(repoPath, location))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ParseCodeowners(f)
+	}
+	return nil, nil
+}
+
+// ParseCodeowners parses CODEOWNERS content
+func ParseCodeowners(r io.Reader) (*Codeowners, error) {
+	c := &Codeowners{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		c.rules = append(c.rules, codeownersRule{
+			pattern: gitignore.ParsePattern(fields[0], nil),
+			owners:  fields[1:],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Owners returns the owners of a repository-relative path. As in GitHub,
+// the last matching rule wins.
+func (c *Codeowners) Owners(path string) []string {
+	segments := strings.Split(path, "/")
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].pattern.Match(segments, false) == gitignore.Exclude {
+			return c.rules[i].owners
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package guardrails
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+const (
+	// ActionFail fails the run when a guardrail is breached
+	ActionFail = "fail"
+	// ActionDraft opens a labelled draft PR when a guardrail is breached
+	ActionDraft = "draft"
+
+	// DefaultReviewLabel is applied to draft PRs that breached a guardrail
+	DefaultReviewLabel = "khitomer:needs-review"
+)
+
+// DefaultProtectedPaths returns gitignore-style patterns for files that
+// generated changes must never touch without extra review
+func DefaultProtectedPaths() []string {
+	return []string{
+		".github/workflows/",
+		"/go.mod",
+		"migrations/",
+		"CODEOWNERS",
+	}
+}
+
+// Config configures the guardrail limits. A zero limit is unlimited.
+type Config struct {
+	MaxFilesChanged int
+	MaxLinesAdded   int
+	MaxLinesRemoved int
+
+	// ProtectedPaths are gitignore-style patterns for protected files
+	ProtectedPaths []string
+
+	// ProtectedOwners marks every file owned by one of these CODEOWNERS
+	// entries (e.g. "@org/security") as protected
+	ProtectedOwners []string
+
+	// Action is ActionFail or ActionDraft
+	Action      string
+	ReviewLabel string
+}
+
+// FileChange describes the size of a change to a single file
+type FileChange struct {
+	Path         string
+	LinesAdded   int
+	LinesRemoved int
+}
+
+// Violation describes a single breached guardrail
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+// String formats the violation for reports
+func (v Violation) String() string {
+	return v.Rule + ": " + v.Message
+}
+
+// Checker enforces guardrails on a set of file changes
+type Checker struct {
+	config    Config
+	protected []protectedPattern
+}
+
+// protectedPattern is a parsed protected path and the pattern it came from
+type protectedPattern struct {
+	src     string
+	pattern gitignore.Pattern
+}
+
+// NewChecker creates a new guardrail checker
+func NewChecker(config Config) *Checker {
+	if config.ProtectedPaths == nil {
+		config.ProtectedPaths = DefaultProtectedPaths()
+	}
+	if config.Action == "" {
+		config.Action = ActionDraft
+	}
+	if config.ReviewLabel == "" {
+		config.ReviewLabel = DefaultReviewLabel
+	}
+
+	protected := make([]protectedPattern, 0, len(config.ProtectedPaths))
+	for _, p := range config.ProtectedPaths {
+		if p = strings.TrimSpace(p); p != "" {
+			protected = append(protected, protectedPattern{src: p, pattern: gitignore.ParsePattern(p, nil)})
+		}
+	}
+
+	return &Checker{
+		config:    config,
+		protected: protected,
+	}
+}
+
+// Action returns the configured breach action
+func (c *Checker) Action() string {
+	return c.config.Action
+}
+
+// ReviewLabel returns the label applied to draft PRs that breached a guardrail
+func (c *Checker) ReviewLabel() string {
+	return c.config.ReviewLabel
+}
+
+// Check returns every guardrail breached by changes. codeowners may be nil
+// when the repository has no CODEOWNERS file.
+func (c *Checker) Check(changes []FileChange, codeowners *Codeowners) []Violation {
+	var violations []Violation
+
+	var added, removed int
+	for _, change := range changes {
+		added += change.LinesAdded
+		removed += change.LinesRemoved
+	}
+
+	if c.config.MaxFilesChanged > 0 && len(changes) > c.config.MaxFilesChanged {
+		violations = append(violations, Violation{
+			Rule:    "max-files-changed",
+			Message: fmt.Sprintf("%d files changed, limit is %d", len(changes), c.config.MaxFilesChanged),
+		})
+	}
+	if c.config.MaxLinesAdded > 0 && added > c.config.MaxLinesAdded {
+		violations = append(violations, Violation{
+			Rule:    "max-lines-added",
+			Message: fmt.Sprintf("%d lines added, limit is %d", added, c.config.MaxLinesAdded),
+		})
+	}
+	if c.config.MaxLinesRemoved > 0 && removed > c.config.MaxLinesRemoved {
+		violations = append(violations, Violation{
+			Rule:    "max-lines-removed",
+			Message: fmt.Sprintf("%d lines removed, limit is %d", removed, c.config.MaxLinesRemoved),
+		})
+	}
+
+	for _, change := range changes {
+		segments := strings.Split(change.Path, "/")
+		if pattern, ok := c.matchProtected(segments); ok {
+			violations = append(violations, Violation{
+				Rule:    "protected-path",
+				Message: fmt.Sprintf("%s matches protected pattern %q", change.Path, pattern),
+			})
+			continue
+		}
+
+		if codeowners == nil || len(c.config.ProtectedOwners) == 0 {
+			continue
+		}
+		for _, owner := range codeowners.Owners(change.Path) {
+			if containsFold(c.config.ProtectedOwners, owner) {
+				violations = append(violations, Violation{
+					Rule:    "protected-owner",
+					Message: fmt.Sprintf("%s is owned by %s", change.Path, owner),
+				})
+				break
+			}
+		}
+	}
+
+	return violations
+}
+
+func (c *Checker) matchProtected(segments []string) (string, bool) {
+	for _, p := range c.protected {
+		if p.pattern.Match(segments, false) == gitignore.Exclude {
+			return p.src, true
+		}
+	}
+	return "", false
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package guardrails
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckReportsMatchingProtectedPattern(t *testing.T) {
+	checker := NewChecker(Config{
+		ProtectedPaths: []string{"", ".github/workflows/", " /go.mod "},
+	})
+
+	violations := checker.Check([]FileChange{
+		{Path: ".github/workflows/ci.yml", LinesAdded: 1},
+		{Path: "go.mod", LinesAdded: 1},
+		{Path: "main.go", LinesAdded: 1},
+	}, nil)
+
+	assert.Equal(t, []Violation{
+		{Rule: "protected-path", Message: `.github/workflows/ci.yml matches protected pattern ".github/workflows/"`},
+		{Rule: "protected-path", Message: `go.mod matches protected pattern "/go.mod"`},
+	}, violations)
+}
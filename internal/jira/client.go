@@ -1,12 +1,16 @@
 package jira
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	jira "github.com/andygrunwald/go-jira"
 	"go.uber.org/zap"
 
+	"github.com/clintrovert/khitomer/internal/metrics"
+	"github.com/clintrovert/khitomer/internal/tracing"
 	"github.com/clintrovert/khitomer/pkg/types"
 )
 
@@ -14,15 +18,27 @@ import (
 type Client struct {
 	client      *jira.Client
 	logger      *zap.Logger
-	projectKey  string
 	customField string
+	apiVersion  int
+
+	// fieldMu guards fieldID, the ID of the custom field, once resolved
+	fieldMu sync.Mutex
+	fieldID string
 }
 
-// NewClient creates a new Jira client
-func NewClient(baseURL, username, apiToken, projectKey, customField string, logger *zap.Logger) (*Client, error) {
+// taskFields are the issue fields tasks are built from, besides the
+// repository field
+var taskFields = []string{"summary", "description", "status", "issuetype", "assignee"}
+
+// NewClient creates a new Jira client. customField names the field holding
+// a ticket's repository. apiVersion is APIVersion2 or APIVersion3; with
+// version 3, descriptions and comments are converted between ADF and
+// Markdown.
+func NewClient(baseURL, username, apiToken, customField string, apiVersion int, logger *zap.Logger) (*Client, error) {
 	tp := jira.BasicAuthTransport{
-		Username: username,
-		Password: apiToken,
+		Username:  username,
+		Password:  apiToken,
+		Transport: metrics.InstrumentTransport("jira", tracing.InstrumentTransport("jira", nil)),
 	}
 
 	client, err := jira.NewClient(tp.Client(), baseURL)
@@ -33,50 +49,74 @@ func NewClient(baseURL, username, apiToken, projectKey, customField string, logg
 	return &Client{
 		client:      client,
 		logger:      logger,
-		projectKey:  projectKey,
 		customField: customField,
+		apiVersion:  apiVersion,
 	}, nil
 }
 
-// GetTasksByStatus retrieves tasks with a specific status
-func (c *Client) GetTasksByStatus(status string) ([]*types.Task, error) {
-	jql := fmt.Sprintf("project = %s AND status = \"%s\"", c.projectKey, status)
-	
-	issues, _, err := c.client.Issue.Search(jql, nil)
+// GetTasks retrieves every ready task of a project: those in any of its
+// statuses that match its JQL filter, in its order
+func (c *Client) GetTasks(ctx context.Context, project Project) ([]*types.Task, error) {
+	var fields []string
+	if fieldID := c.repositoryFieldID(ctx); fieldID != "" {
+		fields = append(append(fields, taskFields...), fieldID)
+	}
+
+	issues, err := c.searchIssues(ctx, taskQuery(project), fields)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search issues: %w", err)
+		return nil, err
 	}
 
 	tasks := make([]*types.Task, 0, len(issues))
 	for _, issue := range issues {
 		task, err := c.issueToTask(&issue)
 		if err != nil {
+			// Skip tasks without repository information
 			c.logger.Warn("failed to convert issue to task", zap.Error(err), zap.String("issue", issue.Key))
 			continue
 		}
-		if task != nil {
-			tasks = append(tasks, task)
-		}
+		tasks = append(tasks, task)
 	}
 
 	return tasks, nil
 }
 
-// GetTask retrieves a specific task by ID
-func (c *Client) GetTask(ticketID string) (*types.Task, error) {
-	issue, _, err := c.client.Issue.Get(ticketID, nil)
+// GetTask retrieves a specific task by ID. Unlike polled tasks, a ticket
+// without repository information, or with an incomplete repository, is
+// returned with empty repository fields.
+func (c *Client) GetTask(ctx context.Context, ticketID string) (*types.Task, error) {
+	issue, err := c.getIssue(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.repositoryFieldID(ctx)
+	task, err := c.issueToTask(issue)
+	if err != nil {
+		c.logger.Debug("ticket has no repository", zap.String("issue", issue.Key), zap.Error(err))
+		return c.newTask(issue), nil
+	}
+	return task, nil
+}
+
+// GetTaskStatus retrieves the current status name of a task
+func (c *Client) GetTaskStatus(ctx context.Context, ticketID string) (string, error) {
+	issue, resp, err := c.client.Issue.GetWithContext(ctx, ticketID, &jira.GetQueryOptions{Fields: "status"})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get issue: %w", err)
+		return "", fmt.Errorf("failed to get issue: %w", classifyError(resp, err))
+	}
+	if issue.Fields == nil || issue.Fields.Status == nil {
+		return "", nil
 	}
 
-	return c.issueToTask(issue)
+	return issue.Fields.Status.Name, nil
 }
 
 // UpdateTaskStatus updates the status of a task
-func (c *Client) UpdateTaskStatus(ticketID, status string) error {
-	transitions, _, err := c.client.Issue.GetTransitions(ticketID)
+func (c *Client) UpdateTaskStatus(ctx context.Context, ticketID, status string) error {
+	transitions, resp, err := c.client.Issue.GetTransitionsWithContext(ctx, ticketID)
 	if err != nil {
-		return fmt.Errorf("failed to get transitions: %w", err)
+		return fmt.Errorf("failed to get transitions: %w", classifyError(resp, err))
 	}
 
 	var transitionID string
@@ -91,27 +131,22 @@ func (c *Client) UpdateTaskStatus(ticketID, status string) error {
 		return fmt.Errorf("transition to status %s not found", status)
 	}
 
-	_, err = c.client.Issue.DoTransition(ticketID, transitionID)
+	resp, err = c.client.Issue.DoTransitionWithContext(ctx, ticketID, transitionID)
 	if err != nil {
-		return fmt.Errorf("failed to transition issue: %w", err)
+		return fmt.Errorf("failed to transition issue: %w", classifyError(resp, err))
 	}
 
 	return nil
 }
 
-// AddComment adds a comment to a task
-func (c *Client) AddComment(ticketID, comment string) error {
-	_, _, err := c.client.Issue.AddComment(ticketID, &jira.Comment{
-		Body: comment,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to add comment: %w", err)
-	}
-
-	return nil
+// AddComment adds a Markdown comment to a task, which is rendered as rich
+// text on API version 3
+func (c *Client) AddComment(ctx context.Context, ticketID, comment string) error {
+	return c.addComment(ctx, ticketID, comment)
 }
 
-// issueToTask converts a Jira issue to a Task
+// issueToTask converts a Jira issue to a Task, failing if the issue has no
+// repository information
 func (c *Client) issueToTask(issue *jira.Issue) (*types.Task, error) {
 	// Extract repository information from custom field
 	repoOwner, repoName, err := c.extractRepositoryInfo(issue)
@@ -119,34 +154,74 @@ func (c *Client) issueToTask(issue *jira.Issue) (*types.Task, error) {
 		return nil, fmt.Errorf("failed to extract repository info: %w", err)
 	}
 
-	if repoOwner == "" || repoName == "" {
-		// Skip tasks without repository information
-		return nil, nil
-	}
+	task := c.newTask(issue)
+	task.RepositoryOwner = repoOwner
+	task.RepositoryName = repoName
+	task.RepositoryURL = fmt.Sprintf("https://github.com/%s/%s", repoOwner, repoName)
+	task.BaseBranch = "main" // Default, can be overridden
 
+	return task, nil
+}
+
+// newTask converts an issue's fields to a task without repository information
+func (c *Client) newTask(issue *jira.Issue) *types.Task {
 	task := &types.Task{
-		JiraTicketID:     issue.Key,
-		Title:           issue.Fields.Summary,
-		Description:     issue.Fields.Description,
-		Status:          issue.Fields.Status.Name,
-		RepositoryOwner: repoOwner,
-		RepositoryName:  repoName,
-		RepositoryURL:   fmt.Sprintf("https://github.com/%s/%s", repoOwner, repoName),
-		BaseBranch:      "main", // Default, can be overridden
+		JiraTicketID: issue.Key,
+		Title:        issue.Fields.Summary,
+		Description:  issue.Fields.Description,
+	}
+
+	if issue.Fields.Status != nil {
+		task.Status = issue.Fields.Status.Name
+	}
+
+	if issue.Fields.Type.Name != "" {
+		task.IssueType = issue.Fields.Type.Name
 	}
 
 	if issue.Fields.Assignee != nil {
 		task.Assignee = issue.Fields.Assignee.DisplayName
+		task.AssigneeEmail = issue.Fields.Assignee.EmailAddress
 	}
 
-	return task, nil
+	return task
 }
 
-// extractRepositoryInfo extracts repository owner and name from custom field
+// repositoryFieldID resolves the ID of the custom field named customField,
+// so searches can request just that field. It returns "" if the field can't
+// be resolved, in which case searches return every navigable field.
+func (c *Client) repositoryFieldID(ctx context.Context) string {
+	c.fieldMu.Lock()
+	defer c.fieldMu.Unlock()
+	if c.fieldID != "" || c.customField == "" {
+		return c.fieldID
+	}
+
+	fields, resp, err := c.client.Field.GetListWithContext(ctx)
+	if err != nil {
+		c.logger.Warn("failed to list jira fields", zap.Error(classifyError(resp, err)))
+		return ""
+	}
+	for _, field := range fields {
+		if field.ID == c.customField || strings.EqualFold(field.Name, c.customField) {
+			c.fieldID = field.ID
+			return c.fieldID
+		}
+	}
+	c.logger.Warn("jira field not found", zap.String("field", c.customField))
+	return ""
+}
+
+// extractRepositoryInfo extracts repository owner and name from custom
+// field, failing unless both are present
 func (c *Client) extractRepositoryInfo(issue *jira.Issue) (string, string, error) {
-	// Try to find the custom field by name
+	c.fieldMu.Lock()
+	fieldID := c.fieldID
+	c.fieldMu.Unlock()
+
+	// Try to find the custom field by its ID, or else by name
 	for key, value := range issue.Fields.Unknowns {
-		if strings.Contains(strings.ToLower(key), strings.ToLower(c.customField)) {
+		if key == fieldID || strings.Contains(strings.ToLower(key), strings.ToLower(c.customField)) {
 			repoStr, ok := value.(string)
 			if !ok {
 				continue
@@ -156,12 +231,12 @@ func (c *Client) extractRepositoryInfo(issue *jira.Issue) (string, string, error
 			repoStr = strings.TrimSpace(repoStr)
 			if strings.HasPrefix(repoStr, "https://github.com/") {
 				parts := strings.Split(strings.TrimPrefix(repoStr, "https://github.com/"), "/")
-				if len(parts) >= 2 {
+				if len(parts) >= 2 && parts[0] != "" && parts[1] != "" {
 					return parts[0], parts[1], nil
 				}
 			} else if strings.Contains(repoStr, "/") {
 				parts := strings.Split(repoStr, "/")
-				if len(parts) == 2 {
+				if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
 					return parts[0], parts[1], nil
 				}
 			}
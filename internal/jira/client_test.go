@@ -0,0 +1,49 @@
+package jira
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTaskRepository(t *testing.T) {
+	tests := []struct {
+		name       string
+		repository any
+		wantOwner  string
+		wantName   string
+	}{
+		{name: "owner and name", repository: "acme/api", wantOwner: "acme", wantName: "api"},
+		{name: "url", repository: "https://github.com/acme/api", wantOwner: "acme", wantName: "api"},
+		{name: "missing", repository: nil},
+		{name: "no name", repository: "acme/"},
+		{name: "no owner", repository: "/api"},
+		{name: "url without owner", repository: "https://github.com//api"},
+		{name: "url without name", repository: "https://github.com/acme/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, "/rest/api/2/issue/PROJ-1", func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(map[string]any{
+					"key": "PROJ-1",
+					"fields": map[string]any{
+						"summary":           "Add health check",
+						"customfield_10050": tt.repository,
+					},
+				})
+			})
+
+			task, err := newTestClient(t, server, APIVersion2).GetTask(t.Context(), "PROJ-1")
+			require.NoError(t, err)
+			require.NotNil(t, task)
+			assert.Equal(t, "PROJ-1", task.JiraTicketID)
+			assert.Equal(t, "Add health check", task.Title)
+			assert.Equal(t, tt.wantOwner, task.RepositoryOwner)
+			assert.Equal(t, tt.wantName, task.RepositoryName)
+		})
+	}
+}
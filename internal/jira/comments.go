@@ -0,0 +1,226 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"sync"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira"
+	"go.uber.org/zap"
+)
+
+// commentTimeLayout is the layout of comment timestamps in the Jira API
+const commentTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// commentOverlap widens each comment poll to cover clock skew between the
+// leader and Jira. Comments seen twice are deduplicated by their handler.
+const commentOverlap = time.Minute
+
+// User is a Jira user. Jira Cloud identifies users by AccountID and Jira
+// Server by Name.
+type User struct {
+	AccountID   string
+	Name        string
+	DisplayName string
+}
+
+// Is reports whether u and other are the same user
+func (u User) Is(other User) bool {
+	if u.AccountID != "" || other.AccountID != "" {
+		return u.AccountID == other.AccountID
+	}
+	return u.Name != "" && u.Name == other.Name
+}
+
+// mention returns the wiki markup mentioning the user, which AddComment
+// turns into a mention on API version 3
+func (u User) mention() string {
+	if u.AccountID != "" {
+		return "[~accountid:" + u.AccountID + "]"
+	}
+	if u.Name != "" {
+		return "[~" + u.Name + "]"
+	}
+	return u.DisplayName
+}
+
+// Comment is a comment on a ticket
+type Comment struct {
+	ID       string
+	TicketID string
+	Author   User
+	Body     string
+	Created  time.Time
+}
+
+// newComment converts a Jira comment on ticketID
+func newComment(ticketID string, comment *jira.Comment) *Comment {
+	created, _ := time.Parse(commentTimeLayout, comment.Created)
+	return &Comment{
+		ID:       comment.ID,
+		TicketID: ticketID,
+		Author: User{
+			AccountID:   comment.Author.AccountID,
+			Name:        comment.Author.Name,
+			DisplayName: comment.Author.DisplayName,
+		},
+		Body:    comment.Body,
+		Created: created,
+	}
+}
+
+// GetRecentComments retrieves the comments created since a time on a
+// project's tickets
+func (c *Client) GetRecentComments(ctx context.Context, projectKey string, since time.Time) ([]*Comment, error) {
+	// Relative JQL dates avoid depending on the Jira user's time zone
+	minutes := int(math.Ceil(time.Since(since).Minutes()))
+	if minutes < 1 {
+		minutes = 1
+	}
+	jql := fmt.Sprintf("project = %s AND updated >= -%dm", QuoteJQL(projectKey), minutes)
+
+	issues, err := c.searchIssues(ctx, jql, []string{"comment"})
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []*Comment
+	for _, issue := range issues {
+		if issue.Fields == nil || issue.Fields.Comments == nil {
+			continue
+		}
+		for _, jc := range issue.Fields.Comments.Comments {
+			comment := newComment(issue.Key, jc)
+			if comment.Created.Before(since) {
+				continue
+			}
+			comments = append(comments, comment)
+		}
+	}
+
+	return comments, nil
+}
+
+// Self retrieves the user the client authenticates as
+func (c *Client) Self(ctx context.Context) (User, error) {
+	user, resp, err := c.client.User.GetSelfWithContext(ctx)
+	if err != nil {
+		return User{}, fmt.Errorf("failed to get current user: %w", classifyError(resp, err))
+	}
+	return User{AccountID: user.AccountID, Name: user.Name, DisplayName: user.DisplayName}, nil
+}
+
+// GetUserGroups retrieves the names of the groups a user belongs to
+func (c *Client) GetUserGroups(ctx context.Context, user User) ([]string, error) {
+	query := url.Values{"expand": {"groups"}}
+	if user.AccountID != "" {
+		query.Set("accountId", user.AccountID)
+	} else {
+		query.Set("username", user.Name)
+	}
+
+	req, err := c.client.NewRequestWithContext(ctx, "GET", "rest/api/2/user?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var result struct {
+		Groups struct {
+			Items []jira.UserGroup `json:"items"`
+		} `json:"groups"`
+	}
+	resp, err := c.client.Do(req, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user groups: %w", classifyError(resp, jira.NewJiraError(resp, err)))
+	}
+
+	groups := make([]string, 0, len(result.Groups.Items))
+	for _, group := range result.Groups.Items {
+		groups = append(groups, group.Name)
+	}
+	return groups, nil
+}
+
+// Reply comments on a comment's ticket, mentioning the comment's author
+func (c *Client) Reply(ctx context.Context, comment *Comment, text string) error {
+	return c.AddComment(ctx, comment.TicketID, comment.Author.mention()+" "+text)
+}
+
+// CommentPoller polls Jira for new comments on the tickets of a set of
+// projects
+type CommentPoller struct {
+	client   *Client
+	logger   *zap.Logger
+	projects []string
+	interval time.Duration
+	mu       sync.RWMutex
+}
+
+// NewCommentPoller creates a new comment poller for the given project keys
+func NewCommentPoller(client *Client, projects []string, interval time.Duration, logger *zap.Logger) *CommentPoller {
+	return &CommentPoller{
+		client:   client,
+		logger:   logger,
+		projects: projects,
+		interval: interval,
+	}
+}
+
+// SetProjects replaces the polled projects and the interval between polls.
+// Both take effect from the next poll.
+func (p *CommentPoller) SetProjects(projects []string, interval time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.projects = projects
+	p.interval = interval
+}
+
+// Start calls handle with each comment created after polling started until
+// ctx is done. Comments created around a poll may be passed twice.
+func (p *CommentPoller) Start(ctx context.Context, handle func(context.Context, *Comment)) {
+	since := time.Now()
+	for {
+		p.mu.RLock()
+		interval := p.interval
+		p.mu.RUnlock()
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			p.logger.Info("stopping jira comment poller")
+			return
+		case <-timer.C:
+		}
+
+		started := time.Now()
+		if p.poll(ctx, since.Add(-commentOverlap), handle) {
+			since = started
+		}
+	}
+}
+
+// poll passes the comments created since a time to handle. It reports
+// whether every project was polled.
+func (p *CommentPoller) poll(ctx context.Context, since time.Time, handle func(context.Context, *Comment)) bool {
+	p.mu.RLock()
+	projects := p.projects
+	p.mu.RUnlock()
+
+	complete := true
+	for _, project := range projects {
+		comments, err := p.client.GetRecentComments(ctx, project, since)
+		if err != nil {
+			p.logger.Error("failed to get recent comments", zap.String("project", project), zap.Error(err))
+			complete = false
+			continue
+		}
+		for _, comment := range comments {
+			handle(ctx, comment)
+		}
+	}
+	return complete
+}
@@ -0,0 +1,41 @@
+package jira
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	jira "github.com/andygrunwald/go-jira"
+)
+
+var (
+	// ErrAuthFailed indicates the Jira credentials were rejected or lack the
+	// permissions needed for an operation
+	ErrAuthFailed = errors.New("jira authentication failed")
+
+	// ErrIssueNotFound indicates the issue does not exist or is not visible to
+	// the configured user
+	ErrIssueNotFound = errors.New("jira issue not found")
+
+	// ErrBadRequest indicates Jira rejected the request, e.g. for a malformed
+	// issue key
+	ErrBadRequest = errors.New("jira rejected the request")
+)
+
+// classifyError tags Jira API errors that retrying cannot fix, based on the
+// HTTP status of the response
+func classifyError(resp *jira.Response, err error) error {
+	if err == nil || resp == nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %w", ErrAuthFailed, err)
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrIssueNotFound, err)
+	case http.StatusBadRequest:
+		return fmt.Errorf("%w: %w", ErrBadRequest, err)
+	}
+	return err
+}
@@ -0,0 +1,34 @@
+package jira
+
+import (
+	"strings"
+)
+
+// QuoteJQL returns value as a double-quoted JQL string literal
+func QuoteJQL(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// taskQuery builds the JQL matching a project's ready tickets: those in any
+// of its statuses that also match its filter, in its order
+func taskQuery(project Project) string {
+	clauses := []string{"project = " + QuoteJQL(project.Key)}
+	if len(project.Statuses) > 0 {
+		statuses := make([]string, 0, len(project.Statuses))
+		for _, status := range project.Statuses {
+			statuses = append(statuses, QuoteJQL(status))
+		}
+		clauses = append(clauses, "status IN ("+strings.Join(statuses, ", ")+")")
+	}
+	if filter := strings.TrimSpace(project.JQL); filter != "" {
+		clauses = append(clauses, "("+filter+")")
+	}
+
+	jql := strings.Join(clauses, " AND ")
+	if orderBy := strings.TrimSpace(project.OrderBy); orderBy != "" {
+		jql += " ORDER BY " + orderBy
+	}
+	return jql
+}
@@ -5,69 +5,106 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
+	"github.com/clintrovert/khitomer/internal/metrics"
+	"github.com/clintrovert/khitomer/internal/tracing"
 	"github.com/clintrovert/khitomer/pkg/types"
 )
 
+// Project is a Jira project polled for tasks in any of its statuses
+type Project struct {
+	Key      string
+	Statuses []string
+	// JQL further filters the project's tickets, e.g. by label, component
+	// or sprint
+	JQL string
+	// OrderBy is the JQL ORDER BY clause tickets are polled in, without the
+	// keywords, e.g. "priority DESC, created ASC"
+	OrderBy string
+}
+
 // Poller polls Jira for ready tasks
 type Poller struct {
-	client        *Client
-	logger        *zap.Logger
-	statusFilter  []string
-	interval      time.Duration
+	client         *Client
+	logger         *zap.Logger
+	projects       []Project
+	interval       time.Duration
 	processedTasks map[string]bool
-	mu            sync.RWMutex
+	// pollSpans are the spans of the polls that found tasks not yet taken
+	// by PollSpanContext
+	pollSpans map[string]trace.SpanContext
+	mu        sync.RWMutex
 }
 
 // NewPoller creates a new Jira poller
-func NewPoller(client *Client, statusFilter []string, interval time.Duration, logger *zap.Logger) *Poller {
+func NewPoller(client *Client, projects []Project, interval time.Duration, logger *zap.Logger) *Poller {
 	return &Poller{
 		client:         client,
 		logger:         logger,
-		statusFilter:   statusFilter,
+		projects:       projects,
 		interval:       interval,
 		processedTasks: make(map[string]bool),
+		pollSpans:      make(map[string]trace.SpanContext),
 	}
 }
 
+// SetProjects replaces the polled projects and the interval between polls.
+// Both take effect from the next poll.
+func (p *Poller) SetProjects(projects []Project, interval time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.projects = projects
+	p.interval = interval
+}
+
 // Start starts the polling loop
 func (p *Poller) Start(ctx context.Context, taskChan chan<- *types.Task) {
-	ticker := time.NewTicker(p.interval)
-	defer ticker.Stop()
-
-	// Initial poll
-	p.poll(ctx, taskChan)
-
 	for {
+		p.poll(ctx, taskChan)
+
+		timer := time.NewTimer(p.pollInterval())
 		select {
 		case <-ctx.Done():
+			timer.Stop()
 			p.logger.Info("stopping jira poller")
 			return
-		case <-ticker.C:
-			p.poll(ctx, taskChan)
+		case <-timer.C:
 		}
 	}
 }
 
 // poll performs a single poll operation
 func (p *Poller) poll(ctx context.Context, taskChan chan<- *types.Task) {
-	for _, status := range p.statusFilter {
-		tasks, err := p.client.GetTasksByStatus(status)
+	p.mu.RLock()
+	projects := p.projects
+	p.mu.RUnlock()
+
+	ctx, span := tracing.Start(ctx, "jira.poll", trace.WithAttributes(attribute.Int("jira.projects", len(projects))))
+	defer span.End()
+
+	for _, project := range projects {
+		tasks, err := p.client.GetTasks(ctx, project)
 		if err != nil {
-			p.logger.Error("failed to get tasks by status",
-				zap.String("status", status),
+			p.logger.Error("failed to get tasks",
+				zap.String("project", project.Key),
+				zap.Strings("statuses", project.Statuses),
 				zap.Error(err),
 			)
 			continue
 		}
+		metrics.TicketsPolled.WithLabelValues(project.Key).Add(float64(len(tasks)))
 
 		for _, task := range tasks {
 			if p.isProcessed(task.JiraTicketID) {
+				metrics.TicketsDeduplicated.WithLabelValues(metrics.DedupAlreadyProcessed).Inc()
 				continue
 			}
 
 			p.markProcessed(task.JiraTicketID)
+			p.setPollSpan(task.JiraTicketID, span.SpanContext())
 			select {
 			case taskChan <- task:
 				p.logger.Info("found new task",
@@ -81,6 +118,13 @@ func (p *Poller) poll(ctx context.Context, taskChan chan<- *types.Task) {
 	}
 }
 
+// pollInterval returns the current interval between polls
+func (p *Poller) pollInterval() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.interval
+}
+
 // isProcessed checks if a task has been processed
 func (p *Poller) isProcessed(ticketID string) bool {
 	p.mu.RLock()
@@ -95,10 +139,36 @@ func (p *Poller) markProcessed(ticketID string) {
 	p.processedTasks[ticketID] = true
 }
 
-// ClearProcessed clears the processed tasks list (for testing)
+// Release forgets that a task was processed so it is sent again on the next
+// poll if it is still ready
+func (p *Poller) Release(ticketID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.processedTasks, ticketID)
+}
+
+// ClearProcessed clears the processed tasks list so every ready task is
+// sent again on the next poll
 func (p *Poller) ClearProcessed() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	p.processedTasks = make(map[string]bool)
+	p.pollSpans = make(map[string]trace.SpanContext)
 }
 
+// PollSpanContext returns, once, the span of the poll that found a task so
+// the task's trace can link to it
+func (p *Poller) PollSpanContext(ticketID string) trace.SpanContext {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sc := p.pollSpans[ticketID]
+	delete(p.pollSpans, ticketID)
+	return sc
+}
+
+// setPollSpan records the span of the poll that found a task
+func (p *Poller) setPollSpan(ticketID string, sc trace.SpanContext) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pollSpans[ticketID] = sc
+}
@@ -0,0 +1,78 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	jira "github.com/andygrunwald/go-jira"
+)
+
+// searchPageSize is the number of issues requested per search page. Jira
+// may return fewer.
+const searchPageSize = 100
+
+// searchIssues runs a JQL search and returns every matching issue, page by
+// page, with the given fields or the default navigable fields if there are
+// none. Version 2 pages by offset and version 3 uses the enhanced search's
+// page tokens.
+func (c *Client) searchIssues(ctx context.Context, jql string, fields []string) ([]jira.Issue, error) {
+	if c.apiVersion < APIVersion3 {
+		return c.searchIssuesV2(ctx, jql, fields)
+	}
+	return c.searchIssuesV3(ctx, jql, fields)
+}
+
+func (c *Client) searchIssuesV2(ctx context.Context, jql string, fields []string) ([]jira.Issue, error) {
+	opts := &jira.SearchOptions{Fields: fields, MaxResults: searchPageSize}
+
+	var issues []jira.Issue
+	for {
+		page, resp, err := c.client.Issue.SearchWithContext(ctx, jql, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search issues: %w", classifyError(resp, err))
+		}
+		issues = append(issues, page...)
+
+		opts.StartAt += len(page)
+		if len(page) == 0 || opts.StartAt >= resp.Total {
+			return issues, nil
+		}
+	}
+}
+
+func (c *Client) searchIssuesV3(ctx context.Context, jql string, fields []string) ([]jira.Issue, error) {
+	if len(fields) == 0 {
+		fields = []string{"*navigable"}
+	}
+	body := struct {
+		JQL           string   `json:"jql"`
+		Fields        []string `json:"fields"`
+		MaxResults    int      `json:"maxResults"`
+		NextPageToken string   `json:"nextPageToken,omitempty"`
+	}{JQL: jql, Fields: fields, MaxResults: searchPageSize}
+
+	var issues []jira.Issue
+	for {
+		var page struct {
+			Issues        []json.RawMessage `json:"issues"`
+			NextPageToken string            `json:"nextPageToken"`
+			IsLast        bool              `json:"isLast"`
+		}
+		if err := c.doV3(ctx, "POST", "rest/api/3/search/jql", body, &page); err != nil {
+			return nil, fmt.Errorf("failed to search issues: %w", err)
+		}
+		for _, raw := range page.Issues {
+			issue, err := decodeIssue(raw)
+			if err != nil {
+				return nil, err
+			}
+			issues = append(issues, *issue)
+		}
+
+		if page.IsLast || page.NextPageToken == "" || page.NextPageToken == body.NextPageToken {
+			return issues, nil
+		}
+		body.NextPageToken = page.NextPageToken
+	}
+}
@@ -0,0 +1,152 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// testProject is polled for tickets in a status that needs quoting
+var testProject = Project{
+	Key:      "PROJ",
+	Statuses: []string{`Ready "for" Dev\QA`},
+	JQL:      "labels = khitomer",
+	OrderBy:  "created ASC",
+}
+
+// testIssues returns count issues, each with a repository in the
+// customfield_10050 field
+func testIssues(count int) []map[string]any {
+	issues := make([]map[string]any, 0, count)
+	for i := 1; i <= count; i++ {
+		issues = append(issues, map[string]any{
+			"key": fmt.Sprintf("PROJ-%d", i),
+			"fields": map[string]any{
+				"summary":           fmt.Sprintf("Task %d", i),
+				"status":            map[string]any{"name": testProject.Statuses[0]},
+				"issuetype":         map[string]any{"name": "Task"},
+				"customfield_10050": "acme/api",
+			},
+		})
+	}
+	return issues
+}
+
+// newTestServer serves the field list and the given search handler under
+// its path, the way a Jira site does
+func newTestServer(t *testing.T, searchPath string, search http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/2/field", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]any{
+			{"id": "summary", "name": "Summary"},
+			{"id": "customfield_10050", "name": "Repository", "custom": true},
+		})
+	})
+	mux.HandleFunc(searchPath, search)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestClient(t *testing.T, server *httptest.Server, apiVersion int) *Client {
+	t.Helper()
+	client, err := NewClient(server.URL, "khitomer", "token", "Repository", apiVersion, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	return client
+}
+
+func taskKeys(t *testing.T, client *Client) []string {
+	t.Helper()
+	tasks, err := client.GetTasks(t.Context(), testProject)
+	require.NoError(t, err)
+	var keys []string
+	for _, task := range tasks {
+		assert.Equal(t, "acme", task.RepositoryOwner, task.JiraTicketID)
+		assert.Equal(t, "api", task.RepositoryName, task.JiraTicketID)
+		keys = append(keys, task.JiraTicketID)
+	}
+	return keys
+}
+
+func TestGetTasksPagesByOffset(t *testing.T) {
+	issues := testIssues(5)
+	// The server returns at most two issues per page, fewer than requested,
+	// and a short last page
+	var startAts []int
+	server := newTestServer(t, "/rest/api/2/search", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		assert.Equal(t, taskQuery(testProject), query.Get("jql"))
+		assert.Equal(t, "summary,description,status,issuetype,assignee,customfield_10050", query.Get("fields"))
+		assert.Equal(t, strconv.Itoa(searchPageSize), query.Get("maxResults"))
+
+		startAt, _ := strconv.Atoi(query.Get("startAt"))
+		startAts = append(startAts, startAt)
+		end := min(startAt+2, len(issues))
+		json.NewEncoder(w).Encode(map[string]any{
+			"startAt":    startAt,
+			"maxResults": 2,
+			"total":      len(issues),
+			"issues":     issues[startAt:end],
+		})
+	})
+
+	keys := taskKeys(t, newTestClient(t, server, APIVersion2))
+	assert.Equal(t, []string{"PROJ-1", "PROJ-2", "PROJ-3", "PROJ-4", "PROJ-5"}, keys)
+	assert.Equal(t, []int{0, 2, 4}, startAts)
+}
+
+func TestGetTasksPagesByToken(t *testing.T) {
+	issues := testIssues(3)
+	var tokens []string
+	server := newTestServer(t, "/rest/api/3/search/jql", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		var body struct {
+			JQL           string   `json:"jql"`
+			Fields        []string `json:"fields"`
+			MaxResults    int      `json:"maxResults"`
+			NextPageToken string   `json:"nextPageToken"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, taskQuery(testProject), body.JQL)
+		assert.Equal(t, []string{"summary", "description", "status", "issuetype", "assignee", "customfield_10050"}, body.Fields)
+		assert.Equal(t, searchPageSize, body.MaxResults)
+		tokens = append(tokens, body.NextPageToken)
+
+		// Two pages of one issue carry a next page token; the third is
+		// marked last but still carries one, which must not be followed
+		page := map[string]any{"isLast": false}
+		switch body.NextPageToken {
+		case "":
+			page["issues"], page["nextPageToken"] = issues[:1], "page-2"
+		case "page-2":
+			page["issues"], page["nextPageToken"] = issues[1:2], "page-3"
+		case "page-3":
+			page["issues"], page["nextPageToken"], page["isLast"] = issues[2:], "page-4", true
+		default:
+			t.Errorf("unexpected page token %q", body.NextPageToken)
+		}
+		json.NewEncoder(w).Encode(page)
+	})
+
+	keys := taskKeys(t, newTestClient(t, server, APIVersion3))
+	assert.Equal(t, []string{"PROJ-1", "PROJ-2", "PROJ-3"}, keys)
+	assert.Equal(t, []string{"", "page-2", "page-3"}, tokens)
+}
+
+func TestTaskQueryQuotesStatuses(t *testing.T) {
+	assert.Equal(t,
+		`project = "PROJ" AND status IN ("Ready \"for\" Dev\\QA") AND (labels = khitomer) ORDER BY created ASC`,
+		taskQuery(testProject),
+	)
+	assert.Equal(t, `"a\\\"b"`, QuoteJQL(`a\"b`))
+	assert.False(t, strings.Contains(taskQuery(Project{Key: "PROJ"}), "status"))
+}
@@ -0,0 +1,163 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	jira "github.com/andygrunwald/go-jira"
+
+	"github.com/clintrovert/khitomer/internal/adf"
+)
+
+// API versions. Version 3, on Jira Cloud only, exchanges rich text as
+// Atlassian Document Format, which the client converts to and from Markdown.
+const (
+	APIVersion2 = 2
+	APIVersion3 = 3
+)
+
+// getIssue fetches an issue with its rich text as Markdown
+func (c *Client) getIssue(ctx context.Context, ticketID string) (*jira.Issue, error) {
+	if c.apiVersion < APIVersion3 {
+		issue, resp, err := c.client.Issue.GetWithContext(ctx, ticketID, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get issue: %w", classifyError(resp, err))
+		}
+		return issue, nil
+	}
+
+	var raw json.RawMessage
+	if err := c.doV3(ctx, "GET", "rest/api/3/issue/"+url.PathEscape(ticketID), nil, &raw); err != nil {
+		return nil, fmt.Errorf("failed to get issue: %w", err)
+	}
+	return decodeIssue(raw)
+}
+
+// addComment posts a Markdown comment, as ADF on version 3 and as is on
+// version 2
+func (c *Client) addComment(ctx context.Context, ticketID, markdown string) error {
+	if c.apiVersion < APIVersion3 {
+		_, resp, err := c.client.Issue.AddCommentWithContext(ctx, ticketID, &jira.Comment{Body: markdown})
+		if err != nil {
+			return fmt.Errorf("failed to add comment: %w", classifyError(resp, err))
+		}
+		return nil
+	}
+
+	body := map[string]interface{}{"body": adf.FromMarkdown(markdown)}
+	if err := c.doV3(ctx, "POST", "rest/api/3/issue/"+url.PathEscape(ticketID)+"/comment", body, nil); err != nil {
+		return fmt.Errorf("failed to add comment: %w", err)
+	}
+	return nil
+}
+
+// doV3 sends a request to the version 3 API, decoding the response into v
+// unless it is nil
+func (c *Client) doV3(ctx context.Context, method, path string, body, v interface{}) error {
+	req, err := c.client.NewRequestWithContext(ctx, method, path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.client.Do(req, v)
+	if err != nil {
+		return classifyError(resp, jira.NewJiraError(resp, err))
+	}
+	return nil
+}
+
+// decodeIssue decodes a version 3 issue, converting its description and
+// comment bodies from ADF to Markdown so they fit go-jira's string fields
+func decodeIssue(data []byte) (*jira.Issue, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode issue: %w", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if len(raw["fields"]) > 0 {
+		if err := json.Unmarshal(raw["fields"], &fields); err != nil {
+			return nil, fmt.Errorf("failed to decode issue fields: %w", err)
+		}
+	}
+	if description, ok := fields["description"]; ok {
+		text, err := richText(description)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode issue description: %w", err)
+		}
+		fields["description"], _ = json.Marshal(text)
+	}
+	if comments, ok := fields["comment"]; ok {
+		converted, err := convertComments(comments)
+		if err != nil {
+			return nil, err
+		}
+		fields["comment"] = converted
+	}
+	if fields != nil {
+		raw["fields"], _ = json.Marshal(fields)
+	}
+
+	data, _ = json.Marshal(raw)
+	var issue jira.Issue
+	if err := json.Unmarshal(data, &issue); err != nil {
+		return nil, fmt.Errorf("failed to decode issue: %w", err)
+	}
+	return &issue, nil
+}
+
+// convertComments converts the bodies of an issue's comment field
+func convertComments(data json.RawMessage) (json.RawMessage, error) {
+	var page map[string]json.RawMessage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, fmt.Errorf("failed to decode issue comments: %w", err)
+	}
+	var comments []json.RawMessage
+	if len(page["comments"]) > 0 {
+		if err := json.Unmarshal(page["comments"], &comments); err != nil {
+			return nil, fmt.Errorf("failed to decode issue comments: %w", err)
+		}
+	}
+	for i, comment := range comments {
+		converted, err := convertCommentBody(comment)
+		if err != nil {
+			return nil, err
+		}
+		comments[i] = converted
+	}
+	page["comments"], _ = json.Marshal(comments)
+	return json.Marshal(page)
+}
+
+// convertCommentBody converts a comment's body to Markdown
+func convertCommentBody(data json.RawMessage) (json.RawMessage, error) {
+	var comment map[string]json.RawMessage
+	if err := json.Unmarshal(data, &comment); err != nil {
+		return nil, fmt.Errorf("failed to decode comment: %w", err)
+	}
+	text, err := richText(comment["body"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode comment body: %w", err)
+	}
+	comment["body"], _ = json.Marshal(text)
+	return json.Marshal(comment)
+}
+
+// richText returns a rich text field as Markdown. The field may be an ADF
+// document, a plain string or null.
+func richText(data json.RawMessage) (string, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return "", nil
+	}
+	if data[0] == '"' {
+		var text string
+		err := json.Unmarshal(data, &text)
+		return text, err
+	}
+	doc, err := adf.Parse(data)
+	if err != nil {
+		return "", err
+	}
+	return adf.ToMarkdown(doc), nil
+}
@@ -0,0 +1,76 @@
+package jira
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira"
+)
+
+// SignatureHeader carries the HMAC-SHA256 signature of a webhook delivery,
+// as "sha256=<hex digest>" of the body keyed with the webhook's secret
+const SignatureHeader = "X-Hub-Signature"
+
+// ErrInvalidSignature is returned for webhook deliveries whose signature
+// doesn't match the configured secret
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// commentCreatedEvent is the webhook event sent for new comments
+const commentCreatedEvent = "comment_created"
+
+// VerifySignature checks the signature header of a webhook delivery
+func VerifySignature(secret string, body []byte, signature string) error {
+	digest, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return ErrInvalidSignature
+	}
+	got, err := hex.DecodeString(digest)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ParseWebhook returns the comment created in a webhook delivery, or nil
+// for other events
+func ParseWebhook(body []byte) (*Comment, error) {
+	var event struct {
+		WebhookEvent string          `json:"webhookEvent"`
+		Comment      json.RawMessage `json:"comment"`
+		Issue        *struct {
+			Key string `json:"key"`
+		} `json:"issue"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook: %w", err)
+	}
+
+	if event.WebhookEvent != commentCreatedEvent {
+		return nil, nil
+	}
+	if len(event.Comment) == 0 || string(event.Comment) == "null" || event.Issue == nil || event.Issue.Key == "" {
+		return nil, errors.New("comment event has no comment or issue")
+	}
+
+	// Bodies may be ADF or plain text, depending on the webhook's API version
+	data, err := convertCommentBody(event.Comment)
+	if err != nil {
+		return nil, err
+	}
+	var comment jira.Comment
+	if err := json.Unmarshal(data, &comment); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook comment: %w", err)
+	}
+	return newComment(event.Issue.Key, &comment), nil
+}
@@ -0,0 +1,157 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/temporal"
+	"github.com/clintrovert/khitomer/internal/temporal/workflows"
+	"github.com/clintrovert/khitomer/internal/usage"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// Budget prices planning calls and enforces the ticket and daily LLM
+// budgets. Spend is read back from the usage memos of the workflows that made
+// the calls, so it survives restarts and failovers. Plans that don't start a
+// workflow, e.g. previews without a diff, are only counted in memory by the
+// leader that made them.
+type Budget struct {
+	temporalClient *temporal.Client
+	logger         *zap.Logger
+
+	mu     sync.RWMutex
+	config usage.Config
+	// untracked is spend not recorded in any workflow on day
+	day       time.Time
+	untracked float64
+}
+
+// NewBudget creates a new budget
+func NewBudget(temporalClient *temporal.Client, config usage.Config, logger *zap.Logger) *Budget {
+	return &Budget{
+		temporalClient: temporalClient,
+		config:         config,
+		logger:         logger,
+	}
+}
+
+// SetConfig replaces the prices and budgets. Running workflows keep the
+// prices and budgets they were started with.
+func (b *Budget) SetConfig(config usage.Config) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.config = config
+}
+
+// Config returns the current prices and budgets
+func (b *Budget) Config() usage.Config {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.config
+}
+
+// Policy returns the usage policy to start a workflow for a ticket with.
+// The ticket budget is shared with the ticket's earlier runs, and the run may
+// spend no more than is left of the day's budget. An empty ticketID, as for
+// previews, has no earlier runs. It returns usage.ErrBudgetExceeded if the
+// day's budget is already spent.
+func (b *Budget) Policy(ctx context.Context, ticketID string) (workflows.UsagePolicy, error) {
+	config := b.Config()
+	policy := workflows.UsagePolicy{
+		Prices: config.Prices,
+		Budget: config.TicketBudget,
+	}
+
+	if config.TicketBudget > 0 && ticketID != "" {
+		spent, err := b.temporalClient.TicketSpend(ctx, ticketID)
+		if err != nil {
+			return policy, fmt.Errorf("failed to check ticket budget: %w", err)
+		}
+		policy.PriorCost = spent
+	}
+
+	if config.DailyBudget > 0 {
+		spent, err := b.SpentToday(ctx)
+		if err != nil {
+			return policy, fmt.Errorf("failed to check daily budget: %w", err)
+		}
+		if spent >= config.DailyBudget {
+			return policy, fmt.Errorf("%w: spent $%.2f of the $%.2f daily budget", usage.ErrBudgetExceeded, spent, config.DailyBudget)
+		}
+		policy.DailyAllowance = config.DailyBudget - spent
+	}
+	return policy, nil
+}
+
+// PricePlan sets the cost of the call that generated plan, if any
+func (b *Budget) PricePlan(plan *types.ImplementationPlan) {
+	if plan == nil || plan.Usage == nil {
+		return
+	}
+	cost, ok := b.Config().Prices.Cost(plan.Usage.Model, plan.Usage.PromptTokens, plan.Usage.CompletionTokens)
+	if !ok {
+		b.logger.Warn("no price for model, counting its usage as free", zap.String("model", plan.Usage.Model))
+	}
+	plan.Usage.Cost = cost
+	plan.Usage.Time = time.Now()
+	if plan.Exchange != nil {
+		plan.Exchange.Usage.Cost = cost
+		plan.Exchange.Usage.Time = plan.Usage.Time
+	}
+}
+
+// RecordUntracked counts the cost of a plan that did not start a workflow
+func (b *Budget) RecordUntracked(plan *types.ImplementationPlan) {
+	if plan == nil || plan.Usage == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if today := startOfDay(time.Now()); !b.day.Equal(today) {
+		b.day = today
+		b.untracked = 0
+	}
+	b.untracked += plan.Usage.Cost
+}
+
+// SpentToday returns what has been spent since the start of the UTC day
+func (b *Budget) SpentToday(ctx context.Context) (float64, error) {
+	today := startOfDay(time.Now())
+	spent, err := b.temporalClient.SpentSince(ctx, today)
+	if err != nil {
+		return 0, err
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.day.Equal(today) {
+		spent += b.untracked
+	}
+	return spent, nil
+}
+
+// CheckDaily returns usage.ErrBudgetExceeded once the day's spend has
+// reached the daily budget
+func (b *Budget) CheckDaily(ctx context.Context) error {
+	limit := b.Config().DailyBudget
+	if limit <= 0 {
+		return nil
+	}
+	spent, err := b.SpentToday(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check daily budget: %w", err)
+	}
+	if spent >= limit {
+		return fmt.Errorf("%w: spent $%.2f of the $%.2f daily budget", usage.ErrBudgetExceeded, spent, limit)
+	}
+	return nil
+}
+
+// startOfDay returns midnight UTC on t's day
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,406 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/auth"
+	"github.com/clintrovert/khitomer/internal/jira"
+	"github.com/clintrovert/khitomer/internal/metrics"
+	"github.com/clintrovert/khitomer/internal/temporal"
+	"github.com/clintrovert/khitomer/internal/temporal/workflows"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// CommandPrefix starts a command line in a Jira comment
+const CommandPrefix = "/khitomer"
+
+// Comment commands
+const (
+	CommandRetry   = "retry"
+	CommandCancel  = "cancel"
+	CommandApprove = "approve"
+	CommandReplan  = "replan"
+	CommandStatus  = "status"
+)
+
+// commandRoles are the roles needed to run each command, matching the API
+// calls they stand in for
+var commandRoles = map[string]auth.Role{
+	CommandRetry:   auth.RoleOperator,
+	CommandCancel:  auth.RoleOperator,
+	CommandApprove: auth.RoleOperator,
+	CommandReplan:  auth.RoleOperator,
+	CommandStatus:  auth.RoleViewer,
+}
+
+const (
+	// commandTimeout limits running one command, including waiting for a
+	// replanned run's predecessor to close
+	commandTimeout = 5 * time.Minute
+	// seenRetention is how long handled comment IDs are remembered, which
+	// must exceed the window in which a comment can be delivered twice
+	seenRetention = time.Hour
+)
+
+// Command is a command read from a Jira comment
+type Command struct {
+	Name string
+	// Args is the text after the command name, e.g. the feedback to replan
+	// with
+	Args string
+}
+
+// ParseCommand returns the first command in a comment body. A command line
+// starts with CommandPrefix; its arguments run to the end of the comment.
+func ParseCommand(body string) (Command, bool) {
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), CommandPrefix)
+		if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return Command{}, true
+		}
+		name := fields[0]
+		args := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), name))
+		if i+1 < len(lines) {
+			args = strings.TrimSpace(args + "\n" + strings.Join(lines[i+1:], "\n"))
+		}
+		return Command{Name: strings.ToLower(name), Args: args}, true
+	}
+	return Command{}, false
+}
+
+// Commander runs /khitomer commands from Jira comments against the ticket's
+// latest run and replies on the ticket with the result. Authors are checked
+// against the roles granted to their Jira groups.
+type Commander struct {
+	jiraClient     *jira.Client
+	temporalClient *temporal.Client
+	trigger        *Trigger
+	logger         *zap.Logger
+
+	mu         sync.Mutex
+	groupRoles map[string]auth.Role
+	self       *jira.User
+	// seen maps the IDs of handled comments to when they were handled
+	seen map[string]time.Time
+}
+
+// NewCommander creates a new commander. groupRoles grants the members of
+// Jira groups a role.
+func NewCommander(jiraClient *jira.Client, temporalClient *temporal.Client, trigger *Trigger, groupRoles map[string]auth.Role, logger *zap.Logger) *Commander {
+	return &Commander{
+		jiraClient:     jiraClient,
+		temporalClient: temporalClient,
+		trigger:        trigger,
+		groupRoles:     groupRoles,
+		seen:           make(map[string]time.Time),
+		logger:         logger,
+	}
+}
+
+// SetGroupRoles replaces the roles granted to Jira groups
+func (c *Commander) SetGroupRoles(groupRoles map[string]auth.Role) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.groupRoles = groupRoles
+}
+
+// Handle runs the command in a comment, if it has one. Comments are handled
+// at most once, however often they are delivered.
+func (c *Commander) Handle(ctx context.Context, comment *jira.Comment) {
+	cmd, ok := ParseCommand(comment.Body)
+	if !ok || !c.markSeen(comment.ID) {
+		return
+	}
+	if self, err := c.selfUser(ctx); err != nil {
+		c.logger.Warn("failed to get jira user", zap.Error(err))
+	} else if comment.Author.Is(self) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	logger := c.logger.With(
+		zap.String("jira_ticket", comment.TicketID),
+		zap.String("comment_id", comment.ID),
+		zap.String("command", cmd.Name),
+		zap.String("author", comment.Author.DisplayName),
+	)
+
+	label := cmd.Name
+	if _, ok := commandRoles[cmd.Name]; !ok {
+		label = "unknown"
+	}
+
+	reply, err := c.run(ctx, comment, cmd)
+	result := metrics.ResultSuccess
+	switch {
+	case errors.Is(err, auth.ErrPermissionDenied):
+		result = metrics.ResultDenied
+		logger.Warn("refused comment command", zap.Error(err))
+		reply = "you may not run this command: " + err.Error()
+	case err != nil:
+		result = metrics.ResultFailure
+		logger.Error("comment command failed", zap.Error(err))
+		reply = fmt.Sprintf("%s failed: %s", cmd.Name, err)
+	default:
+		logger.Info("ran comment command")
+	}
+	metrics.CommentCommands.WithLabelValues(label, result).Inc()
+
+	if err := c.jiraClient.Reply(ctx, comment, reply); err != nil {
+		logger.Error("failed to reply to comment command", zap.Error(err))
+	}
+}
+
+// markSeen records a comment as handled, reporting false if it already was
+func (c *Commander) markSeen(commentID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for id, handled := range c.seen {
+		if now.Sub(handled) > seenRetention {
+			delete(c.seen, id)
+		}
+	}
+	if _, ok := c.seen[commentID]; ok {
+		return false
+	}
+	c.seen[commentID] = now
+	return true
+}
+
+// selfUser returns the user the Jira client posts replies as
+func (c *Commander) selfUser(ctx context.Context) (jira.User, error) {
+	c.mu.Lock()
+	self := c.self
+	c.mu.Unlock()
+	if self != nil {
+		return *self, nil
+	}
+
+	user, err := c.jiraClient.Self(ctx)
+	if err != nil {
+		return jira.User{}, err
+	}
+	c.mu.Lock()
+	c.self = &user
+	c.mu.Unlock()
+	return user, nil
+}
+
+// run checks the author may run cmd and runs it, returning the reply
+func (c *Commander) run(ctx context.Context, comment *jira.Comment, cmd Command) (string, error) {
+	required, ok := commandRoles[cmd.Name]
+	if !ok {
+		return fmt.Sprintf("unknown command %q; use %s retry, cancel, approve [comment], replan <feedback> or status.", cmd.Name, CommandPrefix), nil
+	}
+	if err := c.authorize(ctx, comment.Author, required); err != nil {
+		return "", err
+	}
+
+	ticketID := comment.TicketID
+	switch cmd.Name {
+	case CommandStatus:
+		return c.status(ctx, ticketID)
+	case CommandCancel:
+		return c.cancel(ctx, ticketID)
+	case CommandApprove:
+		return c.approve(ctx, ticketID, comment.Author, cmd.Args)
+	case CommandRetry:
+		return c.start(ctx, ticketID, "", false)
+	default:
+		if cmd.Args == "" {
+			return "", fmt.Errorf("%w: %s replan needs feedback for the planner", ErrInvalidRequest, CommandPrefix)
+		}
+		return c.start(ctx, ticketID, cmd.Args, true)
+	}
+}
+
+// authorize checks that a Jira user's groups grant the required role
+func (c *Commander) authorize(ctx context.Context, user jira.User, required auth.Role) error {
+	groups, err := c.jiraClient.GetUserGroups(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	role, ok := auth.GroupRole(groups, c.groupRoles)
+	c.mu.Unlock()
+	if !ok || !role.Allows(required) {
+		return fmt.Errorf("%w: requires the %s role", auth.ErrPermissionDenied, required)
+	}
+	return nil
+}
+
+// latestRun returns the most recently started run for a ticket, or nil if
+// it has none
+func (c *Commander) latestRun(ctx context.Context, ticketID string) (*temporal.WorkflowSummary, error) {
+	runs, _, err := c.temporalClient.ListWorkflows(ctx, temporal.ListFilter{JiraTicket: ticketID}, 1, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, nil
+	}
+	return &runs[0], nil
+}
+
+// runningRun returns the ticket's latest run, which must be running
+func (c *Commander) runningRun(ctx context.Context, ticketID string) (*temporal.WorkflowSummary, error) {
+	run, err := c.latestRun(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil || run.Status != "Running" {
+		return nil, fmt.Errorf("%w: %s has no running workflow", ErrInvalidRequest, ticketID)
+	}
+	return run, nil
+}
+
+func (c *Commander) status(ctx context.Context, ticketID string) (string, error) {
+	run, err := c.latestRun(ctx, ticketID)
+	if err != nil {
+		return "", err
+	}
+	if run == nil {
+		return fmt.Sprintf("%s has no workflow yet.", ticketID), nil
+	}
+
+	reply := fmt.Sprintf("Workflow %s is %s", run.WorkflowID, strings.ToLower(run.Status))
+	if run.Outcome != "" {
+		reply += fmt.Sprintf(" (%s)", run.Outcome)
+	}
+	reply += fmt.Sprintf(". Started %s", run.StartTime.UTC().Format(time.RFC3339))
+	if !run.CloseTime.IsZero() {
+		reply += fmt.Sprintf(", closed %s", run.CloseTime.UTC().Format(time.RFC3339))
+	}
+	reply += fmt.Sprintf("; LLM cost so far $%.4f.", run.Usage.Cost)
+	if run.PRNumber != 0 {
+		reply += fmt.Sprintf(" Pull request #%d.", run.PRNumber)
+	}
+
+	if run.Status == "Running" {
+		events, err := c.temporalClient.WorkflowEvents(ctx, run.WorkflowID, 0)
+		if err != nil {
+			c.logger.Warn("failed to get workflow progress", zap.String("workflow_id", run.WorkflowID), zap.Error(err))
+		} else if len(events) > 0 {
+			last := events[len(events)-1]
+			reply += fmt.Sprintf(" Latest: %s %s", last.Type, last.Step)
+			if last.Message != "" {
+				reply += " - " + last.Message
+			}
+		}
+	}
+	return reply, nil
+}
+
+func (c *Commander) cancel(ctx context.Context, ticketID string) (string, error) {
+	run, err := c.runningRun(ctx, ticketID)
+	if err != nil {
+		return "", err
+	}
+	if err := c.temporalClient.CancelWorkflow(ctx, run.WorkflowID); err != nil {
+		return "", fmt.Errorf("failed to cancel workflow: %w", err)
+	}
+	return fmt.Sprintf("Cancelled workflow %s.", run.WorkflowID), nil
+}
+
+func (c *Commander) approve(ctx context.Context, ticketID string, author jira.User, comment string) (string, error) {
+	run, err := c.runningRun(ctx, ticketID)
+	if err != nil {
+		return "", err
+	}
+	events, err := c.temporalClient.WorkflowEvents(ctx, run.WorkflowID, 0)
+	if err != nil {
+		return "", err
+	}
+	order, ok := pendingReview(events)
+	if !ok {
+		return "", fmt.Errorf("%w: workflow %s is not waiting for a review", ErrInvalidRequest, run.WorkflowID)
+	}
+
+	decision := workflows.ReviewDecision{
+		Approved: true,
+		Reviewer: author.DisplayName,
+		Comment:  comment,
+	}
+	if err := c.temporalClient.SubmitReview(ctx, run.WorkflowID, order, decision); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Approved step %d of workflow %s.", order, run.WorkflowID), nil
+}
+
+// pendingReview returns the order of the review step that has started but
+// not completed, if any
+func pendingReview(events []workflows.ProgressEvent) (int, bool) {
+	pending := make(map[int]bool)
+	for _, event := range events {
+		if event.Step != types.StepTypeReview {
+			continue
+		}
+		switch event.Type {
+		case workflows.EventStepStarted:
+			pending[event.StepOrder] = true
+		case workflows.EventStepCompleted:
+			delete(pending, event.StepOrder)
+		}
+	}
+	for order := range pending {
+		return order, true
+	}
+	return 0, false
+}
+
+// start retries or replans a ticket. The ticket is planned again for the
+// repository of its latest run. A running run is refused for retries and
+// cancelled for replans.
+func (c *Commander) start(ctx context.Context, ticketID, feedback string, replace bool) (string, error) {
+	run, err := c.latestRun(ctx, ticketID)
+	if err != nil {
+		return "", err
+	}
+
+	req := StartRequest{
+		TaskRequest: TaskRequest{JiraTicketID: ticketID},
+		Feedback:    feedback,
+	}
+	if run != nil {
+		req.RepositoryOwner, req.RepositoryName, _ = strings.Cut(run.Repository, "/")
+
+		if run.Status == "Running" {
+			if !replace {
+				return "", fmt.Errorf("%w: workflow %s is still running", ErrInvalidRequest, run.WorkflowID)
+			}
+			if err := c.temporalClient.CancelWorkflow(ctx, run.WorkflowID); err != nil {
+				return "", fmt.Errorf("failed to cancel workflow: %w", err)
+			}
+			if err := c.temporalClient.WaitWorkflow(ctx, run.WorkflowID); err != nil {
+				return "", fmt.Errorf("failed waiting for workflow %s to stop: %w", run.WorkflowID, err)
+			}
+		}
+	}
+
+	workflowID, err := c.trigger.Start(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if replace {
+		return fmt.Sprintf("Replanned with your feedback and started workflow %s.", workflowID), nil
+	}
+	return fmt.Sprintf("Started workflow %s.", workflowID), nil
+}
@@ -2,22 +2,61 @@ package leader
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
 	"github.com/clintrovert/khitomer/internal/jira"
+	"github.com/clintrovert/khitomer/internal/metrics"
 	"github.com/clintrovert/khitomer/internal/planner"
 	"github.com/clintrovert/khitomer/internal/temporal"
+	"github.com/clintrovert/khitomer/internal/tracing"
+	"github.com/clintrovert/khitomer/internal/usage"
 	"github.com/clintrovert/khitomer/pkg/types"
 )
 
+const (
+	// DefaultWorkers is the default number of tasks processed at once
+	DefaultWorkers = 4
+	// DefaultMaxPerRepository is the default number of tasks processed at
+	// once for a single repository
+	DefaultMaxPerRepository = 2
+	// DefaultTaskTimeout is the default time allowed to plan a task and start
+	// its workflow
+	DefaultTaskTimeout = 5 * time.Minute
+	// DefaultDrainTimeout is the default time in-flight tasks are given to
+	// finish on shutdown
+	DefaultDrainTimeout = 30 * time.Second
+)
+
+// OrchestratorConfig bounds how tasks are processed. Zero values use the
+// defaults.
+type OrchestratorConfig struct {
+	// Workers is the most tasks processed at once
+	Workers int
+	// MaxPerRepository is the most tasks processed at once for one repository
+	MaxPerRepository int
+	// TaskTimeout limits planning a task and starting its workflow
+	TaskTimeout time.Duration
+	// DrainTimeout is how long in-flight tasks may run after shutdown begins
+	// before they are cancelled
+	DrainTimeout time.Duration
+}
+
 // Orchestrator coordinates Jira polling, AI planning, and workflow spawning
 type Orchestrator struct {
-	jiraPoller  *jira.Poller
-	planner     planner.Planner
+	jiraPoller     *jira.Poller
+	planner        planner.Planner
 	temporalClient *temporal.Client
-	logger      *zap.Logger
+	budget         *Budget
+	logger         *zap.Logger
+
+	mu     sync.RWMutex
+	config OrchestratorConfig
 }
 
 // NewOrchestrator creates a new orchestrator
@@ -25,36 +64,177 @@ func NewOrchestrator(
 	jiraPoller *jira.Poller,
 	planner planner.Planner,
 	temporalClient *temporal.Client,
+	budget *Budget,
+	config OrchestratorConfig,
 	logger *zap.Logger,
 ) *Orchestrator {
 	return &Orchestrator{
 		jiraPoller:     jiraPoller,
 		planner:        planner,
 		temporalClient: temporalClient,
+		budget:         budget,
+		config:         withDefaults(config),
 		logger:         logger,
 	}
 }
 
-// Start starts the orchestration loop
+// SetConfig replaces the orchestrator's limits. Running tasks keep their
+// timeouts; new limits apply as tasks are started.
+func (o *Orchestrator) SetConfig(config OrchestratorConfig) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.config = withDefaults(config)
+}
+
+// limits returns the current limits
+func (o *Orchestrator) limits() OrchestratorConfig {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.config
+}
+
+// withDefaults fills zero limits with the defaults
+func withDefaults(config OrchestratorConfig) OrchestratorConfig {
+	if config.Workers <= 0 {
+		config.Workers = DefaultWorkers
+	}
+	if config.MaxPerRepository <= 0 {
+		config.MaxPerRepository = DefaultMaxPerRepository
+	}
+	if config.TaskTimeout <= 0 {
+		config.TaskTimeout = DefaultTaskTimeout
+	}
+	if config.DrainTimeout <= 0 {
+		config.DrainTimeout = DefaultDrainTimeout
+	}
+	return config
+}
+
+// Start starts the orchestration loop. Tasks are processed concurrently
+// within the configured limits; a task whose repository is at its limit
+// waits without holding up tasks for other repositories. Once ctx is done,
+// queued tasks are dropped and Start returns after in-flight tasks finish or
+// the drain timeout cancels them.
 func (o *Orchestrator) Start(ctx context.Context) error {
 	taskChan := make(chan *types.Task, 10)
 
+	// Tasks queued but not started in an earlier term were never processed.
+	// Polling them again is safe as tasks with workflows are skipped.
+	o.jiraPoller.ClearProcessed()
+
 	// Start Jira polling in background
 	go o.jiraPoller.Start(ctx, taskChan)
 
+	// In-flight tasks outlive ctx so shutdown can drain them
+	taskCtx, cancelTasks := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelTasks()
+
+	var queue []*types.Task
+	running := 0
+	runningByRepo := make(map[string]int)
+	done := make(chan string)
+
+	// dispatch starts queued tasks in order, skipping those whose repository
+	// is at its limit
+	dispatch := func() {
+		limits := o.limits()
+		for i := 0; i < len(queue) && running < limits.Workers; {
+			task := queue[i]
+			repo := repositoryKey(task)
+			if runningByRepo[repo] >= limits.MaxPerRepository {
+				i++
+				continue
+			}
+
+			queue = append(queue[:i], queue[i+1:]...)
+			running++
+			runningByRepo[repo]++
+			go func() {
+				o.runTask(taskCtx, task)
+				done <- repo
+			}()
+		}
+	}
+
+	finish := func(repo string) {
+		running--
+		runningByRepo[repo]--
+		if runningByRepo[repo] == 0 {
+			delete(runningByRepo, repo)
+		}
+	}
+
 	// Process tasks as they come in
 	for {
 		select {
 		case <-ctx.Done():
+			if len(queue) > 0 {
+				o.logger.Info("dropping queued tasks", zap.Int("tasks", len(queue)))
+			}
+			metrics.TaskBacklog.Set(0)
+			o.drain(running, done, cancelTasks)
+			metrics.TasksRunning.Set(0)
 			return ctx.Err()
 		case task := <-taskChan:
-			if err := o.processTask(ctx, task); err != nil {
-				o.logger.Error("failed to process task",
-					zap.String("jira_ticket", task.JiraTicketID),
-					zap.Error(err),
-				)
-			}
+			queue = append(queue, task)
+		case repo := <-done:
+			finish(repo)
 		}
+		dispatch()
+		metrics.TaskBacklog.Set(float64(len(queue) + len(taskChan)))
+		metrics.TasksRunning.Set(float64(running))
+	}
+}
+
+// drain waits for running tasks to finish, cancelling them if they outlast
+// the drain timeout
+func (o *Orchestrator) drain(running int, done <-chan string, cancelTasks context.CancelFunc) {
+	if running == 0 {
+		return
+	}
+	o.logger.Info("waiting for in-flight tasks", zap.Int("tasks", running))
+
+	timer := time.NewTimer(o.limits().DrainTimeout)
+	defer timer.Stop()
+
+	for running > 0 {
+		select {
+		case <-done:
+			running--
+		case <-timer.C:
+			o.logger.Warn("cancelling in-flight tasks", zap.Int("tasks", running))
+			cancelTasks()
+		}
+	}
+}
+
+// runTask processes a task within the task timeout and logs any failure.
+// Each task starts a new trace, linked to the poll that found it.
+func (o *Orchestrator) runTask(ctx context.Context, task *types.Task) {
+	ctx, cancel := context.WithTimeout(ctx, o.limits().TaskTimeout)
+	defer cancel()
+
+	ctx, span := tracing.Start(ctx, "ProcessTicket",
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.Link{SpanContext: o.jiraPoller.PollSpanContext(task.JiraTicketID)}),
+		trace.WithAttributes(tracing.TaskAttributes(task)...),
+	)
+	err := o.processTask(ctx, task)
+	tracing.End(span, err)
+	if errors.Is(err, usage.ErrBudgetExceeded) {
+		// Poll the ticket again so it is picked up once the budget allows
+		o.jiraPoller.Release(task.JiraTicketID)
+		o.logger.Warn("holding task until the daily budget allows it",
+			zap.String("jira_ticket", task.JiraTicketID),
+			zap.Error(err),
+		)
+		return
+	}
+	if err != nil {
+		o.logger.Error("failed to process task",
+			zap.String("jira_ticket", task.JiraTicketID),
+			zap.Error(err),
+		)
 	}
 }
 
@@ -65,11 +245,27 @@ func (o *Orchestrator) processTask(ctx context.Context, task *types.Task) error
 		zap.String("repository", task.RepositoryName),
 	)
 
+	// Skip tasks another leader already started, e.g. before a failover
+	started, err := o.temporalClient.HasWorkflow(ctx, task)
+	if err != nil {
+		return err
+	}
+	if started {
+		metrics.TicketsDeduplicated.WithLabelValues(metrics.DedupWorkflowExists).Inc()
+		o.logger.Info("skipping task with existing workflow", zap.String("jira_ticket", task.JiraTicketID))
+		return nil
+	}
+
+	if err := o.budget.CheckDaily(ctx); err != nil {
+		return err
+	}
+
 	// Generate implementation plan
-	plan, err := o.planner.Plan(task)
+	plan, err := o.planner.Plan(ctx, task)
 	if err != nil {
 		return fmt.Errorf("failed to generate plan: %w", err)
 	}
+	o.budget.PricePlan(plan)
 
 	// Create repository info
 	repo := &types.RepositoryInfo{
@@ -80,8 +276,14 @@ func (o *Orchestrator) processTask(ctx context.Context, task *types.Task) error
 	}
 
 	// Start workflow
-	workflowID, err := o.temporalClient.StartWorkflow(ctx, task, plan, repo)
+	policy, err := o.budget.Policy(ctx, task.JiraTicketID)
 	if err != nil {
+		o.budget.RecordUntracked(plan)
+		return err
+	}
+	workflowID, err := o.temporalClient.StartWorkflow(ctx, task, plan, repo, policy)
+	if err != nil {
+		o.budget.RecordUntracked(plan)
 		return fmt.Errorf("failed to start workflow: %w", err)
 	}
 
@@ -93,3 +295,7 @@ func (o *Orchestrator) processTask(ctx context.Context, task *types.Task) error
 	return nil
 }
 
+// repositoryKey identifies a task's repository for per-repository limits
+func repositoryKey(task *types.Task) string {
+	return task.RepositoryOwner + "/" + task.RepositoryName
+}
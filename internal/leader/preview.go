@@ -0,0 +1,122 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/jira"
+	"github.com/clintrovert/khitomer/internal/planner"
+	"github.com/clintrovert/khitomer/internal/temporal"
+	"github.com/clintrovert/khitomer/internal/temporal/workflows"
+	"github.com/clintrovert/khitomer/internal/tracing"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// PreviewRequest identifies a ticket to preview, either by Jira key or
+// inline
+type PreviewRequest struct {
+	TaskRequest
+	// IncludeDiff also runs code generation in a throwaway workspace and
+	// returns the proposed diff
+	IncludeDiff bool
+}
+
+// Preview is what Khitomer would do for a ticket
+type Preview struct {
+	Task *types.Task
+	Plan *types.ImplementationPlan
+	// Result is only set when the diff was requested
+	Result *workflows.PreviewResult
+	// Usage is the LLM usage of planning and, with the diff, code generation
+	Usage *types.UsageTotals
+}
+
+// Previewer plans tickets without pushing, opening pull requests or
+// commenting on Jira
+type Previewer struct {
+	jiraClient     *jira.Client
+	planner        planner.Planner
+	temporalClient *temporal.Client
+	budget         *Budget
+	logger         *zap.Logger
+}
+
+// NewPreviewer creates a new previewer. jiraClient may be nil, in which case
+// only inline requests can be previewed.
+func NewPreviewer(jiraClient *jira.Client, planner planner.Planner, temporalClient *temporal.Client, budget *Budget, logger *zap.Logger) *Previewer {
+	return &Previewer{
+		jiraClient:     jiraClient,
+		planner:        planner,
+		temporalClient: temporalClient,
+		budget:         budget,
+		logger:         logger,
+	}
+}
+
+// Preview returns the plan the configured planner makes for a ticket and,
+// if requested, the diff its codegen steps would produce. Previews count
+// towards the daily budget and are refused with usage.ErrBudgetExceeded once
+// it is spent.
+func (p *Previewer) Preview(ctx context.Context, req PreviewRequest) (_ *Preview, err error) {
+	ctx, span := tracing.Start(ctx, "PreviewTicket", trace.WithAttributes(
+		attribute.String("jira.ticket", req.JiraTicketID),
+		attribute.Bool("include_diff", req.IncludeDiff),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if req.JiraTicketID == "" && req.Title == "" {
+		return nil, fmt.Errorf("%w: jira_ticket_id or title is required", ErrInvalidRequest)
+	}
+	task, err := resolveTask(ctx, p.jiraClient, req.TaskRequest)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.budget.CheckDaily(ctx); err != nil {
+		return nil, err
+	}
+
+	plan, err := p.planner.Plan(ctx, task)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate plan: %w", err)
+	}
+	p.budget.PricePlan(plan)
+
+	preview := &Preview{Task: task, Plan: plan, Usage: &types.UsageTotals{}}
+	if plan.Usage != nil {
+		preview.Usage.Add(*plan.Usage)
+	}
+	if !req.IncludeDiff {
+		p.budget.RecordUntracked(plan)
+		return preview, nil
+	}
+
+	repo := &types.RepositoryInfo{
+		Owner:      task.RepositoryOwner,
+		Name:       task.RepositoryName,
+		BaseBranch: task.BaseBranch,
+		CloneURL:   task.RepositoryURL,
+	}
+	policy, err := p.budget.Policy(ctx, "")
+	if err != nil {
+		p.budget.RecordUntracked(plan)
+		return nil, err
+	}
+	preview.Result, err = p.temporalClient.PreviewPlan(ctx, task, plan, repo, policy)
+	if err != nil {
+		return nil, err
+	}
+	if preview.Result.Usage != nil {
+		preview.Usage = preview.Result.Usage
+	}
+
+	p.logger.Info("previewed plan",
+		zap.String("jira_ticket", task.JiraTicketID),
+		zap.Int("files_changed", preview.Result.Diff.FilesChanged),
+	)
+
+	return preview, nil
+}
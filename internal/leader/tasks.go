@@ -0,0 +1,102 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/clintrovert/khitomer/internal/jira"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// ErrInvalidRequest is returned for requests that are missing required
+// fields or combine them incorrectly
+var ErrInvalidRequest = errors.New("invalid request")
+
+// defaultBaseBranch is used when neither the request nor the ticket names a
+// base branch
+const defaultBaseBranch = "main"
+
+// TaskRequest identifies a ticket, either by Jira key or inline. Repository
+// fields override those found on the Jira ticket.
+type TaskRequest struct {
+	JiraTicketID    string
+	Title           string
+	Description     string
+	RepositoryOwner string
+	RepositoryName  string
+	BaseBranch      string
+}
+
+// resolveTask builds the task for a request, fetching the Jira ticket if a
+// key was given. jiraClient may be nil if Jira is not configured.
+func resolveTask(ctx context.Context, jiraClient *jira.Client, req TaskRequest) (*types.Task, error) {
+	task := &types.Task{
+		Title:       req.Title,
+		Description: req.Description,
+	}
+
+	if req.JiraTicketID != "" {
+		if jiraClient == nil {
+			return nil, fmt.Errorf("%w: Jira is not configured", ErrInvalidRequest)
+		}
+		var err error
+		task, err = jiraClient.GetTask(ctx, req.JiraTicketID)
+		if errors.Is(err, jira.ErrIssueNotFound) || errors.Is(err, jira.ErrBadRequest) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if req.RepositoryOwner != "" {
+		task.RepositoryOwner = req.RepositoryOwner
+	}
+	if req.RepositoryName != "" {
+		task.RepositoryName = req.RepositoryName
+	}
+	if task.RepositoryOwner == "" || task.RepositoryName == "" {
+		return nil, fmt.Errorf("%w: repository_owner and repository_name are required", ErrInvalidRequest)
+	}
+	task.RepositoryURL = fmt.Sprintf("https://github.com/%s/%s", task.RepositoryOwner, task.RepositoryName)
+
+	if req.BaseBranch != "" {
+		task.BaseBranch = req.BaseBranch
+	}
+	if task.BaseBranch == "" {
+		task.BaseBranch = defaultBaseBranch
+	}
+
+	return task, nil
+}
+
+// validatePlan checks a caller-supplied plan before a workflow is started
+// with it
+func validatePlan(plan *types.ImplementationPlan) error {
+	if len(plan.Steps) == 0 {
+		return fmt.Errorf("%w: plan has no steps", ErrInvalidRequest)
+	}
+
+	hasCodegen := false
+	for _, step := range plan.Steps {
+		switch step.ActivityType {
+		case types.StepTypeCodegen:
+			hasCodegen = true
+		case types.StepTypeTesting, types.StepTypeDeployment, types.StepTypeReview:
+		default:
+			return fmt.Errorf("%w: step %d has unknown activity type %q", ErrInvalidRequest, step.Order, step.ActivityType)
+		}
+		if step.Description == "" {
+			return fmt.Errorf("%w: step %d has no description", ErrInvalidRequest, step.Order)
+		}
+	}
+	if !hasCodegen {
+		return fmt.Errorf("%w: plan has no codegen steps", ErrInvalidRequest)
+	}
+
+	if _, err := plan.OrderedSteps(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+	return nil
+}
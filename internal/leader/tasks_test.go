@@ -0,0 +1,101 @@
+package leader
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/clintrovert/khitomer/internal/jira"
+)
+
+// newTestJiraClient returns a client for a Jira site with one ticket,
+// PROJ-1, whose repository field holds repository
+func newTestJiraClient(t *testing.T, repository string) *jira.Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/2/field", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]any{
+			{"id": "customfield_10050", "name": "Repository", "custom": true},
+		})
+	})
+	mux.HandleFunc("/rest/api/2/issue/PROJ-1", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"key": "PROJ-1",
+			"fields": map[string]any{
+				"summary":           "Add health check",
+				"customfield_10050": repository,
+			},
+		})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{"errorMessages": []string{"Issue does not exist"}})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client, err := jira.NewClient(server.URL, "khitomer", "token", "customfield_10050", jira.APIVersion2, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	return client
+}
+
+func TestResolveTask(t *testing.T) {
+	tests := []struct {
+		name       string
+		repository string
+		req        TaskRequest
+		wantURL    string
+		wantErr    error
+	}{
+		{
+			name:       "repository from ticket",
+			repository: "acme/api",
+			req:        TaskRequest{JiraTicketID: "PROJ-1"},
+			wantURL:    "https://github.com/acme/api",
+		},
+		{
+			name:       "incomplete repository",
+			repository: "acme/",
+			req:        TaskRequest{JiraTicketID: "PROJ-1"},
+			wantErr:    ErrInvalidRequest,
+		},
+		{
+			name:       "incomplete repository overridden",
+			repository: "acme/",
+			req:        TaskRequest{JiraTicketID: "PROJ-1", RepositoryOwner: "acme", RepositoryName: "web"},
+			wantURL:    "https://github.com/acme/web",
+		},
+		{
+			name:       "name overridden",
+			repository: "acme/api",
+			req:        TaskRequest{JiraTicketID: "PROJ-1", RepositoryName: "web"},
+			wantURL:    "https://github.com/acme/web",
+		},
+		{
+			name:       "unknown ticket",
+			repository: "acme/api",
+			req:        TaskRequest{JiraTicketID: "PROJ-2"},
+			wantErr:    ErrInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, err := resolveTask(t.Context(), newTestJiraClient(t, tt.repository), tt.req)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "resolveTask returned %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "PROJ-1", task.JiraTicketID)
+			assert.Equal(t, tt.wantURL, task.RepositoryURL)
+			assert.Equal(t, defaultBaseBranch, task.BaseBranch)
+		})
+	}
+}
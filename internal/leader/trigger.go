@@ -0,0 +1,105 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/jira"
+	"github.com/clintrovert/khitomer/internal/planner"
+	"github.com/clintrovert/khitomer/internal/temporal"
+	"github.com/clintrovert/khitomer/internal/tracing"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// StartRequest identifies a Jira ticket to implement. Repository fields
+// override those found on the ticket.
+type StartRequest struct {
+	TaskRequest
+	// Plan is used instead of running the planner if set
+	Plan *types.ImplementationPlan
+	// Feedback on an earlier plan is passed to the planner
+	Feedback string
+}
+
+// Trigger starts implementation workflows for manually requested tickets
+type Trigger struct {
+	jiraClient     *jira.Client
+	planner        planner.Planner
+	temporalClient *temporal.Client
+	budget         *Budget
+	logger         *zap.Logger
+}
+
+// NewTrigger creates a new trigger
+func NewTrigger(jiraClient *jira.Client, planner planner.Planner, temporalClient *temporal.Client, budget *Budget, logger *zap.Logger) *Trigger {
+	return &Trigger{
+		jiraClient:     jiraClient,
+		planner:        planner,
+		temporalClient: temporalClient,
+		budget:         budget,
+		logger:         logger,
+	}
+}
+
+// Start fetches the ticket, plans it unless the request carries a plan, and
+// starts its workflow. It returns the workflow ID, or usage.ErrBudgetExceeded
+// once the daily budget is spent.
+func (t *Trigger) Start(ctx context.Context, req StartRequest) (workflowID string, err error) {
+	ctx, span := tracing.Start(ctx, "TriggerTicket", trace.WithAttributes(attribute.String("jira.ticket", req.JiraTicketID)))
+	defer func() { tracing.End(span, err) }()
+
+	if req.JiraTicketID == "" {
+		return "", fmt.Errorf("%w: jira_ticket_id is required", ErrInvalidRequest)
+	}
+	task, err := resolveTask(ctx, t.jiraClient, req.TaskRequest)
+	if err != nil {
+		return "", err
+	}
+	if err := t.budget.CheckDaily(ctx); err != nil {
+		return "", err
+	}
+	task.PlanFeedback = req.Feedback
+
+	plan := req.Plan
+	if plan != nil {
+		if err := validatePlan(plan); err != nil {
+			return "", err
+		}
+	} else {
+		plan, err = t.planner.Plan(ctx, task)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate plan: %w", err)
+		}
+		t.budget.PricePlan(plan)
+	}
+
+	repo := &types.RepositoryInfo{
+		Owner:      task.RepositoryOwner,
+		Name:       task.RepositoryName,
+		BaseBranch: task.BaseBranch,
+		CloneURL:   task.RepositoryURL,
+	}
+
+	policy, err := t.budget.Policy(ctx, task.JiraTicketID)
+	if err != nil {
+		t.budget.RecordUntracked(plan)
+		return "", err
+	}
+	workflowID, err = t.temporalClient.StartWorkflow(ctx, task, plan, repo, policy)
+	if err != nil {
+		t.budget.RecordUntracked(plan)
+		return "", err
+	}
+
+	t.logger.Info("started workflow for manual trigger",
+		zap.String("jira_ticket", task.JiraTicketID),
+		zap.String("workflow_id", workflowID),
+		zap.Bool("caller_plan", req.Plan != nil),
+	)
+
+	return workflowID, nil
+}
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+)
+
+// NewWorkerInterceptor returns a worker interceptor that records the
+// duration of each activity attempt and counts retries
+func NewWorkerInterceptor() interceptor.WorkerInterceptor {
+	return &workerInterceptor{}
+}
+
+type workerInterceptor struct {
+	interceptor.WorkerInterceptorBase
+}
+
+// InterceptActivity implements interceptor.WorkerInterceptor
+func (w *workerInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	i := &activityInterceptor{}
+	i.Next = next
+	return i
+}
+
+type activityInterceptor struct {
+	interceptor.ActivityInboundInterceptorBase
+}
+
+// ExecuteActivity implements interceptor.ActivityInboundInterceptor
+func (a *activityInterceptor) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	info := activity.GetInfo(ctx)
+	if info.Attempt > 1 {
+		ActivityRetries.WithLabelValues(info.ActivityType.Name).Inc()
+	}
+
+	start := time.Now()
+	result, err := a.Next.ExecuteActivity(ctx, in)
+	ActivityDuration.WithLabelValues(info.ActivityType.Name, Result(err)).Observe(time.Since(start).Seconds())
+	return result, err
+}
@@ -0,0 +1,151 @@
+// Package metrics defines the Prometheus metrics exported by the leader and
+// worker on /metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric defined here
+const namespace = "khitomer"
+
+// Reasons a polled ticket is not processed again
+const (
+	DedupAlreadyProcessed = "already_processed"
+	DedupWorkflowExists   = "workflow_exists"
+)
+
+// Results recorded by the result label
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	// ResultDenied is recorded for requests the caller may not make
+	ResultDenied = "denied"
+)
+
+var (
+	// TicketsPolled counts ready tickets returned by Jira polls
+	TicketsPolled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tickets_polled_total",
+		Help:      "Ready tickets returned by Jira polls.",
+	}, []string{"project"})
+
+	// TicketsDeduplicated counts polled tickets skipped as already handled
+	TicketsDeduplicated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tickets_deduplicated_total",
+		Help:      "Polled tickets skipped because they were already processed or have a workflow.",
+	}, []string{"reason"})
+
+	// TaskBacklog is the number of polled tickets waiting to be processed
+	TaskBacklog = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "task_backlog",
+		Help:      "Polled tickets waiting to be processed, including those not yet received from the poller.",
+	})
+
+	// TasksRunning is the number of tickets being planned and started
+	TasksRunning = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "tasks_running",
+		Help:      "Tickets currently being planned and started.",
+	})
+
+	// PlansGenerated counts plan generation attempts
+	PlansGenerated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "plans_generated_total",
+		Help:      "Implementation plans generated, by result.",
+	}, []string{"model", "result"})
+
+	// PlanDuration observes how long plan generation takes
+	PlanDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "plan_duration_seconds",
+		Help:      "Time taken to generate an implementation plan.",
+		Buckets:   []float64{1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"model"})
+
+	// PlanTokens counts tokens used generating plans
+	PlanTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "plan_tokens_total",
+		Help:      "Tokens used generating implementation plans, by type (prompt or completion).",
+	}, []string{"model", "type"})
+
+	// WorkflowsStarted counts workflows started by the leader
+	WorkflowsStarted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "workflows_started_total",
+		Help:      "Workflows started by the leader, by workflow type.",
+	}, []string{"workflow_type"})
+
+	// CommentCommands counts commands read from Jira comments
+	CommentCommands = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "comment_commands_total",
+		Help:      "Commands read from Jira comments, by command and result.",
+	}, []string{"command", "result"})
+
+	// ActivityDuration observes activity attempts
+	ActivityDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "activity_duration_seconds",
+		Help:      "Time taken by each activity attempt, by result.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 300, 900, 1800},
+	}, []string{"activity_type", "result"})
+
+	// ActivityRetries counts activity attempts after the first
+	ActivityRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "activity_retries_total",
+		Help:      "Activity attempts after the first.",
+	}, []string{"activity_type"})
+
+	// TestRuns counts test runs by whether they passed
+	TestRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "test_runs_total",
+		Help:      "Test runs of generated changes, by result.",
+	}, []string{"result"})
+
+	// PullRequestsCreated counts pull requests opened
+	PullRequestsCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_requests_created_total",
+		Help:      "Pull requests opened, by whether they are drafts.",
+	}, []string{"draft"})
+
+	// APIRequestDuration observes calls to external APIs
+	APIRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of Jira and GitHub API calls.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service", "method"})
+
+	// APIRequestErrors counts failed calls to external APIs
+	APIRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_request_errors_total",
+		Help:      "Jira and GitHub API calls that failed or returned an error status, by status code (0 if no response).",
+	}, []string{"service", "code"})
+)
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Result returns the result label for err
+func Result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
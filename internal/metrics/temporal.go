@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.temporal.io/sdk/client"
+)
+
+// temporalBuckets are the histogram buckets, in seconds, for Temporal SDK
+// timers, which range from request latencies to workflow run times
+var temporalBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600}
+
+// NewTemporalHandler returns a Temporal SDK metrics handler that exports the
+// SDK's metrics, and those recorded through workflow.GetMetricsHandler, to
+// Prometheus. Timers are exported as histograms in seconds.
+//
+// Prometheus needs a fixed set of labels per metric, so each metric keeps the
+// tag keys it is first recorded with; later tags outside that set are dropped
+// and missing ones are empty.
+func NewTemporalHandler() client.MetricsHandler {
+	return &temporalHandler{
+		vecs: &temporalVecs{
+			registerer: prometheus.DefaultRegisterer,
+			vecs:       make(map[string]*temporalVec),
+		},
+	}
+}
+
+// temporalHandler is a client.MetricsHandler with a fixed set of tags
+type temporalHandler struct {
+	vecs *temporalVecs
+	tags map[string]string
+}
+
+// WithTags implements client.MetricsHandler
+func (h *temporalHandler) WithTags(tags map[string]string) client.MetricsHandler {
+	merged := make(map[string]string, len(h.tags)+len(tags))
+	for k, v := range h.tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return &temporalHandler{vecs: h.vecs, tags: merged}
+}
+
+// Counter implements client.MetricsHandler
+func (h *temporalHandler) Counter(name string) client.MetricsCounter {
+	vec := h.vecs.get(name, h.tags, func(labels []string) prometheus.Collector {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: "Temporal SDK counter " + name + "."}, labels)
+	})
+	counter := vec.collector.(*prometheus.CounterVec).WithLabelValues(vec.values(h.tags)...)
+	return counterFunc(func(n int64) { counter.Add(float64(n)) })
+}
+
+// Gauge implements client.MetricsHandler
+func (h *temporalHandler) Gauge(name string) client.MetricsGauge {
+	vec := h.vecs.get(name, h.tags, func(labels []string) prometheus.Collector {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: "Temporal SDK gauge " + name + "."}, labels)
+	})
+	gauge := vec.collector.(*prometheus.GaugeVec).WithLabelValues(vec.values(h.tags)...)
+	return gaugeFunc(gauge.Set)
+}
+
+// Timer implements client.MetricsHandler
+func (h *temporalHandler) Timer(name string) client.MetricsTimer {
+	vec := h.vecs.get(name, h.tags, func(labels []string) prometheus.Collector {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    name,
+			Help:    "Temporal SDK timer " + name + ", in seconds.",
+			Buckets: temporalBuckets,
+		}, labels)
+	})
+	histogram := vec.collector.(*prometheus.HistogramVec).WithLabelValues(vec.values(h.tags)...)
+	return timerFunc(func(d time.Duration) { histogram.Observe(d.Seconds()) })
+}
+
+// temporalVecs holds the metric vectors created by a handler and those
+// derived from it
+type temporalVecs struct {
+	registerer prometheus.Registerer
+
+	mu   sync.Mutex
+	vecs map[string]*temporalVec
+}
+
+// temporalVec is a registered metric vector and its label names
+type temporalVec struct {
+	collector prometheus.Collector
+	labels    []string
+}
+
+// get returns the vector for name, creating it with the keys of tags as its
+// labels if it doesn't exist
+func (v *temporalVecs) get(name string, tags map[string]string, create func(labels []string) prometheus.Collector) *temporalVec {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if vec, ok := v.vecs[name]; ok {
+		return vec
+	}
+
+	labels := make([]string, 0, len(tags))
+	for k := range tags {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+
+	vec := &temporalVec{collector: create(labels), labels: labels}
+	if err := v.registerer.Register(vec.collector); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if errors.As(err, &registered) {
+			vec.collector = registered.ExistingCollector
+		}
+	}
+	v.vecs[name] = vec
+	return vec
+}
+
+// values returns the label values for tags
+func (v *temporalVec) values(tags map[string]string) []string {
+	values := make([]string, len(v.labels))
+	for i, label := range v.labels {
+		values[i] = tags[label]
+	}
+	return values
+}
+
+type counterFunc func(int64)
+
+func (f counterFunc) Inc(n int64) { f(n) }
+
+type gaugeFunc func(float64)
+
+func (f gaugeFunc) Update(x float64) { f(x) }
+
+type timerFunc func(time.Duration)
+
+func (f timerFunc) Record(d time.Duration) { f(d) }
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// transport records the latency and errors of API calls
+type transport struct {
+	service string
+	base    http.RoundTripper
+}
+
+// InstrumentTransport wraps base, or http.DefaultTransport if base is nil,
+// recording API call metrics under service. Responses with a 4xx or 5xx
+// status count as errors.
+func InstrumentTransport(service string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{service: service, base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	APIRequestDuration.WithLabelValues(t.service, req.Method).Observe(time.Since(start).Seconds())
+
+	switch {
+	case err != nil:
+		APIRequestErrors.WithLabelValues(t.service, "0").Inc()
+	case resp.StatusCode >= http.StatusBadRequest:
+		APIRequestErrors.WithLabelValues(t.service, strconv.Itoa(resp.StatusCode)).Inc()
+	}
+	return resp, err
+}
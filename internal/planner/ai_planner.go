@@ -3,14 +3,23 @@ package planner
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
+	"github.com/clintrovert/khitomer/internal/metrics"
+	"github.com/clintrovert/khitomer/internal/tracing"
 	"github.com/clintrovert/khitomer/pkg/types"
 )
 
+// DefaultModel is the model used when none is configured
+const DefaultModel = openai.GPT4TurboPreview
+
 // AIPlanner uses OpenAI to generate implementation plans
 type AIPlanner struct {
 	client *openai.Client
@@ -23,7 +32,7 @@ func NewAIPlanner(apiKey, model string, logger *zap.Logger) *AIPlanner {
 	client := openai.NewClient(apiKey)
 	
 	if model == "" {
-		model = openai.GPT4TurboPreview
+		model = DefaultModel
 	}
 
 	return &AIPlanner{
@@ -34,23 +43,40 @@ func NewAIPlanner(apiKey, model string, logger *zap.Logger) *AIPlanner {
 }
 
 // Plan generates an implementation plan for a task
-func (p *AIPlanner) Plan(task *types.Task) (*types.ImplementationPlan, error) {
+func (p *AIPlanner) Plan(ctx context.Context, task *types.Task) (plan *types.ImplementationPlan, err error) {
 	prompt := p.buildPrompt(task)
 
+	ctx, span := tracing.Start(ctx, "chat "+p.model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gen_ai.system", "openai"),
+			attribute.String("gen_ai.operation.name", "chat"),
+			attribute.String("gen_ai.request.model", p.model),
+			attribute.String("jira.ticket", task.JiraTicketID),
+		),
+	)
+	start := time.Now()
+	defer func() {
+		metrics.PlanDuration.WithLabelValues(p.model).Observe(time.Since(start).Seconds())
+		metrics.PlansGenerated.WithLabelValues(p.model, metrics.Result(err)).Inc()
+		tracing.End(span, err)
+	}()
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: "You are an expert software engineer that creates detailed implementation plans for code changes based on Jira tickets.",
+		},
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: prompt,
+		},
+	}
 	resp, err := p.client.CreateChatCompletion(
-		context.Background(),
+		ctx,
 		openai.ChatCompletionRequest{
-			Model: p.model,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: "You are an expert software engineer that creates detailed implementation plans for code changes based on Jira tickets.",
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: prompt,
-				},
-			},
+			Model:       p.model,
+			Messages:    messages,
 			Temperature: 0.7,
 		},
 	)
@@ -58,19 +84,41 @@ func (p *AIPlanner) Plan(task *types.Task) (*types.ImplementationPlan, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create chat completion: %w", err)
 	}
+	metrics.PlanTokens.WithLabelValues(p.model, "prompt").Add(float64(resp.Usage.PromptTokens))
+	metrics.PlanTokens.WithLabelValues(p.model, "completion").Add(float64(resp.Usage.CompletionTokens))
+	span.SetAttributes(
+		attribute.String("gen_ai.response.model", resp.Model),
+		attribute.Int("gen_ai.usage.input_tokens", resp.Usage.PromptTokens),
+		attribute.Int("gen_ai.usage.output_tokens", resp.Usage.CompletionTokens),
+	)
 
 	if len(resp.Choices) == 0 {
 		return nil, fmt.Errorf("no response from AI")
 	}
 
-	plan, err := p.parseResponse(resp.Choices[0].Message.Content, task)
+	plan, err = p.parseResponse(resp.Choices[0].Message.Content, task)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse AI response: %w", err)
 	}
+	plan.Usage = &types.LLMUsage{
+		Phase:            types.UsagePhasePlan,
+		Model:            p.model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}
+	plan.Exchange = &types.LLMExchange{
+		Usage:    *plan.Usage,
+		Response: resp.Choices[0].Message.Content,
+	}
+	for _, m := range messages {
+		plan.Exchange.Messages = append(plan.Exchange.Messages, types.LLMMessage{Role: m.Role, Content: m.Content})
+	}
 
 	p.logger.Info("generated implementation plan",
 		zap.String("jira_ticket", task.JiraTicketID),
 		zap.Int("steps", len(plan.Steps)),
+		zap.Int("prompt_tokens", resp.Usage.PromptTokens),
+		zap.Int("completion_tokens", resp.Usage.CompletionTokens),
 	)
 
 	return plan, nil
@@ -84,6 +132,10 @@ func (p *AIPlanner) buildPrompt(task *types.Task) string {
 	sb.WriteString("**Title:** " + task.Title + "\n")
 	sb.WriteString("**Description:** " + task.Description + "\n")
 	sb.WriteString("**Repository:** " + task.RepositoryOwner + "/" + task.RepositoryName + "\n\n")
+	if task.PlanFeedback != "" {
+		sb.WriteString("An earlier plan for this ticket was rejected. Address this feedback:\n")
+		sb.WriteString(task.PlanFeedback + "\n\n")
+	}
 	
 	sb.WriteString("Please provide:\n")
 	sb.WriteString("1. A summary of the implementation approach\n")
@@ -94,7 +146,7 @@ func (p *AIPlanner) buildPrompt(task *types.Task) string {
 	sb.WriteString("Format your response as:\n")
 	sb.WriteString("SUMMARY: <summary>\n")
 	sb.WriteString("STEPS:\n")
-	sb.WriteString("1. <step description> [TYPE: codegen|testing|deployment|review]\n")
+	sb.WriteString("1. <step description> [TYPE: codegen|testing|deployment|review] [AFTER: <comma-separated step numbers it depends on, if any>]\n")
 	sb.WriteString("2. ...\n")
 	sb.WriteString("FILES_MODIFY: <comma-separated list>\n")
 	sb.WriteString("FILES_CREATE: <comma-separated list>\n")
@@ -174,8 +226,22 @@ func (p *AIPlanner) parseStep(line string, order int) *types.PlanStep {
 		line = strings.TrimSpace(line[idx+1:])
 	}
 
+	// Extract dependencies if present
+	var dependsOn []int
+	if idx := strings.Index(line, "[AFTER:"); idx != -1 {
+		afterPart := line[idx:]
+		if endIdx := strings.Index(afterPart, "]"); endIdx != -1 {
+			for _, dep := range strings.Split(afterPart[len("[AFTER:"):endIdx], ",") {
+				if n, err := strconv.Atoi(strings.TrimSpace(dep)); err == nil {
+					dependsOn = append(dependsOn, n)
+				}
+			}
+			line = strings.TrimSpace(line[:idx] + afterPart[endIdx+1:])
+		}
+	}
+
 	// Extract type if present
-	activityType := "codegen" // default
+	activityType := types.StepTypeCodegen // default
 	if idx := strings.Index(line, "[TYPE:"); idx != -1 {
 		typePart := line[idx:]
 		if endIdx := strings.Index(typePart, "]"); endIdx != -1 {
@@ -194,6 +260,7 @@ func (p *AIPlanner) parseStep(line string, order int) *types.PlanStep {
 		Description:  line,
 		ActivityType: activityType,
 		Parameters:   make(map[string]string),
+		DependsOn:    dependsOn,
 	}
 }
 
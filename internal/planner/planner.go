@@ -1,11 +1,14 @@
 package planner
 
 import (
+	"context"
+
 	"github.com/clintrovert/khitomer/pkg/types"
 )
 
-// Planner interface for generating implementation plans
+// Planner interface for generating implementation plans. Plan should return
+// promptly once ctx is done.
 type Planner interface {
-	Plan(task *types.Task) (*types.ImplementationPlan, error)
+	Plan(ctx context.Context, task *types.Task) (*types.ImplementationPlan, error)
 }
 
@@ -0,0 +1,107 @@
+package scan
+
+import (
+	"regexp"
+)
+
+// Rule is a regular expression that identifies a known secret format
+type Rule struct {
+	ID          string
+	Description string
+	Pattern     *regexp.Regexp
+}
+
+// DefaultRules returns the built-in secret detection rules
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			ID:          "private-key",
+			Description: "private key block",
+			Pattern:     regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`),
+		},
+		{
+			ID:          "aws-access-key-id",
+			Description: "AWS access key ID",
+			Pattern:     regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`),
+		},
+		{
+			ID:          "aws-secret-access-key",
+			Description: "AWS secret access key",
+			Pattern:     regexp.MustCompile(`(?i)aws_?secret_?access_?key\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`),
+		},
+		{
+			ID:          "github-token",
+			Description: "GitHub token",
+			Pattern:     regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`),
+		},
+		{
+			ID:          "slack-token",
+			Description: "Slack token",
+			Pattern:     regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`),
+		},
+		{
+			ID:          "openai-api-key",
+			Description: "OpenAI API key",
+			Pattern:     regexp.MustCompile(`\bsk-(proj-)?[A-Za-z0-9_-]{32,}\b`),
+		},
+		{
+			ID:          "google-api-key",
+			Description: "Google API key",
+			Pattern:     regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+		},
+		{
+			ID:          "stripe-secret-key",
+			Description: "Stripe secret key",
+			Pattern:     regexp.MustCompile(`\b(sk|rk)_live_[0-9A-Za-z]{24,}\b`),
+		},
+		{
+			ID:          "jwt",
+			Description: "JSON web token",
+			Pattern:     regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`),
+		},
+		{
+			ID:          "generic-credential",
+			Description: "hard-coded credential assignment",
+			Pattern:     regexp.MustCompile(`(?i)\b(api[_-]?key|secret|token|passw(or)?d|client[_-]?secret)\b["']?\s*[:=]\s*["'][^"'\s]{8,}["']`),
+		},
+	}
+}
+
+// DefaultDenyPaths returns path patterns that are never allowed in a commit
+func DefaultDenyPaths() []string {
+	return []string{
+		".env",
+		".env.local",
+		".env.*.local",
+		"*.pem",
+		"*.key",
+		"*.p12",
+		"*.pfx",
+		"*.jks",
+		"*.keystore",
+		"id_rsa*",
+		"id_dsa*",
+		"id_ecdsa*",
+		"id_ed25519*",
+		".npmrc",
+		".pypirc",
+		".netrc",
+		"credentials.json",
+		"*.tfstate",
+		"coverage.out",
+		"*.coverprofile",
+		"lcov.info",
+		".coverage",
+	}
+}
+
+// entropyExemptPaths are files whose contents are expected to be high entropy
+var entropyExemptPaths = []string{
+	"go.sum",
+	"package-lock.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"poetry.lock",
+	"Cargo.lock",
+	"*.svg",
+}
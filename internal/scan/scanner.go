@@ -0,0 +1,233 @@
+package scan
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultMaxBinarySize is the largest binary file allowed in a commit
+	DefaultMaxBinarySize = 1 << 20
+
+	// DefaultEntropyThreshold is the Shannon entropy (bits per character)
+	// above which a base64-like token is reported. Hex strings such as commit
+	// SHAs top out at 4 bits per character and are never reported.
+	DefaultEntropyThreshold = 4.3
+)
+
+// tokenPattern matches base64-like runs long enough to be credentials
+var tokenPattern = regexp.MustCompile(`[A-Za-z0-9+/=_-]{24,}`)
+
+// Config configures a Scanner
+type Config struct {
+	// DenyPaths are glob patterns matched against the file path and its base
+	// name, in addition to DefaultDenyPaths. A pattern ending in "/**"
+	// matches everything below a directory.
+	DenyPaths []string
+
+	// ReplaceDefaultDenyPaths denies only DenyPaths, dropping the defaults
+	ReplaceDefaultDenyPaths bool
+
+	// MaxBinarySize is the largest binary file, in bytes, that may be committed
+	MaxBinarySize int64
+
+	// EntropyThreshold is the minimum entropy for a token to be reported;
+	// zero disables entropy checks
+	EntropyThreshold float64
+}
+
+// Finding describes sensitive content found in a staged file
+type Finding struct {
+	Path        string
+	Line        int
+	RuleID      string
+	Description string
+	Match       string
+}
+
+// String formats the finding for a report
+func (f Finding) String() string {
+	location := f.Path
+	if f.Line > 0 {
+		location = fmt.Sprintf("%s:%d", f.Path, f.Line)
+	}
+	if f.Match != "" {
+		return fmt.Sprintf("%s: %s (%s) %q", location, f.RuleID, f.Description, f.Match)
+	}
+	return fmt.Sprintf("%s: %s (%s)", location, f.RuleID, f.Description)
+}
+
+// FindingsError is returned when a scan finds sensitive content
+type FindingsError struct {
+	Findings []Finding
+}
+
+// Error returns a report listing every finding
+func (e *FindingsError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("sensitive content detected in staged changes (%d findings):", len(e.Findings)))
+	for _, f := range e.Findings {
+		sb.WriteString("\n  " + f.String())
+	}
+	return sb.String()
+}
+
+// Scanner checks staged file content for secrets and disallowed files
+type Scanner struct {
+	config Config
+	rules  []Rule
+}
+
+// NewScanner creates a new scanner using the built-in rules
+func NewScanner(config Config) *Scanner {
+	if !config.ReplaceDefaultDenyPaths {
+		config.DenyPaths = append(DefaultDenyPaths(), config.DenyPaths...)
+	}
+	if config.MaxBinarySize <= 0 {
+		config.MaxBinarySize = DefaultMaxBinarySize
+	}
+
+	return &Scanner{
+		config: config,
+		rules:  DefaultRules(),
+	}
+}
+
+// ScanFile scans a staged file. Only the lines in addedLines are checked for
+// secrets; a nil addedLines scans the whole file.
+func (s *Scanner) ScanFile(filePath string, content []byte, addedLines map[int]bool) []Finding {
+	filePath = path.Clean(strings.TrimPrefix(filePath, "./"))
+
+	if pattern, ok := matchAny(s.config.DenyPaths, filePath); ok {
+		return []Finding{{
+			Path:        filePath,
+			RuleID:      "denied-path",
+			Description: fmt.Sprintf("path matches deny-list pattern %q", pattern),
+		}}
+	}
+
+	if isBinary(content) {
+		if int64(len(content)) > s.config.MaxBinarySize {
+			return []Finding{{
+				Path:        filePath,
+				RuleID:      "binary-size",
+				Description: fmt.Sprintf("binary file of %d bytes exceeds limit of %d bytes", len(content), s.config.MaxBinarySize),
+			}}
+		}
+		return nil
+	}
+
+	_, entropyExempt := matchAny(entropyExemptPaths, filePath)
+
+	var findings []Finding
+	for i, line := range strings.Split(string(content), "\n") {
+		lineNo := i + 1
+		if addedLines != nil && !addedLines[lineNo] {
+			continue
+		}
+
+		matched := false
+		for _, rule := range s.rules {
+			if m := rule.Pattern.FindString(line); m != "" {
+				findings = append(findings, Finding{
+					Path:        filePath,
+					Line:        lineNo,
+					RuleID:      rule.ID,
+					Description: rule.Description,
+					Match:       redact(m),
+				})
+				matched = true
+			}
+		}
+
+		if matched || entropyExempt || s.config.EntropyThreshold <= 0 {
+			continue
+		}
+
+		for _, token := range tokenPattern.FindAllString(line, -1) {
+			entropy := shannonEntropy(token)
+			if entropy < s.config.EntropyThreshold {
+				continue
+			}
+			findings = append(findings, Finding{
+				Path:        filePath,
+				Line:        lineNo,
+				RuleID:      "high-entropy",
+				Description: fmt.Sprintf("high entropy string (%.2f bits/char)", entropy),
+				Match:       redact(token),
+			})
+		}
+	}
+
+	return findings
+}
+
+// SortFindings orders findings by path and line
+func SortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Path != findings[j].Path {
+			return findings[i].Path < findings[j].Path
+		}
+		return findings[i].Line < findings[j].Line
+	})
+}
+
+// matchAny returns the first pattern matching the path or its base name
+func matchAny(patterns []string, filePath string) (string, bool) {
+	base := path.Base(filePath)
+	for _, pattern := range patterns {
+		if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+			if filePath == dir || strings.HasPrefix(filePath, dir+"/") {
+				return pattern, true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, filePath); ok {
+			return pattern, true
+		}
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(pattern, base); ok {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
+
+// isBinary uses the same heuristic as git: a NUL byte in the first 8000 bytes
+func isBinary(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) != -1
+}
+
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var entropy float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// redact keeps enough of a match to identify it without leaking the secret
+func redact(s string) string {
+	if len(s) <= 8 {
+		return strings.Repeat("*", len(s))
+	}
+	return s[:4] + strings.Repeat("*", 8)
+}
@@ -0,0 +1,84 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/artifacts"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// ListArtifacts returns the artifacts archived for a workflow run. An empty
+// runID selects the workflow's latest run. It returns the run the artifacts
+// belong to.
+func (c *Client) ListArtifacts(ctx context.Context, workflowID, runID string) (artifacts.Run, []artifacts.Artifact, error) {
+	run, err := c.artifactRun(ctx, workflowID, runID)
+	if err != nil {
+		return run, nil, err
+	}
+	list, err := c.archive.List(ctx, run)
+	return run, list, err
+}
+
+// GetArtifact returns an artifact of a workflow run. An empty runID selects
+// the workflow's latest run.
+func (c *Client) GetArtifact(ctx context.Context, workflowID, runID, name string) ([]byte, error) {
+	run, err := c.artifactRun(ctx, workflowID, runID)
+	if err != nil {
+		return nil, err
+	}
+	return c.archive.Get(ctx, run, name)
+}
+
+// artifactRun resolves the run whose artifacts are read
+func (c *Client) artifactRun(ctx context.Context, workflowID, runID string) (artifacts.Run, error) {
+	run := artifacts.Run{WorkflowID: workflowID, RunID: runID}
+	if c.archive == nil {
+		return run, artifacts.ErrNotConfigured
+	}
+	if runID != "" {
+		return run, nil
+	}
+
+	resp, err := c.temporalClient.DescribeWorkflowExecution(ctx, workflowID, "")
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			return run, fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
+		}
+		return run, fmt.Errorf("failed to describe workflow: %w", err)
+	}
+	run.RunID = resp.GetWorkflowExecutionInfo().GetExecution().GetRunId()
+	return run, nil
+}
+
+// archivePlan keeps the plan a run was started with. Archiving is best
+// effort, so a failure doesn't fail the start.
+func (c *Client) archivePlan(ctx context.Context, we client.WorkflowRun, plan *types.ImplementationPlan) {
+	if c.archive == nil {
+		return
+	}
+	run := artifacts.Run{WorkflowID: we.GetID(), RunID: we.GetRunID()}
+	if err := c.archive.PutPlan(ctx, run, plan); err != nil {
+		c.logger.Warn("failed to archive plan",
+			zap.String("workflow_id", run.WorkflowID),
+			zap.Error(err),
+		)
+	}
+}
+
+// artifactsURL returns the REST URL listing a workflow's artifacts, or "" if
+// they aren't archived or the API's URL isn't known
+func (c *Client) artifactsURL(workflowID string) string {
+	if c.archive == nil || c.defaults.APIURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(c.defaults.APIURL, "/") + "/api/v1/workflows/" + url.PathEscape(workflowID) + "/artifacts"
+}
@@ -2,27 +2,56 @@ package temporal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/google/uuid"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
 	"go.uber.org/zap"
 
+	"github.com/clintrovert/khitomer/internal/artifacts"
+	"github.com/clintrovert/khitomer/internal/metrics"
 	"github.com/clintrovert/khitomer/internal/temporal/workflows"
+	"github.com/clintrovert/khitomer/internal/tracing"
 	"github.com/clintrovert/khitomer/pkg/types"
 )
 
+// WorkflowDefaults are settings passed to every workflow the client starts
+type WorkflowDefaults struct {
+	// CommitPerStep commits after each codegen plan step
+	CommitPerStep bool
+	// Cleanup controls how failed or cancelled runs are cleaned up
+	Cleanup workflows.CleanupPolicy
+	// APIURL is the REST API's external base URL, which pull requests link
+	// to for the run's artifacts
+	APIURL string
+}
+
 // Client wraps Temporal client functionality
 type Client struct {
 	temporalClient client.Client
 	logger         *zap.Logger
 	taskQueue      string
+	defaults       WorkflowDefaults
+	archive        *artifacts.Archive
 }
 
-// NewClient creates a new Temporal client
-func NewClient(address, namespace, taskQueue string, logger *zap.Logger) (*Client, error) {
+// NewClient creates a new Temporal client. Workflows it starts continue the
+// trace in the caller's context. The plans of the runs it starts are kept in
+// archive, if it is not nil.
+func NewClient(address, namespace, taskQueue string, defaults WorkflowDefaults, archive *artifacts.Archive, logger *zap.Logger) (*Client, error) {
+	tracingInterceptor, err := tracing.NewTemporalInterceptor()
+	if err != nil {
+		return nil, err
+	}
+
 	c, err := client.Dial(client.Options{
-		HostPort:  address,
-		Namespace: namespace,
+		HostPort:       address,
+		Namespace:      namespace,
+		MetricsHandler: metrics.NewTemporalHandler(),
+		Interceptors:   []interceptor.ClientInterceptor{tracingInterceptor},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temporal client: %w", err)
@@ -32,28 +61,45 @@ func NewClient(address, namespace, taskQueue string, logger *zap.Logger) (*Clien
 		temporalClient: c,
 		logger:         logger,
 		taskQueue:      taskQueue,
+		defaults:       defaults,
+		archive:        archive,
 	}, nil
 }
 
-// StartWorkflow starts a new implementation workflow
-func (c *Client) StartWorkflow(ctx context.Context, task *types.Task, plan *types.ImplementationPlan, repo *types.RepositoryInfo) (string, error) {
-	workflowID := fmt.Sprintf("implementation-%s-%s", task.JiraTicketID, task.RepositoryName)
-	
+// implementationWorkflowID is the ID of the implementation workflow for a task
+func implementationWorkflowID(task *types.Task) string {
+	return fmt.Sprintf("implementation-%s-%s", task.JiraTicketID, task.RepositoryName)
+}
+
+// StartWorkflow starts a new implementation workflow. The plan's LLM usage
+// is recorded in the workflow's usage memo, which the daily budget is
+// checked against.
+func (c *Client) StartWorkflow(ctx context.Context, task *types.Task, plan *types.ImplementationPlan, repo *types.RepositoryInfo, usage workflows.UsagePolicy) (string, error) {
+	workflowID := implementationWorkflowID(task)
+
 	workflowOptions := client.StartWorkflowOptions{
-		ID:        workflowID,
-		TaskQueue: c.taskQueue,
+		ID:                    workflowID,
+		TaskQueue:             c.taskQueue,
+		TypedSearchAttributes: workflows.InitialSearchAttributes(task, plan, repo),
+		Memo:                  workflows.InitialMemo(plan),
 	}
 
 	workflowInput := workflows.WorkflowInput{
-		Task:       task,
-		Plan:       plan,
-		Repository: repo,
+		Task:          task,
+		Plan:          plan,
+		Repository:    repo,
+		CommitPerStep: c.defaults.CommitPerStep,
+		Cleanup:       c.defaults.Cleanup,
+		Usage:         usage,
+		ArtifactsURL:  c.artifactsURL(workflowID),
 	}
 
 	we, err := c.temporalClient.ExecuteWorkflow(ctx, workflowOptions, workflows.ImplementationWorkflow, workflowInput)
 	if err != nil {
 		return "", fmt.Errorf("failed to start workflow: %w", err)
 	}
+	metrics.WorkflowsStarted.WithLabelValues("ImplementationWorkflow").Inc()
+	c.archivePlan(ctx, we, plan)
 
 	c.logger.Info("started workflow",
 		zap.String("workflow_id", we.GetID()),
@@ -64,6 +110,69 @@ func (c *Client) StartWorkflow(ctx context.Context, task *types.Task, plan *type
 	return we.GetID(), nil
 }
 
+// HasWorkflow reports whether an implementation workflow has been started for
+// a task, whether it is still running or has closed
+func (c *Client) HasWorkflow(ctx context.Context, task *types.Task) (bool, error) {
+	_, err := c.temporalClient.DescribeWorkflowExecution(ctx, implementationWorkflowID(task), "")
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to describe workflow: %w", err)
+	}
+	return true, nil
+}
+
+// PreviewPlan runs a plan's codegen steps in a throwaway workspace on a
+// worker and waits for the resulting diff. The preview is cancelled if ctx is
+// done first.
+func (c *Client) PreviewPlan(ctx context.Context, task *types.Task, plan *types.ImplementationPlan, repo *types.RepositoryInfo, usage workflows.UsagePolicy) (*workflows.PreviewResult, error) {
+	workflowID := "preview-" + uuid.NewString()
+	if task.JiraTicketID != "" {
+		workflowID = fmt.Sprintf("preview-%s-%s", task.JiraTicketID, uuid.NewString())
+	}
+
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        workflowID,
+		TaskQueue: c.taskQueue,
+		Memo:      workflows.InitialMemo(plan),
+	}
+
+	workflowInput := workflows.PreviewInput{
+		Task:       task,
+		Plan:       plan,
+		Repository: repo,
+		Usage:      usage,
+	}
+
+	we, err := c.temporalClient.ExecuteWorkflow(ctx, workflowOptions, workflows.PreviewWorkflow, workflowInput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start preview workflow: %w", err)
+	}
+	metrics.WorkflowsStarted.WithLabelValues("PreviewWorkflow").Inc()
+	c.archivePlan(ctx, we, plan)
+
+	c.logger.Info("started preview workflow",
+		zap.String("workflow_id", we.GetID()),
+		zap.String("jira_ticket", task.JiraTicketID),
+	)
+
+	var result workflows.PreviewResult
+	if err := we.Get(ctx, &result); err != nil {
+		if ctx.Err() != nil {
+			if cancelErr := c.temporalClient.CancelWorkflow(context.Background(), we.GetID(), we.GetRunID()); cancelErr != nil {
+				c.logger.Warn("failed to cancel preview workflow",
+					zap.String("workflow_id", we.GetID()),
+					zap.Error(cancelErr),
+				)
+			}
+		}
+		return nil, fmt.Errorf("preview failed: %w", err)
+	}
+	return &result, nil
+}
+
 // GetWorkflowStatus retrieves the status of a workflow
 func (c *Client) GetWorkflowStatus(ctx context.Context, workflowID string) (client.WorkflowRun, error) {
 	workflow := c.temporalClient.GetWorkflow(ctx, workflowID, "")
@@ -72,12 +181,42 @@ func (c *Client) GetWorkflowStatus(ctx context.Context, workflowID string) (clie
 
 // CancelWorkflow cancels a running workflow
 func (c *Client) CancelWorkflow(ctx context.Context, workflowID string) error {
-	workflowRun := c.temporalClient.GetWorkflow(ctx, workflowID, "")
-	return workflowRun.Cancel(ctx)
+	return c.temporalClient.CancelWorkflow(ctx, workflowID, "")
+}
+
+// WaitWorkflow waits for the latest run of a workflow to close, however it
+// closes. It returns ctx's error if ctx is done first.
+func (c *Client) WaitWorkflow(ctx context.Context, workflowID string) error {
+	err := c.temporalClient.GetWorkflow(ctx, workflowID, "").Get(ctx, nil)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
+	}
+	return nil
+}
+
+// SubmitReview delivers a review decision to the review step of a workflow
+func (c *Client) SubmitReview(ctx context.Context, workflowID string, stepOrder int, decision workflows.ReviewDecision) error {
+	reviewID := workflows.ReviewWorkflowID(workflowID, stepOrder)
+	err := c.temporalClient.SignalWorkflow(ctx, reviewID, "", workflows.ReviewSignalName, decision)
+	if err != nil {
+		return fmt.Errorf("failed to submit review: %w", err)
+	}
+
+	c.logger.Info("submitted review",
+		zap.String("workflow_id", workflowID),
+		zap.Int("step", stepOrder),
+		zap.Bool("approved", decision.Approved),
+		zap.String("reviewer", decision.Reviewer),
+	)
+
+	return nil
 }
 
 // Close closes the Temporal client
 func (c *Client) Close() {
 	c.temporalClient.Close()
 }
-
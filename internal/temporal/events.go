@@ -0,0 +1,98 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+
+	"github.com/clintrovert/khitomer/internal/temporal/workflows"
+)
+
+// ErrWorkflowNotFound is returned when watching or reading the usage of a
+// workflow that doesn't exist
+var ErrWorkflowNotFound = errors.New("workflow not found")
+
+// watchInterval is how often WatchWorkflow polls a running workflow for new
+// events
+const watchInterval = time.Second
+
+// WorkflowEvents returns the progress events of a workflow with IDs greater
+// than afterID. A workflow that hasn't run its first task yet has none.
+func (c *Client) WorkflowEvents(ctx context.Context, workflowID string, afterID int64) ([]workflows.ProgressEvent, error) {
+	value, err := c.temporalClient.QueryWorkflow(ctx, workflowID, "", workflows.ProgressQueryName, afterID)
+	if err != nil {
+		var notReady *serviceerror.WorkflowNotReady
+		if errors.As(err, &notReady) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query workflow progress: %w", err)
+	}
+
+	var events []workflows.ProgressEvent
+	if err := value.Get(&events); err != nil {
+		return nil, fmt.Errorf("failed to decode workflow progress: %w", err)
+	}
+	return events, nil
+}
+
+// WatchWorkflow calls fn with each progress event of a workflow after
+// afterID, in order, as the workflow runs, and idle, unless it is nil, on
+// each poll of the running workflow that found no new events. It returns
+// once the workflow has closed and its last events have been delivered, when
+// ctx is done, or when fn or idle returns an error.
+func (c *Client) WatchWorkflow(ctx context.Context, workflowID string, afterID int64, fn func(workflows.ProgressEvent) error, idle func() error) error {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		// Check for closure before querying so no events are missed between
+		// the last query and the workflow closing
+		closed, err := c.workflowClosed(ctx, workflowID)
+		if err != nil {
+			return err
+		}
+
+		events, err := c.WorkflowEvents(ctx, workflowID, afterID)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+			afterID = event.ID
+		}
+
+		if closed {
+			return nil
+		}
+		if len(events) == 0 && idle != nil {
+			if err := idle(); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// workflowClosed reports whether the latest run of a workflow has closed
+func (c *Client) workflowClosed(ctx context.Context, workflowID string) (bool, error) {
+	resp, err := c.temporalClient.DescribeWorkflowExecution(ctx, workflowID, "")
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			return false, fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
+		}
+		return false, fmt.Errorf("failed to describe workflow: %w", err)
+	}
+	return resp.GetWorkflowExecutionInfo().GetStatus() != enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING, nil
+}
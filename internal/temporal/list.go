@@ -0,0 +1,153 @@
+package temporal
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/converter"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/temporal/workflows"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// ErrInvalidPageToken is returned for page tokens not issued by ListWorkflows
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// DefaultListPageSize is the page size used when a list request doesn't set one
+const DefaultListPageSize = 50
+
+// MaxListPageSize caps the page size of list requests
+const MaxListPageSize = 1000
+
+// ListFilter narrows a workflow listing. Empty fields match everything.
+type ListFilter struct {
+	JiraTicket string
+	// Repository is "owner/name"
+	Repository string
+	Assignee   string
+	Complexity string
+	// Outcome is one of the workflows.Outcome* values
+	Outcome string
+	// Status is a Temporal execution status, e.g. Running, Completed or Failed
+	Status string
+}
+
+// WorkflowSummary describes one implementation workflow run
+type WorkflowSummary struct {
+	WorkflowID string
+	RunID      string
+	Status     string
+	JiraTicket string
+	Repository string
+	Assignee   string
+	Complexity string
+	PRNumber   int64
+	Outcome    string
+	StartTime  time.Time
+	CloseTime  time.Time
+	// Usage is the run's LLM usage so far
+	Usage types.UsageTotals
+}
+
+// ListWorkflows lists implementation workflows matching filter, most recently
+// started first. pageToken is the opaque token returned with the previous
+// page, or empty for the first page; the returned token is empty after the
+// last page.
+func (c *Client) ListWorkflows(ctx context.Context, filter ListFilter, pageSize int, pageToken string) ([]WorkflowSummary, string, error) {
+	token, err := base64.RawURLEncoding.DecodeString(pageToken)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidPageToken, err)
+	}
+
+	if pageSize <= 0 {
+		pageSize = DefaultListPageSize
+	}
+	if pageSize > MaxListPageSize {
+		pageSize = MaxListPageSize
+	}
+
+	resp, err := c.temporalClient.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+		PageSize:      int32(pageSize),
+		NextPageToken: token,
+		Query:         listQuery(filter),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list workflows: %w", err)
+	}
+
+	summaries := make([]WorkflowSummary, 0, len(resp.Executions))
+	for _, exec := range resp.Executions {
+		summary := WorkflowSummary{
+			WorkflowID: exec.GetExecution().GetWorkflowId(),
+			RunID:      exec.GetExecution().GetRunId(),
+			Status:     exec.GetStatus().String(),
+		}
+		if exec.GetStartTime() != nil {
+			summary.StartTime = exec.GetStartTime().AsTime()
+		}
+		if exec.GetCloseTime() != nil {
+			summary.CloseTime = exec.GetCloseTime().AsTime()
+		}
+
+		fields := exec.GetSearchAttributes().GetIndexedFields()
+		c.decodeSearchAttribute(fields, workflows.JiraTicketKey.GetName(), &summary.JiraTicket)
+		c.decodeSearchAttribute(fields, workflows.RepositoryKey.GetName(), &summary.Repository)
+		c.decodeSearchAttribute(fields, workflows.AssigneeKey.GetName(), &summary.Assignee)
+		c.decodeSearchAttribute(fields, workflows.ComplexityKey.GetName(), &summary.Complexity)
+		c.decodeSearchAttribute(fields, workflows.PRNumberKey.GetName(), &summary.PRNumber)
+		c.decodeSearchAttribute(fields, workflows.OutcomeKey.GetName(), &summary.Outcome)
+		summary.Usage = c.decodeUsage(exec.GetMemo())
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, base64.RawURLEncoding.EncodeToString(resp.NextPageToken), nil
+}
+
+// decodeSearchAttribute decodes a search attribute into value if present
+func (c *Client) decodeSearchAttribute(fields map[string]*commonpb.Payload, name string, value interface{}) {
+	payload, ok := fields[name]
+	if !ok {
+		return
+	}
+	if err := converter.GetDefaultDataConverter().FromPayload(payload, value); err != nil {
+		c.logger.Warn("failed to decode search attribute",
+			zap.String("name", name),
+			zap.Error(err),
+		)
+	}
+}
+
+// listQuery builds a visibility query for filter
+func listQuery(filter ListFilter) string {
+	clauses := []string{"WorkflowType = 'ImplementationWorkflow'"}
+	add := func(name, value string) {
+		if value != "" {
+			clauses = append(clauses, fmt.Sprintf("%s = %s", name, quote(value)))
+		}
+	}
+	add(workflows.JiraTicketKey.GetName(), filter.JiraTicket)
+	add(workflows.RepositoryKey.GetName(), filter.Repository)
+	add(workflows.AssigneeKey.GetName(), filter.Assignee)
+	add(workflows.ComplexityKey.GetName(), filter.Complexity)
+	add(workflows.OutcomeKey.GetName(), filter.Outcome)
+	add("ExecutionStatus", filter.Status)
+
+	// ORDER BY isn't supported by SQL visibility stores, which already return
+	// the most recently started runs first
+	return strings.Join(clauses, " AND ")
+}
+
+// quote returns value as a single-quoted visibility query literal
+func quote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
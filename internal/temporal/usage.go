@@ -0,0 +1,106 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/serviceerror"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/converter"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/temporal/workflows"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// GetUsage returns the LLM usage recorded by a workflow run so far. Runs
+// started before usage accounting have none.
+func (c *Client) GetUsage(ctx context.Context, workflowID string) (*types.UsageTotals, error) {
+	resp, err := c.temporalClient.DescribeWorkflowExecution(ctx, workflowID, "")
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			return nil, fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
+		}
+		return nil, fmt.Errorf("failed to describe workflow: %w", err)
+	}
+	usage := c.decodeUsage(resp.GetWorkflowExecutionInfo().GetMemo())
+	return &usage, nil
+}
+
+// SpentSince sums the LLM cost of the calls implementation and preview
+// workflows made at or after since. Runs that started earlier but were still
+// open at since count the calls they made from then on. Calls recorded
+// before calls were timestamped count towards the day their run started.
+func (c *Client) SpentSince(ctx context.Context, since time.Time) (float64, error) {
+	at := quote(since.UTC().Format(time.RFC3339))
+	query := fmt.Sprintf("(WorkflowType = 'ImplementationWorkflow' OR WorkflowType = 'PreviewWorkflow') AND "+
+		"(StartTime >= %s OR CloseTime >= %s OR ExecutionStatus = 'Running')", at, at)
+
+	var spent float64
+	err := c.eachExecution(ctx, query, func(exec *workflowpb.WorkflowExecutionInfo) {
+		usage := c.decodeUsage(exec.GetMemo())
+		if !exec.GetStartTime().AsTime().Before(since) {
+			spent += usage.Cost
+			return
+		}
+		for _, call := range usage.Calls {
+			if !call.Time.IsZero() && !call.Time.Before(since) {
+				spent += call.Cost
+			}
+		}
+	})
+	return spent, err
+}
+
+// TicketSpend sums the LLM cost of every implementation workflow run of a
+// ticket
+func (c *Client) TicketSpend(ctx context.Context, ticketID string) (float64, error) {
+	var spent float64
+	err := c.eachExecution(ctx, listQuery(ListFilter{JiraTicket: ticketID}), func(exec *workflowpb.WorkflowExecutionInfo) {
+		usage := c.decodeUsage(exec.GetMemo())
+		spent += usage.Cost
+	})
+	return spent, err
+}
+
+// eachExecution calls fn with every workflow execution matching a visibility
+// query
+func (c *Client) eachExecution(ctx context.Context, query string, fn func(*workflowpb.WorkflowExecutionInfo)) error {
+	var token []byte
+	for {
+		resp, err := c.temporalClient.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			PageSize:      MaxListPageSize,
+			NextPageToken: token,
+			Query:         query,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list workflows: %w", err)
+		}
+		for _, exec := range resp.Executions {
+			fn(exec)
+		}
+		if len(resp.NextPageToken) == 0 {
+			return nil
+		}
+		token = resp.NextPageToken
+	}
+}
+
+// decodeUsage decodes the usage memo, returning empty totals if it is
+// missing or invalid
+func (c *Client) decodeUsage(memo *commonpb.Memo) types.UsageTotals {
+	var usage types.UsageTotals
+	payload, ok := memo.GetFields()[workflows.UsageMemoKey]
+	if !ok {
+		return usage
+	}
+	if err := converter.GetDefaultDataConverter().FromPayload(payload, &usage); err != nil {
+		c.logger.Warn("failed to decode usage memo", zap.Error(err))
+	}
+	return usage
+}
@@ -0,0 +1,68 @@
+package workflows
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/clintrovert/khitomer/internal/activities"
+)
+
+// nonRetryableErrorTypes are activity error types that retrying cannot fix
+var nonRetryableErrorTypes = []string{
+	activities.ErrTypeNotConfigured,
+	activities.ErrTypeAuthFailed,
+	activities.ErrTypeNotFound,
+	activities.ErrTypePullRequestExists,
+	activities.ErrTypeSensitiveContent,
+}
+
+// Activity options, sized to each activity's expected duration. Activities
+// that heartbeat set a HeartbeatTimeout so a stalled worker is detected long
+// before StartToCloseTimeout expires.
+var (
+	// cloneOptions allows for large repositories; git progress and a ticker
+	// through checkout heartbeat
+	cloneOptions = activityOptions(30*time.Minute, time.Minute, 3)
+
+	// codegenOptions runs one codegen plan step, heartbeating as it starts
+	codegenOptions = activityOptions(30*time.Minute, 5*time.Minute, 2)
+
+	// testingOptions allows for long test suites; a failed run is reported
+	// rather than retried, so only infrastructure failures retry
+	testingOptions = activityOptions(time.Hour, time.Minute, 2)
+
+	// deployOptions allows for slow environment provisioning; deploy scripts
+	// heartbeat their elapsed time
+	deployOptions = activityOptions(30*time.Minute, time.Minute, 2)
+
+	// localGitOptions covers branch, commit and diff operations on the clone
+	localGitOptions = activityOptions(2*time.Minute, 0, 3)
+
+	// pushOptions covers pushing the feature branch
+	pushOptions = activityOptions(10*time.Minute, 0, 5)
+
+	// githubAPIOptions covers GitHub REST calls
+	githubAPIOptions = activityOptions(2*time.Minute, 0, 5)
+
+	// jiraOptions covers Jira REST calls, which are cheap but often flaky
+	jiraOptions = activityOptions(time.Minute, 0, 5)
+)
+
+// activityOptions builds options with exponential backoff and the shared
+// non-retryable error types. A zero heartbeat timeout disables heartbeat
+// checking.
+func activityOptions(startToClose, heartbeat time.Duration, attempts int32) workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		StartToCloseTimeout: startToClose,
+		HeartbeatTimeout:    heartbeat,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:        time.Second,
+			BackoffCoefficient:     2.0,
+			MaximumInterval:        time.Minute,
+			MaximumAttempts:        attempts,
+			NonRetryableErrorTypes: nonRetryableErrorTypes,
+		},
+	}
+}
@@ -0,0 +1,208 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/activities"
+)
+
+// Branch policies for a failed run's pushed feature branch
+const (
+	// BranchPolicyDelete deletes the pushed branch
+	BranchPolicyDelete = "delete"
+	// BranchPolicyKeep leaves the pushed branch for inspection
+	BranchPolicyKeep = "keep"
+)
+
+// CleanupPolicy controls how a failed or cancelled run is cleaned up
+type CleanupPolicy struct {
+	// BranchPolicy is BranchPolicyDelete or BranchPolicyKeep. Empty deletes.
+	BranchPolicy string
+	// TemporalUIURL is the base URL of the Temporal Web UI, used to link the
+	// run from the failure comment. Empty omits the link.
+	TemporalUIURL string
+}
+
+// Names of the workflow's compensations
+const (
+	compensationRemoveWorkspace = "remove workspace"
+	compensationDeleteBranch    = "delete branch"
+)
+
+// compensation undoes one completed step of the workflow
+type compensation struct {
+	name string
+	fn   func(ctx workflow.Context) error
+}
+
+// saga records compensations as steps complete and runs them in reverse order
+// if the workflow fails
+type saga struct {
+	compensations []compensation
+}
+
+// add registers a compensation for a step that has completed
+func (s *saga) add(name string, fn func(ctx workflow.Context) error) {
+	s.compensations = append(s.compensations, compensation{name: name, fn: fn})
+}
+
+// compensate runs every registered compensation, most recent first, and
+// returns the errors of those that failed by name. A failed compensation is
+// logged and does not stop the others.
+func (s *saga) compensate(ctx workflow.Context) map[string]error {
+	logger := workflow.GetLogger(ctx)
+	failed := make(map[string]error)
+	for i := len(s.compensations) - 1; i >= 0; i-- {
+		c := s.compensations[i]
+		if err := c.fn(ctx); err != nil {
+			logger.Error("compensation failed",
+				zap.String("compensation", c.name),
+				zap.Error(err),
+			)
+			failed[c.name] = err
+		}
+	}
+	return failed
+}
+
+// cleanupReport records what cleaning up after a failure actually did, for
+// the failure comment
+type cleanupReport struct {
+	// failed holds the errors of failed compensations by name
+	failed map[string]error
+	// statusRestored reports whether the ticket's status was restored before
+	// the comment was posted. Runs from before the status was restored first
+	// restore it afterwards and leave this unset.
+	statusRestored bool
+	// statusErr is the error restoring the ticket's status, if any
+	statusErr error
+}
+
+// cleanupAfterFailure compensates completed steps, then reports the failure on
+// the Jira ticket and restores its original status. pushed reports whether
+// the feature branch may have reached GitHub. It runs in a disconnected
+// context so it also completes when the workflow was cancelled.
+func cleanupAfterFailure(ctx workflow.Context, input WorkflowInput, s *saga, pushed bool, cause error) {
+	ctx, cancel := workflow.NewDisconnectedContext(ctx)
+	defer cancel()
+
+	logger := workflow.GetLogger(ctx)
+	logger.Info("cleaning up failed workflow",
+		zap.String("jira_ticket", input.Task.JiraTicketID),
+		zap.Error(cause),
+	)
+
+	if temporal.IsCanceledError(cause) {
+		setOutcome(ctx, OutcomeCancelled)
+	} else {
+		setOutcome(ctx, OutcomeFailed)
+	}
+
+	report := cleanupReport{failed: s.compensate(ctx)}
+
+	// The status is restored before commenting so the comment can say
+	// whether it was. Runs that commented first keep doing so on replay.
+	restoreFirst := hasCleanupReport(ctx)
+	if restoreFirst {
+		report.statusErr = restoreJiraStatus(ctx, input)
+		report.statusRestored = true
+	}
+
+	var a *activities.Activities
+	var jiraResult activities.JiraUpdateResult
+	comment := generateFailureComment(workflow.GetInfo(ctx), input, pushed, cause, report)
+	err := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, jiraOptions), a.AddJiraCommentActivity, input.Task.JiraTicketID, comment).Get(ctx, &jiraResult)
+	if err != nil {
+		logger.Error("failed to report failure to Jira", zap.Error(err))
+	}
+
+	if !restoreFirst {
+		restoreJiraStatus(ctx, input)
+	}
+}
+
+// restoreJiraStatus returns the ticket to the status it had when the run
+// picked it up, if it was known, logging and returning any failure
+func restoreJiraStatus(ctx workflow.Context, input WorkflowInput) error {
+	if input.Task.Status == "" {
+		return nil
+	}
+
+	var a *activities.Activities
+	var jiraResult activities.JiraUpdateResult
+	err := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, jiraOptions), a.RestoreJiraStatusActivity, input.Task.JiraTicketID, input.Task.Status).Get(ctx, &jiraResult)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("failed to restore Jira status", zap.Error(err))
+	}
+	return err
+}
+
+// keepBranch reports whether a failed run's pushed branch should be kept. A
+// branch that already has a pull request is never deleted, as that would
+// close the pull request.
+func keepBranch(policy CleanupPolicy, cause error) bool {
+	var appErr *temporal.ApplicationError
+	if errors.As(cause, &appErr) && appErr.Type() == activities.ErrTypePullRequestExists {
+		return true
+	}
+	return policy.BranchPolicy == BranchPolicyKeep
+}
+
+func generateFailureComment(info *workflow.Info, input WorkflowInput, pushed bool, cause error, report cleanupReport) string {
+	var comment string
+	if temporal.IsCanceledError(cause) {
+		comment = "Khitomer's run for this ticket was cancelled."
+	} else {
+		comment = "Khitomer's run for this ticket failed: " + failureReason(cause)
+	}
+
+	comment += fmt.Sprintf("\n\nWorkflow: %s (run %s)", info.WorkflowExecution.ID, info.WorkflowExecution.RunID)
+	if input.Cleanup.TemporalUIURL != "" {
+		comment += "\n" + runURL(input.Cleanup.TemporalUIURL, info)
+	}
+
+	if pushed {
+		branch := input.Repository.FeatureBranch
+		if keepBranch(input.Cleanup, cause) {
+			comment += fmt.Sprintf("\n\nThe branch %s was kept for inspection.", branch)
+		} else if err, ok := report.failed[compensationDeleteBranch]; ok {
+			comment += fmt.Sprintf("\n\nKhitomer could not delete the branch %s: %s", branch, failureReason(err))
+		} else {
+			comment += fmt.Sprintf("\n\nThe branch %s was deleted.", branch)
+		}
+	}
+	if input.Task.Status != "" {
+		switch {
+		case !report.statusRestored:
+			comment += fmt.Sprintf("\n\nThe ticket will be returned to %q.", input.Task.Status)
+		case report.statusErr != nil:
+			comment += fmt.Sprintf("\n\nKhitomer could not restore the ticket's status to %q: %s", input.Task.Status, failureReason(report.statusErr))
+		default:
+			comment += fmt.Sprintf("\n\nThe ticket was returned to %q.", input.Task.Status)
+		}
+	}
+
+	return comment
+}
+
+// failureReason returns the message of the application error behind a
+// failure, without Temporal's activity wrapping
+func failureReason(err error) string {
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) {
+		return appErr.Message()
+	}
+	return err.Error()
+}
+
+// runURL links a workflow run in the Temporal Web UI
+func runURL(baseURL string, info *workflow.Info) string {
+	return fmt.Sprintf("%s/namespaces/%s/workflows/%s/%s/history",
+		strings.TrimSuffix(baseURL, "/"), info.Namespace, info.WorkflowExecution.ID, info.WorkflowExecution.RunID)
+}
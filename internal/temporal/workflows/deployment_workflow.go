@@ -0,0 +1,61 @@
+package workflows
+
+import (
+	"fmt"
+
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/activities"
+	"github.com/clintrovert/khitomer/internal/deploy"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// DeploymentInput is the input for the deployment workflow
+type DeploymentInput struct {
+	Task       *types.Task
+	Repository *types.RepositoryInfo
+	RepoPath   string
+	Step       types.PlanStep
+}
+
+// DeploymentWorkflowID returns the ID of the deployment child workflow for a
+// step
+func DeploymentWorkflowID(parentWorkflowID string, stepOrder int) string {
+	return fmt.Sprintf("%s-deploy-%d", parentWorkflowID, stepOrder)
+}
+
+// DeploymentWorkflow deploys the worktree with the worker's deployer, e.g. to
+// a preview environment
+func DeploymentWorkflow(ctx workflow.Context, input DeploymentInput) (*activities.DeploymentResult, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("starting deployment workflow",
+		zap.String("jira_ticket", input.Task.JiraTicketID),
+		zap.Int("step", input.Step.Order),
+	)
+
+	var a *activities.Activities
+
+	req := deploy.Request{
+		JiraKey:    input.Task.JiraTicketID,
+		Owner:      input.Repository.Owner,
+		Repo:       input.Repository.Name,
+		Branch:     input.Repository.FeatureBranch,
+		RepoPath:   input.RepoPath,
+		StepOrder:  input.Step.Order,
+		Parameters: input.Step.Parameters,
+	}
+
+	var result activities.DeploymentResult
+	err := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, deployOptions), a.DeployActivity, req).Get(ctx, &result)
+	if err != nil {
+		logger.Error("failed to deploy", zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("deployment workflow completed",
+		zap.String("url", result.URL),
+	)
+
+	return &result, nil
+}
@@ -2,99 +2,235 @@ package workflows
 
 import (
 	"fmt"
-	"time"
+	"net/url"
+	"strconv"
 
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 	"go.uber.org/zap"
 
 	"github.com/clintrovert/khitomer/internal/activities"
+	"github.com/clintrovert/khitomer/internal/coverage"
+	"github.com/clintrovert/khitomer/internal/guardrails"
 	"github.com/clintrovert/khitomer/pkg/types"
 )
 
-// ImplementationWorkflow orchestrates the implementation of a Jira task
-func ImplementationWorkflow(ctx workflow.Context, input WorkflowInput) (*types.PRInfo, error) {
+// ImplementationWorkflow orchestrates the implementation of a Jira task. If
+// it fails or is cancelled, completed steps are compensated in reverse order
+// and the failure is reported on the Jira ticket. Progress is reported through
+// ProgressQueryName.
+func ImplementationWorkflow(ctx workflow.Context, input WorkflowInput) (result *WorkflowResult, err error) {
 	logger := workflow.GetLogger(ctx)
 	logger.Info("starting implementation workflow",
 		zap.String("jira_ticket", input.Task.JiraTicketID),
 		zap.String("repository", input.Repository.Name),
 	)
 
-	ao := workflow.ActivityOptions{
-		StartToCloseTimeout: 10 * time.Minute,
-		RetryPolicy: &workflow.RetryPolicy{
-			InitialInterval:    time.Second,
-			BackoffCoefficient: 2.0,
-			MaximumInterval:    time.Minute,
-			MaximumAttempts:    3,
-		},
+	var a *activities.Activities
+
+	progress, err := newProgressLog(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger := newUsageLedger(ctx, input)
+
+	var s saga
+	pushed := false
+	defer func() {
+		recordOutcome(ctx, err)
+		if err != nil {
+			progress.emit(ProgressEvent{Type: EventFailed, Message: failureReason(err)})
+			cleanupAfterFailure(ctx, input, &s, pushed, err)
+		}
+	}()
+
+	// The plan alone may already be over the ticket budget
+	if err = ledger.checkBudget(); err != nil {
+		return nil, err
 	}
-	ctx = workflow.WithActivityOptions(ctx, ao)
 
-	// Step 1: Clone repository
+	// Step 1: Clone repository. A partial clone is removed too.
+	progress.stepStarted(StepClone, 0, input.Repository.Owner+"/"+input.Repository.Name)
+	s.add(compensationRemoveWorkspace, func(ctx workflow.Context) error {
+		return workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, localGitOptions), a.RemoveWorkspaceActivity, input.Repository).Get(ctx, nil)
+	})
 	var cloneResult activities.GitHubOperationResult
-	err := workflow.ExecuteActivity(ctx, activities.CloneRepositoryActivity, input.Repository).Get(ctx, &cloneResult)
+	err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, cloneOptions), a.CloneRepositoryActivity, input.Repository).Get(ctx, &cloneResult)
 	if err != nil {
 		logger.Error("failed to clone repository", zap.Error(err))
 		return nil, err
 	}
+	progress.stepCompleted(StepClone, 0, "")
 
 	// Step 2: Create feature branch
 	var branchResult activities.GitHubOperationResult
 	branchName := generateBranchName(input.Task.JiraTicketID, input.Task.Title)
-	err = workflow.ExecuteActivity(ctx, activities.CreateBranchActivity, input.Repository, branchName).Get(ctx, &branchResult)
+	progress.stepStarted(StepCreateBranch, 0, branchName)
+	err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, localGitOptions), a.CreateBranchActivity, input.Repository, branchName).Get(ctx, &branchResult)
 	if err != nil {
 		logger.Error("failed to create branch", zap.Error(err))
 		return nil, err
 	}
 	input.Repository.FeatureBranch = branchResult.BranchName
+	progress.stepCompleted(StepCreateBranch, 0, branchResult.BranchName)
+
+	// Step 3: Run the plan's codegen, testing, deployment and review steps in
+	// dependency order, committing after each codegen step if requested
+	steps, err := runPlanSteps(ctx, input, cloneResult.RepositoryPath, progress, ledger)
+	if err != nil {
+		logger.Error("failed to run plan steps", zap.Error(err))
+		return nil, err
+	}
+	codegenResult := steps.Codegen
+	commits := steps.Commits
+	progress.emit(ProgressEvent{
+		Type:    EventCodegenSummary,
+		Message: codegenResult.Summary,
+		Data: map[string]string{
+			"modified_files": strconv.Itoa(len(codegenResult.ModifiedFiles)),
+			"created_files":  strconv.Itoa(len(codegenResult.CreatedFiles)),
+		},
+	})
 
-	// Step 3: Generate/modify code
-	var codegenResult activities.CodeGenerationResult
-	err = workflow.ExecuteActivity(ctx, activities.CodeGenerationActivity, input.Task, input.Plan, cloneResult.RepositoryPath).Get(ctx, &codegenResult)
+	// Step 4: Enforce diff-size and protected-path guardrails
+	var guardrailResult activities.GuardrailResult
+	progress.stepStarted(StepGuardrails, 0, "")
+	err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, localGitOptions), a.CheckGuardrailsActivity, cloneResult.RepositoryPath, input.Repository.BaseBranch).Get(ctx, &guardrailResult)
 	if err != nil {
-		logger.Error("failed to generate code", zap.Error(err))
+		logger.Error("failed to check guardrails", zap.Error(err))
 		return nil, err
 	}
+	if len(guardrailResult.Violations) > 0 {
+		var jiraResult activities.JiraUpdateResult
+		comment := generateGuardrailComment(&guardrailResult)
+		err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, jiraOptions), a.AddJiraCommentActivity, input.Task.JiraTicketID, comment).Get(ctx, &jiraResult)
+		if err != nil {
+			logger.Error("failed to report guardrail violations to Jira", zap.Error(err))
+		}
+
+		if guardrailResult.Action == guardrails.ActionFail {
+			return nil, temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("generated changes breached %d guardrails", len(guardrailResult.Violations)),
+				"GuardrailViolation", nil, guardrailResult.Violations,
+			)
+		}
+	}
+	progress.stepCompleted(StepGuardrails, 0, fmt.Sprintf("%d violations", len(guardrailResult.Violations)))
 
-	// Step 4: Run tests
+	// Step 5: Run tests
 	var testResult activities.TestingResult
-	err = workflow.ExecuteActivity(ctx, activities.TestingActivity, cloneResult.RepositoryPath).Get(ctx, &testResult)
+	progress.stepStarted(StepTests, 0, "")
+	err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, testingOptions), a.TestingActivity, cloneResult.RepositoryPath, input.Repository.BaseBranch, 0).Get(ctx, &testResult)
 	if err != nil {
 		logger.Error("tests failed", zap.Error(err))
 		// Continue even if tests fail - let humans review
 	}
+	progress.emit(testResultEvent(StepTests, 0, &testResult))
+	if err = ledger.record(0, types.UsagePhaseRepair, testResult.Usage); err != nil {
+		return nil, err
+	}
+	progress.stepCompleted(StepTests, 0, "")
 
-	// Step 5: Commit changes
+	// Step 6: Commit changes. With per-step commits, only changes made during
+	// testing (e.g. test repair) remain and they get a separate fix-up commit.
 	var commitResult activities.GitHubOperationResult
-	err = workflow.ExecuteActivity(ctx, activities.CommitChangesActivity, input.Repository, cloneResult.RepositoryPath, codegenResult.Summary).Get(ctx, &commitResult)
+	commitMessage := generateCommitMessage(input.Task, input.Plan, codegenResult.Summary)
+	commitKind := types.CommitKindSquash
+	if input.CommitPerStep {
+		commitMessage = generateFixupCommitMessage(input.Task)
+		commitKind = types.CommitKindFixup
+	}
+	progress.stepStarted(StepCommit, 0, commitMessage.Subject)
+	err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, localGitOptions), a.CommitChangesActivity, input.Repository, cloneResult.RepositoryPath, commitMessage).Get(ctx, &commitResult)
 	if err != nil {
 		logger.Error("failed to commit changes", zap.Error(err))
 		return nil, err
 	}
+	if commitResult.CommitSHA != "" {
+		commits = append(commits, types.CommitInfo{
+			SHA:     commitResult.CommitSHA,
+			Subject: commitMessage.Subject,
+			Kind:    commitKind,
+		})
+	}
+	if len(commits) == 0 {
+		return nil, temporal.NewNonRetryableApplicationError("code generation produced no changes", "NoChanges", nil)
+	}
+	progress.stepCompleted(StepCommit, 0, fmt.Sprintf("%d commits", len(commits)))
 
-	// Step 6: Create PR
+	// Step 7: Push branch. A failed push may still have updated the remote, so
+	// the branch is compensated either way.
+	pushed = true
+	s.add(compensationDeleteBranch, func(ctx workflow.Context) error {
+		if keepBranch(input.Cleanup, err) {
+			return nil
+		}
+		return workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, githubAPIOptions), a.DeleteBranchActivity, input.Repository).Get(ctx, nil)
+	})
+	var pushResult activities.GitHubOperationResult
+	progress.stepStarted(StepPush, 0, input.Repository.FeatureBranch)
+	err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, pushOptions), a.PushBranchActivity, input.Repository, cloneResult.RepositoryPath).Get(ctx, &pushResult)
+	if err != nil {
+		logger.Error("failed to push branch", zap.Error(err))
+		return nil, err
+	}
+	progress.stepCompleted(StepPush, 0, input.Repository.FeatureBranch)
+
+	// Step 8: Create PR, as a draft if changed-line coverage is below threshold
+	// or a guardrail was breached
 	var prResult activities.GitHubOperationResult
 	prTitle := generatePRTitle(input.Task.JiraTicketID, input.Task.Title)
-	prDescription := generatePRDescription(input.Task, input.Plan)
-	err = workflow.ExecuteActivity(ctx, activities.CreatePRActivity, input.Repository, prTitle, prDescription).Get(ctx, &prResult)
+	prDescription := generatePRDescription(input.Task, input.Plan, steps, &testResult, &guardrailResult, ledger.result())
+	if input.ArtifactsURL != "" {
+		prDescription += generateArtifactsSection(input.ArtifactsURL + "?run_id=" + url.QueryEscape(workflow.GetInfo(ctx).WorkflowExecution.RunID))
+	}
+	draft := testResult.Coverage != nil && testResult.Coverage.BelowThreshold
+	var labels []string
+	if len(guardrailResult.Violations) > 0 {
+		draft = true
+		labels = append(labels, guardrailResult.ReviewLabel)
+	}
+	progress.stepStarted(StepCreatePR, 0, prTitle)
+	err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, githubAPIOptions), a.CreatePRActivity, input.Repository, prTitle, prDescription, draft, labels).Get(ctx, &prResult)
 	if err != nil {
 		logger.Error("failed to create PR", zap.Error(err))
 		return nil, err
 	}
+	setOutcome(ctx, OutcomePROpened, PRNumberKey.ValueSet(prResult.PRInfo.PRNumber))
+	progress.stepCompleted(StepCreatePR, 0, prTitle)
+	progress.emit(ProgressEvent{
+		Type:    EventPROpened,
+		Message: prTitle,
+		Data: map[string]string{
+			"url":    prResult.PRInfo.PRURL,
+			"number": strconv.FormatInt(prResult.PRInfo.PRNumber, 10),
+			"draft":  strconv.FormatBool(draft),
+		},
+	})
 
-	// Step 7: Update Jira with PR link
+	// Step 9: Update Jira with PR link
 	var jiraResult activities.JiraUpdateResult
-	err = workflow.ExecuteActivity(ctx, activities.UpdateJiraActivity, input.Task.JiraTicketID, prResult.PRInfo.PRURL).Get(ctx, &jiraResult)
+	progress.stepStarted(StepUpdateJira, 0, input.Task.JiraTicketID)
+	err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, jiraOptions), a.UpdateJiraActivity, input.Task.JiraTicketID, prResult.PRInfo.PRURL, ledger.result(), generateJiraSummary(input.Plan, &testResult)).Get(ctx, &jiraResult)
 	if err != nil {
 		logger.Error("failed to update Jira", zap.Error(err))
 		// Non-fatal - PR was created successfully
+	} else {
+		progress.stepCompleted(StepUpdateJira, 0, input.Task.JiraTicketID)
 	}
 
 	logger.Info("implementation workflow completed",
 		zap.String("pr_url", prResult.PRInfo.PRURL),
+		zap.Int("commits", len(commits)),
 	)
+	progress.emit(ProgressEvent{Type: EventCompleted, Message: prResult.PRInfo.PRURL})
 
-	return prResult.PRInfo, nil
+	return &WorkflowResult{
+		PRInfo:  prResult.PRInfo,
+		Commits: commits,
+		Usage:   ledger.result(),
+	}, nil
 }
 
 func generateBranchName(ticketID, title string) string {
@@ -103,11 +239,39 @@ func generateBranchName(ticketID, title string) string {
 	return "khitomer/" + ticketID + "-" + sanitizeBranchName(shortTitle)
 }
 
+func generateCommitMessage(task *types.Task, plan *types.ImplementationPlan, body string) types.CommitMessage {
+	msg := types.CommitMessage{
+		IssueType:   task.IssueType,
+		JiraKey:     task.JiraTicketID,
+		Subject:     task.Title,
+		Body:        body,
+		PlanSummary: plan.Summary,
+	}
+	if task.Assignee != "" && task.AssigneeEmail != "" {
+		msg.CoAuthors = append(msg.CoAuthors, task.Assignee+" <"+task.AssigneeEmail+">")
+	}
+	return msg
+}
+
+func generateStepCommitMessage(task *types.Task, step types.PlanStep) types.CommitMessage {
+	msg := generateCommitMessage(task, &types.ImplementationPlan{}, "")
+	msg.Subject = step.Description
+	msg.Body = fmt.Sprintf("Plan step %d for %s: %s", step.Order, task.JiraTicketID, task.Title)
+	return msg
+}
+
+func generateFixupCommitMessage(task *types.Task) types.CommitMessage {
+	msg := generateCommitMessage(task, &types.ImplementationPlan{}, "")
+	msg.Type = "fix"
+	msg.Subject = "apply fix-ups from test repair"
+	return msg
+}
+
 func generatePRTitle(ticketID, title string) string {
 	return ticketID + ": " + title
 }
 
-func generatePRDescription(task *types.Task, plan *types.ImplementationPlan) string {
+func generatePRDescription(task *types.Task, plan *types.ImplementationPlan, steps *stepResults, testResult *activities.TestingResult, guardrailResult *activities.GuardrailResult, usage *types.UsageTotals) string {
 	desc := "## Implementation for " + task.JiraTicketID + "\n\n"
 	desc += "**Jira Ticket:** " + task.JiraTicketID + "\n"
 	desc += "**Description:** " + task.Description + "\n\n"
@@ -117,9 +281,158 @@ func generatePRDescription(task *types.Task, plan *types.ImplementationPlan) str
 	for i, step := range plan.Steps {
 		desc += fmt.Sprintf("%d. %s\n", i+1, step.Description)
 	}
+	desc += generateStepSection(steps)
+	desc += generateTestSection(testResult)
+	desc += generateGuardrailSection(guardrailResult)
+	desc += generateUsageSection(usage)
+	return desc
+}
+
+func generateStepSection(steps *stepResults) string {
+	if steps == nil || (len(steps.Deployments) == 0 && len(steps.Reviews) == 0) {
+		return ""
+	}
+
+	desc := ""
+	if urls := steps.previewURLs(); len(urls) > 0 {
+		desc += "\n## Preview Environments\n\n"
+		for _, url := range urls {
+			desc += "- " + url + "\n"
+		}
+	}
+	if len(steps.Reviews) > 0 {
+		desc += "\n## Reviews\n\n"
+		for _, r := range steps.Reviews {
+			line := "- Approved by " + r.Reviewer
+			if r.Comment != "" {
+				line += ": " + r.Comment
+			}
+			desc += line + "\n"
+		}
+	}
+	return desc
+}
+
+func generateGuardrailSection(result *activities.GuardrailResult) string {
+	if result == nil || len(result.Violations) == 0 {
+		return ""
+	}
+
+	desc := "\n## Guardrail Violations\n\n"
+	desc += "This change exceeds the limits for generated code and needs extra review:\n\n"
+	for _, v := range result.Violations {
+		desc += "- " + v + "\n"
+	}
+	return desc
+}
+
+func generateUsageSection(usage *types.UsageTotals) string {
+	if usage == nil || len(usage.Calls) == 0 {
+		return ""
+	}
+
+	desc := "\n## LLM Usage\n\n"
+	desc += "| Phase | Model | Prompt tokens | Completion tokens | Cost |\n"
+	desc += "|---|---|---|---|---|\n"
+	for _, call := range usage.Calls {
+		desc += fmt.Sprintf("| %s | %s | %d | %d | $%.4f |\n", call.Phase, call.Model, call.PromptTokens, call.CompletionTokens, call.Cost)
+	}
+	desc += fmt.Sprintf("| **Total** | | %d | %d | $%.4f |\n", usage.PromptTokens, usage.CompletionTokens, usage.Cost)
 	return desc
 }
 
+func generateArtifactsSection(link string) string {
+	return "\n## Artifacts\n\n" +
+		"The plan, LLM prompts and responses, per-attempt diffs and test reports of this run are archived at " + link + "\n"
+}
+
+func generateGuardrailComment(result *activities.GuardrailResult) string {
+	comment := fmt.Sprintf("Khitomer's generated change breached %d guardrails (%d files changed, +%d/-%d lines):\n",
+		len(result.Violations), result.FilesChanged, result.LinesAdded, result.LinesRemoved)
+	for _, v := range result.Violations {
+		comment += "* " + v + "\n"
+	}
+	if result.Action == guardrails.ActionFail {
+		comment += "\nThe run was stopped and no pull request was opened."
+	} else {
+		comment += fmt.Sprintf("\nThe pull request will be opened as a draft labelled %q for extra review.", result.ReviewLabel)
+	}
+	return comment
+}
+
+func generateTestSection(testResult *activities.TestingResult) string {
+	if testResult == nil {
+		return ""
+	}
+
+	desc := "\n## Test Results\n\n"
+	if testResult.Passed {
+		desc += "**Tests:** passed\n"
+	} else {
+		desc += "**Tests:** failed\n"
+	}
+
+	report := testResult.Coverage
+	if report == nil {
+		return desc
+	}
+
+	desc += "\n## Coverage\n\n"
+	desc += generateCoverageTable(report)
+
+	if report.BelowThreshold {
+		desc += fmt.Sprintf("\n:warning: Changed-line coverage is below the %.1f%% threshold; this PR was opened as a draft.\n", report.Threshold)
+	}
+
+	if len(report.UncoveredChanges) > 0 {
+		desc += "\n<details><summary>Uncovered changed lines</summary>\n\n"
+		for _, line := range report.UncoveredChanges {
+			desc += "- `" + line + "`\n"
+		}
+		desc += "\n</details>\n"
+	}
+
+	return desc
+}
+
+func generateCoverageTable(report *coverage.Report) string {
+	table := "| | Coverage | Lines |\n"
+	table += "|---|---|---|\n"
+	table += fmt.Sprintf("| Total | %.1f%% | %d/%d |\n", report.TotalCoverage, report.CoveredLines, report.TotalLines)
+	if report.DeltaLines > 0 {
+		table += fmt.Sprintf("| Changed lines | %.1f%% | %d/%d |\n", report.DeltaCoverage, report.DeltaCoveredLines, report.DeltaLines)
+	} else {
+		table += "| Changed lines | n/a | 0/0 |\n"
+	}
+	return table
+}
+
+// generateJiraSummary summarises the plan and test results for the Jira
+// comment, in Markdown that is rendered as rich text on Jira Cloud
+func generateJiraSummary(plan *types.ImplementationPlan, testResult *activities.TestingResult) string {
+	summary := "## Plan\n\n"
+	if plan.Summary != "" {
+		summary += plan.Summary + "\n\n"
+	}
+	for i, step := range plan.Steps {
+		summary += fmt.Sprintf("%d. %s\n", i+1, step.Description)
+	}
+
+	summary += "\n## Tests\n\n"
+	if testResult.Passed {
+		summary += "**Tests:** passed\n"
+	} else {
+		summary += "**Tests:** failed\n"
+	}
+	if report := testResult.Coverage; report != nil {
+		summary += "\n" + generateCoverageTable(report)
+		if report.BelowThreshold {
+			summary += fmt.Sprintf("\n:warning: Changed-line coverage is below the %.1f%% threshold; the pull request was opened as a draft.\n", report.Threshold)
+		}
+	}
+	return summary
+}
+
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
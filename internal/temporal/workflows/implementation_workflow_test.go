@@ -0,0 +1,229 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/clintrovert/khitomer/internal/activities"
+	"github.com/clintrovert/khitomer/internal/guardrails"
+	"github.com/clintrovert/khitomer/internal/usage"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+type ImplementationWorkflowTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+
+	env *testsuite.TestWorkflowEnvironment
+}
+
+func TestImplementationWorkflow(t *testing.T) {
+	suite.Run(t, new(ImplementationWorkflowTestSuite))
+}
+
+func (s *ImplementationWorkflowTestSuite) SetupTest() {
+	s.env = s.NewTestWorkflowEnvironment()
+	// Activities without mocks run for real and, with no dependencies, fail
+	// as not configured
+	s.env.RegisterActivity(&activities.Activities{})
+}
+
+func (s *ImplementationWorkflowTestSuite) AfterTest(suiteName, testName string) {
+	s.env.AssertExpectations(s.T())
+}
+
+// testInput returns the input of a run with a single codegen step
+func testInput() WorkflowInput {
+	return WorkflowInput{
+		Task: &types.Task{
+			JiraTicketID: "PROJ-123",
+			Title:        "Add health check",
+			Status:       "Ready for Development",
+		},
+		Plan: &types.ImplementationPlan{
+			Summary: "Add a /healthz endpoint",
+			Steps: []types.PlanStep{
+				{Order: 1, Description: "Add the handler", ActivityType: types.StepTypeCodegen},
+			},
+		},
+		Repository: &types.RepositoryInfo{Owner: "acme", Name: "api", BaseBranch: "main"},
+	}
+}
+
+// mockUntilPush mocks every activity up to and including the push
+func (s *ImplementationWorkflowTestSuite) mockUntilPush() {
+	var a *activities.Activities
+	s.env.OnActivity(a.CloneRepositoryActivity, mock.Anything, mock.Anything).
+		Return(activities.GitHubOperationResult{Success: true, RepositoryPath: "/workspace/acme/api"}, nil)
+	s.env.OnActivity(a.CreateBranchActivity, mock.Anything, mock.Anything, "khitomer/PROJ-123-Add-health-check").
+		Return(activities.GitHubOperationResult{Success: true, BranchName: "khitomer/PROJ-123-Add-health-check"}, nil)
+	s.env.OnActivity(a.CodeGenerationActivity, mock.Anything, mock.Anything, mock.Anything, mock.Anything, "/workspace/acme/api").
+		Return(activities.CodeGenerationResult{Success: true, ModifiedFiles: []string{"server.go"}}, nil)
+	s.env.OnActivity(a.CheckGuardrailsActivity, mock.Anything, "/workspace/acme/api", "main").
+		Return(activities.GuardrailResult{FilesChanged: 1, LinesAdded: 10, Action: guardrails.ActionDraft}, nil)
+	s.env.OnActivity(a.TestingActivity, mock.Anything, "/workspace/acme/api", "main", 0).
+		Return(activities.TestingResult{Passed: true}, nil)
+	s.env.OnActivity(a.CommitChangesActivity, mock.Anything, mock.Anything, "/workspace/acme/api", mock.Anything).
+		Return(activities.GitHubOperationResult{Success: true, CommitSHA: "abc123"}, nil)
+	s.env.OnActivity(a.PushBranchActivity, mock.Anything, mock.Anything, "/workspace/acme/api").
+		Return(activities.GitHubOperationResult{Success: true}, nil)
+}
+
+func (s *ImplementationWorkflowTestSuite) Test_OpensPullRequest() {
+	var a *activities.Activities
+	s.mockUntilPush()
+	pr := &types.PRInfo{PRNumber: 7, PRURL: "https://github.com/acme/api/pull/7"}
+	s.env.OnActivity(a.CreatePRActivity, mock.Anything, mock.Anything, "PROJ-123: Add health check", mock.Anything, false, []string(nil)).
+		Return(activities.GitHubOperationResult{Success: true, PRInfo: pr}, nil)
+	s.env.OnActivity(a.UpdateJiraActivity, mock.Anything, "PROJ-123", pr.PRURL, mock.Anything, mock.Anything).
+		Return(activities.JiraUpdateResult{Success: true}, nil)
+
+	s.env.ExecuteWorkflow(ImplementationWorkflow, testInput())
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result WorkflowResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(pr, result.PRInfo)
+	s.Equal([]types.CommitInfo{{SHA: "abc123", Subject: "Add health check", Kind: types.CommitKindSquash}}, result.Commits)
+}
+
+func (s *ImplementationWorkflowTestSuite) Test_MissingDependencyFailsWithoutRetrying() {
+	// With no GitHub dependency the clone fails as not configured, and the
+	// workspace removal and Jira report fail the same way during cleanup
+	clones := 0
+	s.env.SetOnActivityStartedListener(func(info *activity.Info, ctx context.Context, args converter.EncodedValues) {
+		if info.ActivityType.Name == "CloneRepositoryActivity" {
+			clones++
+		}
+	})
+
+	s.env.ExecuteWorkflow(ImplementationWorkflow, testInput())
+
+	s.True(s.env.IsWorkflowCompleted())
+	err := s.env.GetWorkflowError()
+	s.Error(err)
+	var appErr *temporal.ApplicationError
+	s.True(errors.As(err, &appErr))
+	s.Equal(activities.ErrTypeNotConfigured, appErr.Type())
+	s.True(appErr.NonRetryable())
+	s.Equal(1, clones)
+}
+
+func (s *ImplementationWorkflowTestSuite) Test_FailureAfterPushCompensates() {
+	var a *activities.Activities
+	s.mockUntilPush()
+	s.env.OnActivity(a.CreatePRActivity, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(activities.GitHubOperationResult{}, temporal.NewNonRetryableApplicationError("validation failed", "PullRequestInvalid", nil))
+	s.env.OnActivity(a.DeleteBranchActivity, mock.Anything, mock.Anything).
+		Return(activities.GitHubOperationResult{Success: true}, nil).Once()
+	s.env.OnActivity(a.RemoveWorkspaceActivity, mock.Anything, mock.Anything).
+		Return(activities.GitHubOperationResult{Success: true}, nil).Once()
+	s.env.OnActivity(a.AddJiraCommentActivity, mock.Anything, "PROJ-123", mock.MatchedBy(func(comment string) bool {
+		return strings.Contains(comment, "failed: validation failed") &&
+			strings.Contains(comment, "The branch khitomer/PROJ-123-Add-health-check was deleted.")
+	})).Return(activities.JiraUpdateResult{Success: true}, nil).Once()
+	s.env.OnActivity(a.RestoreJiraStatusActivity, mock.Anything, "PROJ-123", "Ready for Development").
+		Return(activities.JiraUpdateResult{Success: true}, nil).Once()
+
+	s.env.ExecuteWorkflow(ImplementationWorkflow, testInput())
+
+	s.True(s.env.IsWorkflowCompleted())
+	var appErr *temporal.ApplicationError
+	s.True(errors.As(s.env.GetWorkflowError(), &appErr))
+	s.Equal("PullRequestInvalid", appErr.Type())
+}
+
+func (s *ImplementationWorkflowTestSuite) Test_FailureCommentReportsFailedCleanup() {
+	var a *activities.Activities
+	s.mockUntilPush()
+	s.env.OnActivity(a.CreatePRActivity, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(activities.GitHubOperationResult{}, temporal.NewNonRetryableApplicationError("validation failed", "PullRequestInvalid", nil))
+	s.env.OnActivity(a.DeleteBranchActivity, mock.Anything, mock.Anything).
+		Return(activities.GitHubOperationResult{}, temporal.NewNonRetryableApplicationError("branch is protected", "BranchProtected", nil))
+	s.env.OnActivity(a.RemoveWorkspaceActivity, mock.Anything, mock.Anything).
+		Return(activities.GitHubOperationResult{Success: true}, nil)
+	s.env.OnActivity(a.RestoreJiraStatusActivity, mock.Anything, "PROJ-123", "Ready for Development").
+		Return(activities.JiraUpdateResult{}, temporal.NewNonRetryableApplicationError("no transition", "NoTransition", nil))
+	var comment string
+	s.env.OnActivity(a.AddJiraCommentActivity, mock.Anything, "PROJ-123", mock.Anything).
+		Run(func(args mock.Arguments) { comment = args.String(2) }).
+		Return(activities.JiraUpdateResult{Success: true}, nil).Once()
+
+	var order []string
+	s.env.SetOnActivityStartedListener(func(info *activity.Info, ctx context.Context, args converter.EncodedValues) {
+		order = append(order, info.ActivityType.Name)
+	})
+
+	s.env.ExecuteWorkflow(ImplementationWorkflow, testInput())
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.Error(s.env.GetWorkflowError())
+	s.Contains(comment, "Khitomer could not delete the branch khitomer/PROJ-123-Add-health-check: branch is protected")
+	s.Contains(comment, `Khitomer could not restore the ticket's status to "Ready for Development": no transition`)
+	s.NotContains(comment, "was deleted")
+	s.NotContains(comment, "was returned")
+	s.Equal([]string{"DeleteBranchActivity", "RemoveWorkspaceActivity", "RestoreJiraStatusActivity", "AddJiraCommentActivity"}, order[len(order)-4:])
+}
+
+func (s *ImplementationWorkflowTestSuite) Test_TicketBudgetCountsEarlierRuns() {
+	input := testInput()
+	input.Plan.Usage = &types.LLMUsage{Phase: types.UsagePhasePlan, Cost: 0.2}
+	input.Usage = UsagePolicy{Budget: 1, PriorCost: 0.9}
+
+	s.env.ExecuteWorkflow(ImplementationWorkflow, input)
+
+	s.True(s.env.IsWorkflowCompleted())
+	var appErr *temporal.ApplicationError
+	s.True(errors.As(s.env.GetWorkflowError(), &appErr))
+	s.Equal(ErrTypeBudgetExceeded, appErr.Type())
+	s.Contains(appErr.Message(), "spent $1.10 of the $1.00 ticket budget")
+}
+
+func (s *ImplementationWorkflowTestSuite) Test_RunStopsOverDailyAllowance() {
+	var a *activities.Activities
+	input := testInput()
+	input.Usage = UsagePolicy{
+		// $10 per million prompt tokens
+		Prices:         usage.PriceTable{"gpt-4": {Prompt: 10, Completion: 30}},
+		DailyAllowance: 0.5,
+	}
+	s.env.OnActivity(a.CloneRepositoryActivity, mock.Anything, mock.Anything).
+		Return(activities.GitHubOperationResult{Success: true, RepositoryPath: "/workspace/acme/api"}, nil)
+	s.env.OnActivity(a.CreateBranchActivity, mock.Anything, mock.Anything, mock.Anything).
+		Return(activities.GitHubOperationResult{Success: true, BranchName: "khitomer/PROJ-123-Add-health-check"}, nil)
+	s.env.OnActivity(a.CodeGenerationActivity, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(activities.CodeGenerationResult{Success: true, Usage: []types.LLMUsage{{Model: "gpt-4", PromptTokens: 75000}}}, nil)
+	s.env.OnActivity(a.RemoveWorkspaceActivity, mock.Anything, mock.Anything).
+		Return(activities.GitHubOperationResult{Success: true}, nil).Once()
+
+	s.env.ExecuteWorkflow(ImplementationWorkflow, input)
+
+	s.True(s.env.IsWorkflowCompleted())
+	appErr := findApplicationError(s.env.GetWorkflowError(), ErrTypeBudgetExceeded)
+	s.NotNil(appErr)
+	s.Contains(appErr.Message(), "more than the $0.50 left of the daily budget")
+}
+
+// findApplicationError returns the application error of type errType in
+// err's chain. Plan step errors are wrapped, so the workflow fails with the
+// wrapping error and the application error as its cause.
+func findApplicationError(err error, errType string) *temporal.ApplicationError {
+	var appErr *temporal.ApplicationError
+	for errors.As(err, &appErr) {
+		if appErr.Type() == errType {
+			return appErr
+		}
+		err = appErr.Unwrap()
+	}
+	return nil
+}
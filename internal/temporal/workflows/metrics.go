@@ -0,0 +1,55 @@
+package workflows
+
+import (
+	"errors"
+	"slices"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Workflow outcome metrics. They are recorded through the workflow's metrics
+// handler, which skips them while replaying, so each run counts once.
+const (
+	workflowsCompletedMetric = "khitomer_workflows_completed_total"
+	workflowsFailedMetric    = "khitomer_workflows_failed_total"
+)
+
+// failureErrorTypes are the application error types reported as failure
+// reasons. Other failures are reported by category to keep the number of
+// reasons small.
+var failureErrorTypes = append([]string{"GuardrailViolation", "NoChanges", "ReviewRejected", ErrTypeBudgetExceeded}, nonRetryableErrorTypes...)
+
+// recordOutcome counts a completed or failed run
+func recordOutcome(ctx workflow.Context, err error) {
+	handler := workflow.GetMetricsHandler(ctx)
+	if err == nil {
+		handler.Counter(workflowsCompletedMetric).Inc(1)
+		return
+	}
+	handler.WithTags(map[string]string{"reason": failureKind(err)}).Counter(workflowsFailedMetric).Inc(1)
+}
+
+// failureKind classifies a workflow error as a metric label
+func failureKind(err error) string {
+	switch {
+	case temporal.IsCanceledError(err):
+		return "cancelled"
+	case temporal.IsTimeoutError(err):
+		return "timeout"
+	}
+
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) && slices.Contains(failureErrorTypes, appErr.Type()) {
+		return appErr.Type()
+	}
+	var activityErr *temporal.ActivityError
+	if errors.As(err, &activityErr) {
+		return "activity_failed"
+	}
+	var childErr *temporal.ChildWorkflowExecutionError
+	if errors.As(err, &childErr) {
+		return "child_workflow_failed"
+	}
+	return "error"
+}
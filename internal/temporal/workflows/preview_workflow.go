@@ -0,0 +1,104 @@
+package workflows
+
+import (
+	"fmt"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/activities"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// PreviewInput is the input for the preview workflow
+type PreviewInput struct {
+	Task       *types.Task
+	Plan       *types.ImplementationPlan
+	Repository *types.RepositoryInfo
+	// Usage prices the preview's LLM calls and bounds what it may spend
+	Usage UsagePolicy
+}
+
+// PreviewResult is the change a plan would make
+type PreviewResult struct {
+	Codegen activities.CodeGenerationResult
+	Diff    activities.DiffResult
+	// Usage includes the planning call
+	Usage *types.UsageTotals
+}
+
+// PreviewWorkflow runs a plan's codegen steps in a throwaway clone and
+// returns the resulting diff. Nothing is committed or pushed, Jira is not
+// touched, and other step types are skipped.
+func PreviewWorkflow(ctx workflow.Context, input PreviewInput) (*PreviewResult, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("starting preview workflow",
+		zap.String("jira_ticket", input.Task.JiraTicketID),
+		zap.String("repository", input.Repository.Name),
+	)
+
+	var a *activities.Activities
+	ledger := newUsageLedger(ctx, WorkflowInput{Plan: input.Plan, Usage: input.Usage})
+
+	steps, err := input.Plan.OrderedSteps()
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), "InvalidPlan", err)
+	}
+
+	var cloneResult activities.GitHubOperationResult
+	err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, cloneOptions), a.ClonePreviewActivity, input.Repository).Get(ctx, &cloneResult)
+	if err != nil {
+		logger.Error("failed to clone repository", zap.Error(err))
+		return nil, err
+	}
+	defer func() {
+		// Remove the clone even if the preview was cancelled
+		ctx, cancel := workflow.NewDisconnectedContext(ctx)
+		defer cancel()
+		err := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, localGitOptions), a.RemovePreviewActivity, cloneResult.RepositoryPath).Get(ctx, nil)
+		if err != nil {
+			logger.Error("failed to remove preview workspace", zap.Error(err))
+		}
+	}()
+
+	result := &PreviewResult{
+		Codegen: activities.CodeGenerationResult{
+			ModifiedFiles: []string{},
+			CreatedFiles:  []string{},
+			Success:       true,
+		},
+	}
+	for _, step := range steps {
+		if step.ActivityType != types.StepTypeCodegen {
+			continue
+		}
+
+		var codegenResult activities.CodeGenerationResult
+		err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, codegenOptions), a.CodeGenerationActivity, input.Task, input.Plan, step, cloneResult.RepositoryPath).Get(ctx, &codegenResult)
+		if err != nil {
+			logger.Error("failed to generate code", zap.Int("step", step.Order), zap.Error(err))
+			return nil, fmt.Errorf("step %d (%s): %w", step.Order, step.ActivityType, err)
+		}
+		result.Codegen.ModifiedFiles = append(result.Codegen.ModifiedFiles, codegenResult.ModifiedFiles...)
+		result.Codegen.CreatedFiles = append(result.Codegen.CreatedFiles, codegenResult.CreatedFiles...)
+		if err := ledger.record(step.Order, types.UsagePhaseCodegen, codegenResult.Usage); err != nil {
+			return nil, err
+		}
+	}
+	result.Usage = ledger.result()
+	result.Codegen.Summary = fmt.Sprintf("Generated code for %d files, created %d files", len(result.Codegen.ModifiedFiles), len(result.Codegen.CreatedFiles))
+
+	err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, localGitOptions), a.DiffActivity, cloneResult.RepositoryPath).Get(ctx, &result.Diff)
+	if err != nil {
+		logger.Error("failed to compute diff", zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("preview workflow completed",
+		zap.Int("files_changed", result.Diff.FilesChanged),
+		zap.Bool("truncated", result.Diff.Truncated),
+	)
+
+	return result, nil
+}
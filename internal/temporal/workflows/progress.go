@@ -0,0 +1,120 @@
+package workflows
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/clintrovert/khitomer/internal/activities"
+)
+
+// ProgressQueryName is the query that returns an implementation workflow's
+// progress events. It takes the ID of the last event the caller has seen, or
+// 0 for all events, and returns the events after it.
+const ProgressQueryName = "progress"
+
+// Progress event types
+const (
+	EventStepStarted    = "step_started"
+	EventStepCompleted  = "step_completed"
+	EventCodegenSummary = "codegen_summary"
+	EventTestResult     = "test_result"
+	EventPROpened       = "pr_opened"
+	EventFailed         = "workflow_failed"
+	EventCompleted      = "workflow_completed"
+)
+
+// Workflow steps named in progress events. Plan steps are named by their
+// activity type instead.
+const (
+	StepClone        = "clone"
+	StepCreateBranch = "create_branch"
+	StepGuardrails   = "guardrails"
+	StepTests        = "tests"
+	StepCommit       = "commit"
+	StepPush         = "push"
+	StepCreatePR     = "create_pr"
+	StepUpdateJira   = "update_jira"
+)
+
+// ProgressEvent is one entry in a workflow's progress log
+type ProgressEvent struct {
+	// ID increases by one per event, starting at 1
+	ID   int64
+	Type string
+	Time time.Time
+	// Step names the workflow step or plan step type the event belongs to
+	Step string
+	// StepOrder is the plan step's order, or 0 outside plan steps
+	StepOrder int
+	Message   string
+	// Data holds type-specific details, e.g. the PR URL or test counts
+	Data map[string]string
+}
+
+// progressLog records progress events and serves them to ProgressQueryName.
+// It only lives in workflow memory, so it issues no commands and is rebuilt
+// deterministically on replay.
+type progressLog struct {
+	ctx    workflow.Context
+	events []ProgressEvent
+}
+
+// newProgressLog creates a progress log and registers its query handler
+func newProgressLog(ctx workflow.Context) (*progressLog, error) {
+	p := &progressLog{ctx: ctx}
+	err := workflow.SetQueryHandler(ctx, ProgressQueryName, func(afterID int64) ([]ProgressEvent, error) {
+		if afterID < 0 || afterID >= int64(len(p.events)) {
+			return []ProgressEvent{}, nil
+		}
+		return p.events[afterID:], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// emit appends an event, assigning its ID and time
+func (p *progressLog) emit(event ProgressEvent) {
+	event.ID = int64(len(p.events)) + 1
+	event.Time = workflow.Now(p.ctx)
+	p.events = append(p.events, event)
+}
+
+// stepStarted records the start of a workflow or plan step
+func (p *progressLog) stepStarted(step string, order int, message string) {
+	p.emit(ProgressEvent{Type: EventStepStarted, Step: step, StepOrder: order, Message: message})
+}
+
+// stepCompleted records the successful end of a workflow or plan step
+func (p *progressLog) stepCompleted(step string, order int, message string) {
+	p.emit(ProgressEvent{Type: EventStepCompleted, Step: step, StepOrder: order, Message: message})
+}
+
+// testResultEvent summarizes a test run, with changed-line coverage when it
+// was measured
+func testResultEvent(step string, order int, result *activities.TestingResult) ProgressEvent {
+	event := ProgressEvent{
+		Type:      EventTestResult,
+		Step:      step,
+		StepOrder: order,
+		Message:   "tests passed",
+		Data: map[string]string{
+			"passed":   strconv.FormatBool(result.Passed),
+			"failures": strconv.Itoa(len(result.Failures)),
+		},
+	}
+	if !result.Passed {
+		event.Message = fmt.Sprintf("tests failed with %d failures", len(result.Failures))
+	}
+	if report := result.Coverage; report != nil {
+		event.Data["total_coverage"] = strconv.FormatFloat(report.TotalCoverage, 'f', 1, 64)
+		if report.DeltaLines > 0 {
+			event.Data["changed_line_coverage"] = strconv.FormatFloat(report.DeltaCoverage, 'f', 1, 64)
+		}
+	}
+	return event
+}
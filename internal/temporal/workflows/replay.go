@@ -0,0 +1,84 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+)
+
+// HistoriesDir is where workflow histories used for replay checks are checked
+// in, relative to the repository root
+const HistoriesDir = "internal/temporal/workflows/testdata/histories"
+
+// Register registers every workflow in this package. Workers and the
+// replayer share it so that replay covers exactly what workers run.
+func Register(registry worker.WorkflowRegistry) {
+	registry.RegisterWorkflow(ImplementationWorkflow)
+	registry.RegisterWorkflow(DeploymentWorkflow)
+	registry.RegisterWorkflow(ReviewWorkflow)
+	registry.RegisterWorkflow(PreviewWorkflow)
+}
+
+// ReplayHistories replays every JSON workflow history in dir, as exported by
+// `temporal workflow show --output json`, against the current workflow code.
+// Each file is named after its workflow ID, e.g.
+// implementation-PROJ-123.json, since child workflow IDs are derived from it.
+// It returns the number of histories replayed and an error naming each
+// history that no longer replays deterministically.
+func ReplayHistories(dir string, logger *zap.Logger) (int, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return 0, fmt.Errorf("failed to read histories: %w", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list histories: %w", err)
+	}
+	sort.Strings(files)
+
+	replayer := worker.NewWorkflowReplayer()
+	Register(replayer)
+
+	var errs []error
+	for _, file := range files {
+		if err := replayHistory(replayer, file); err != nil {
+			logger.Error("history failed to replay",
+				zap.String("history", file),
+				zap.Error(err),
+			)
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(file), err))
+			continue
+		}
+		logger.Info("history replayed", zap.String("history", file))
+	}
+
+	return len(files), errors.Join(errs...)
+}
+
+// replayHistory replays one history file as the workflow its file is named
+// after
+func replayHistory(replayer worker.WorkflowReplayer, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	history, err := client.HistoryFromJSON(f, client.HistoryJSONOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to parse history: %w", err)
+	}
+
+	return replayer.ReplayWorkflowHistoryWithOptions(nil, history, worker.ReplayWorkflowHistoryOptions{
+		OriginalExecution: workflow.Execution{
+			ID: strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)),
+		},
+	})
+}
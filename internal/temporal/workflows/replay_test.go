@@ -0,0 +1,17 @@
+package workflows
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestReplayHistories(t *testing.T) {
+	count, err := ReplayHistories("testdata/histories", zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatalf("workflow histories no longer replay: %v", err)
+	}
+	if count == 0 {
+		t.Fatal("no workflow histories to replay")
+	}
+}
@@ -0,0 +1,120 @@
+package workflows
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/activities"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// ReviewSignalName is the signal that delivers a ReviewDecision to a
+// ReviewWorkflow
+const ReviewSignalName = "review-decision"
+
+// DefaultReviewTimeout is how long a review step waits for a decision when
+// the step has no "timeout" parameter
+const DefaultReviewTimeout = 72 * time.Hour
+
+// ReviewInput is the input for the review workflow
+type ReviewInput struct {
+	Task *types.Task
+	Step types.PlanStep
+	// Timeout is how long to wait for a decision before failing the step
+	Timeout time.Duration
+	// PreviewURLs are deployments made by earlier steps, shown to the reviewer
+	PreviewURLs []string
+}
+
+// ReviewDecision is a human's verdict on a review step
+type ReviewDecision struct {
+	Approved bool
+	Reviewer string
+	Comment  string
+}
+
+// ReviewWorkflowID returns the ID of the review child workflow for a step, so
+// decisions can be signalled to it
+func ReviewWorkflowID(parentWorkflowID string, stepOrder int) string {
+	return fmt.Sprintf("%s-review-%d", parentWorkflowID, stepOrder)
+}
+
+// ReviewWorkflow asks for a human review on the Jira ticket and waits for a
+// ReviewSignalName signal. Rejections and timeouts fail the workflow.
+func ReviewWorkflow(ctx workflow.Context, input ReviewInput) (*ReviewDecision, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("waiting for review",
+		zap.String("jira_ticket", input.Task.JiraTicketID),
+		zap.Int("step", input.Step.Order),
+	)
+
+	var a *activities.Activities
+
+	// The request is advisory; reviewers may also be notified out of band
+	var jiraResult activities.JiraUpdateResult
+	workflowID := workflow.GetInfo(ctx).WorkflowExecution.ID
+	if parent := workflow.GetInfo(ctx).ParentWorkflowExecution; parent != nil {
+		workflowID = parent.ID
+	}
+	comment := generateReviewComment(workflowID, input)
+	err := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, jiraOptions), a.AddJiraCommentActivity, input.Task.JiraTicketID, comment).Get(ctx, &jiraResult)
+	if err != nil {
+		logger.Error("failed to request review on Jira", zap.Error(err))
+	}
+
+	var decision ReviewDecision
+	received := false
+	timerCtx, cancelTimer := workflow.WithCancel(ctx)
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(workflow.GetSignalChannel(ctx, ReviewSignalName), func(c workflow.ReceiveChannel, more bool) {
+		c.Receive(ctx, &decision)
+		received = true
+		cancelTimer()
+	})
+	var timerErr error
+	selector.AddFuture(workflow.NewTimer(timerCtx, input.Timeout), func(f workflow.Future) {
+		timerErr = f.Get(ctx, nil)
+	})
+	selector.Select(ctx)
+
+	if !received {
+		if timerErr != nil {
+			// The workflow was cancelled while waiting
+			return nil, timerErr
+		}
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("no review decision for step %d within %s", input.Step.Order, input.Timeout),
+			"ReviewTimedOut", nil,
+		)
+	}
+
+	logger.Info("received review decision",
+		zap.Int("step", input.Step.Order),
+		zap.Bool("approved", decision.Approved),
+		zap.String("reviewer", decision.Reviewer),
+	)
+
+	if !decision.Approved {
+		msg := fmt.Sprintf("step %d was rejected by %s", input.Step.Order, decision.Reviewer)
+		if decision.Comment != "" {
+			msg += ": " + decision.Comment
+		}
+		return nil, temporal.NewNonRetryableApplicationError(msg, "ReviewRejected", nil, decision)
+	}
+
+	return &decision, nil
+}
+
+func generateReviewComment(parentWorkflowID string, input ReviewInput) string {
+	comment := fmt.Sprintf("Khitomer is waiting for a review of step %d: %s\n", input.Step.Order, input.Step.Description)
+	for _, url := range input.PreviewURLs {
+		comment += "\nPreview: " + url
+	}
+	comment += fmt.Sprintf("\n\nApprove or reject it with POST /api/v1/workflows/%s/steps/%d/review on the leader. ", parentWorkflowID, input.Step.Order)
+	comment += fmt.Sprintf("Without a decision the run fails after %s.", input.Timeout)
+	return comment
+}
@@ -0,0 +1,63 @@
+package workflows
+
+import (
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// Custom search attributes set on implementation workflows. They must be
+// registered in the namespace before workflows can use them (see
+// `make search-attributes`).
+var (
+	JiraTicketKey = temporal.NewSearchAttributeKeyKeyword("JiraTicket")
+	RepositoryKey = temporal.NewSearchAttributeKeyKeyword("Repository")
+	AssigneeKey   = temporal.NewSearchAttributeKeyKeyword("Assignee")
+	ComplexityKey = temporal.NewSearchAttributeKeyKeyword("Complexity")
+	PRNumberKey   = temporal.NewSearchAttributeKeyInt64("PRNumber")
+	OutcomeKey    = temporal.NewSearchAttributeKeyKeyword("Outcome")
+)
+
+// Values of the Outcome search attribute
+const (
+	OutcomeRunning        = "running"
+	OutcomeAwaitingReview = "awaiting_review"
+	OutcomePROpened       = "pr_opened"
+	OutcomeFailed         = "failed"
+	OutcomeCancelled      = "cancelled"
+)
+
+// InitialSearchAttributes returns the search attributes to start an
+// implementation workflow with
+func InitialSearchAttributes(task *types.Task, plan *types.ImplementationPlan, repo *types.RepositoryInfo) temporal.SearchAttributes {
+	updates := []temporal.SearchAttributeUpdate{
+		JiraTicketKey.ValueSet(task.JiraTicketID),
+		RepositoryKey.ValueSet(repo.Owner + "/" + repo.Name),
+		OutcomeKey.ValueSet(OutcomeRunning),
+	}
+	if task.Assignee != "" {
+		updates = append(updates, AssigneeKey.ValueSet(task.Assignee))
+	}
+	if plan != nil && plan.EstimatedComplexity != "" {
+		updates = append(updates, ComplexityKey.ValueSet(plan.EstimatedComplexity))
+	}
+	return temporal.NewSearchAttributes(updates...)
+}
+
+// setOutcome records the workflow's progress in the Outcome search attribute.
+// Failures are logged; search attributes never fail a run. Runs started
+// before search attributes were introduced are left as they are.
+func setOutcome(ctx workflow.Context, outcome string, updates ...temporal.SearchAttributeUpdate) {
+	if !hasSearchAttributes(ctx) {
+		return
+	}
+	updates = append(updates, OutcomeKey.ValueSet(outcome))
+	if err := workflow.UpsertTypedSearchAttributes(ctx, updates...); err != nil {
+		workflow.GetLogger(ctx).Warn("failed to update search attributes",
+			zap.String("outcome", outcome),
+			zap.Error(err),
+		)
+	}
+}
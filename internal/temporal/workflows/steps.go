@@ -0,0 +1,194 @@
+package workflows
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/activities"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// stepResults accumulates the outcome of every plan step
+type stepResults struct {
+	Codegen     activities.CodeGenerationResult
+	Commits     []types.CommitInfo
+	Deployments []activities.DeploymentResult
+	Reviews     []ReviewDecision
+}
+
+// previewURLs returns the URLs of deployments made so far
+func (r *stepResults) previewURLs() []string {
+	var urls []string
+	for _, d := range r.Deployments {
+		if d.URL != "" {
+			urls = append(urls, d.URL)
+		}
+	}
+	return urls
+}
+
+// runPlanSteps dispatches every plan step in dependency order. Codegen and
+// testing steps run as activities; deployment and review steps run as child
+// workflows. Steps of unknown types are skipped. LLM calls made by the steps
+// are recorded in ledger, and the run stops once it is over budget.
+func runPlanSteps(ctx workflow.Context, input WorkflowInput, repoPath string, progress *progressLog, ledger *usageLedger) (*stepResults, error) {
+	logger := workflow.GetLogger(ctx)
+	results := &stepResults{
+		Codegen: activities.CodeGenerationResult{
+			ModifiedFiles: []string{},
+			CreatedFiles:  []string{},
+			Success:       true,
+		},
+	}
+
+	steps, err := input.Plan.OrderedSteps()
+	if err != nil {
+		return results, temporal.NewNonRetryableApplicationError(err.Error(), "InvalidPlan", err)
+	}
+
+	for _, step := range steps {
+		progress.stepStarted(step.ActivityType, step.Order, step.Description)
+		switch step.ActivityType {
+		case types.StepTypeCodegen:
+			err = runCodegenStep(ctx, input, repoPath, step, results, ledger)
+		case types.StepTypeTesting:
+			err = runTestingStep(ctx, input, repoPath, step, progress, ledger)
+		case types.StepTypeDeployment:
+			err = runDeploymentStep(ctx, input, repoPath, step, results)
+		case types.StepTypeReview:
+			err = runReviewStep(ctx, input, step, results)
+		default:
+			logger.Warn("skipping plan step of unknown type",
+				zap.Int("step", step.Order),
+				zap.String("type", step.ActivityType),
+			)
+		}
+		if err != nil {
+			return results, fmt.Errorf("step %d (%s): %w", step.Order, step.ActivityType, err)
+		}
+		progress.stepCompleted(step.ActivityType, step.Order, step.Description)
+	}
+
+	results.Codegen.Summary = fmt.Sprintf("Generated code for %d files, created %d files", len(results.Codegen.ModifiedFiles), len(results.Codegen.CreatedFiles))
+	if input.CommitPerStep {
+		results.Codegen.Summary += fmt.Sprintf(" in %d commits", len(results.Commits))
+	}
+	return results, nil
+}
+
+// runCodegenStep generates code for a step, committing it on its own when
+// per-step commits are enabled
+func runCodegenStep(ctx workflow.Context, input WorkflowInput, repoPath string, step types.PlanStep, results *stepResults, ledger *usageLedger) error {
+	var a *activities.Activities
+
+	var codegenResult activities.CodeGenerationResult
+	err := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, codegenOptions), a.CodeGenerationActivity, input.Task, input.Plan, step, repoPath).Get(ctx, &codegenResult)
+	if err != nil {
+		return err
+	}
+	results.Codegen.ModifiedFiles = append(results.Codegen.ModifiedFiles, codegenResult.ModifiedFiles...)
+	results.Codegen.CreatedFiles = append(results.Codegen.CreatedFiles, codegenResult.CreatedFiles...)
+	if err := ledger.record(step.Order, types.UsagePhaseCodegen, codegenResult.Usage); err != nil {
+		return err
+	}
+
+	if !input.CommitPerStep {
+		return nil
+	}
+
+	var commitResult activities.GitHubOperationResult
+	commitMessage := generateStepCommitMessage(input.Task, step)
+	err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, localGitOptions), a.CommitChangesActivity, input.Repository, repoPath, commitMessage).Get(ctx, &commitResult)
+	if err != nil {
+		return err
+	}
+	if commitResult.CommitSHA != "" {
+		results.Commits = append(results.Commits, types.CommitInfo{
+			SHA:       commitResult.CommitSHA,
+			Subject:   step.Description,
+			Kind:      types.CommitKindStep,
+			StepOrder: step.Order,
+		})
+	}
+	return nil
+}
+
+// runTestingStep runs the test suite as an intermediate check. Like the final
+// test run, failures are logged rather than stopping the workflow.
+func runTestingStep(ctx workflow.Context, input WorkflowInput, repoPath string, step types.PlanStep, progress *progressLog, ledger *usageLedger) error {
+	var a *activities.Activities
+	logger := workflow.GetLogger(ctx)
+
+	var testResult activities.TestingResult
+	err := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, testingOptions), a.TestingActivity, repoPath, input.Repository.BaseBranch, step.Order).Get(ctx, &testResult)
+	if err != nil {
+		return err
+	}
+	progress.emit(testResultEvent(step.ActivityType, step.Order, &testResult))
+	if err := ledger.record(step.Order, types.UsagePhaseRepair, testResult.Usage); err != nil {
+		return err
+	}
+	if !testResult.Passed {
+		logger.Warn("tests failed at plan step",
+			zap.Int("step", step.Order),
+			zap.Strings("failures", testResult.Failures),
+		)
+	}
+	return nil
+}
+
+// runDeploymentStep deploys the worktree in a child workflow
+func runDeploymentStep(ctx workflow.Context, input WorkflowInput, repoPath string, step types.PlanStep, results *stepResults) error {
+	ctx = workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID: DeploymentWorkflowID(workflow.GetInfo(ctx).WorkflowExecution.ID, step.Order),
+	})
+
+	var deployResult activities.DeploymentResult
+	err := workflow.ExecuteChildWorkflow(ctx, DeploymentWorkflow, DeploymentInput{
+		Task:       input.Task,
+		Repository: input.Repository,
+		RepoPath:   repoPath,
+		Step:       step,
+	}).Get(ctx, &deployResult)
+	if err != nil {
+		return err
+	}
+	results.Deployments = append(results.Deployments, deployResult)
+	return nil
+}
+
+// runReviewStep pauses for a human decision in a child workflow. The step's
+// "timeout" parameter overrides DefaultReviewTimeout.
+func runReviewStep(ctx workflow.Context, input WorkflowInput, step types.PlanStep, results *stepResults) error {
+	timeout := DefaultReviewTimeout
+	if value := step.Parameters["timeout"]; value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return temporal.NewNonRetryableApplicationError(fmt.Sprintf("invalid review timeout %q", value), "InvalidPlan", err)
+		}
+		timeout = parsed
+	}
+
+	ctx = workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID: ReviewWorkflowID(workflow.GetInfo(ctx).WorkflowExecution.ID, step.Order),
+	})
+
+	setOutcome(ctx, OutcomeAwaitingReview)
+	var decision ReviewDecision
+	err := workflow.ExecuteChildWorkflow(ctx, ReviewWorkflow, ReviewInput{
+		Task:        input.Task,
+		Step:        step,
+		Timeout:     timeout,
+		PreviewURLs: results.previewURLs(),
+	}).Get(ctx, &decision)
+	if err != nil {
+		return err
+	}
+	setOutcome(ctx, OutcomeRunning)
+	results.Reviews = append(results.Reviews, decision)
+	return nil
+}
@@ -0,0 +1,112 @@
+package workflows
+
+import (
+	"fmt"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+
+	"github.com/clintrovert/khitomer/internal/usage"
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// UsageMemoKey is the memo field holding a run's types.UsageTotals. It is
+// set when the run starts and updated as LLM calls are made, so open runs
+// can be listed with their spend so far.
+const UsageMemoKey = "usage"
+
+// ErrTypeBudgetExceeded is the error type of runs stopped for exceeding
+// their budget
+const ErrTypeBudgetExceeded = "BudgetExceeded"
+
+// UsagePolicy prices a run's LLM calls and bounds what it may spend
+type UsagePolicy struct {
+	Prices usage.PriceTable
+	// Budget is the most the ticket may spend across its runs, in US
+	// dollars. Zero is unlimited.
+	Budget float64
+	// PriorCost is what the ticket's earlier runs spent, which counts
+	// towards Budget
+	PriorCost float64
+	// DailyAllowance is what was left of the daily budget when the run
+	// started, and the most the run may spend. Zero is unlimited.
+	DailyAllowance float64
+}
+
+// InitialMemo returns the memo to start an implementation workflow with
+func InitialMemo(plan *types.ImplementationPlan) map[string]interface{} {
+	return map[string]interface{}{UsageMemoKey: planUsage(plan)}
+}
+
+// planUsage returns the usage totals of a run before it makes any calls
+func planUsage(plan *types.ImplementationPlan) types.UsageTotals {
+	var totals types.UsageTotals
+	if plan != nil && plan.Usage != nil {
+		totals.Add(*plan.Usage)
+	}
+	return totals
+}
+
+// usageLedger sums a run's LLM usage and enforces its budget. Its totals
+// start with the planning call, which the leader made before the run.
+type usageLedger struct {
+	ctx    workflow.Context
+	policy UsagePolicy
+	totals types.UsageTotals
+}
+
+// newUsageLedger creates a ledger holding the plan's usage
+func newUsageLedger(ctx workflow.Context, input WorkflowInput) *usageLedger {
+	return &usageLedger{
+		ctx:    ctx,
+		policy: input.Usage,
+		totals: planUsage(input.Plan),
+	}
+}
+
+// record prices and adds the calls an activity made for a plan step (0
+// outside plan steps), updates the usage memo and fails once the run is over
+// budget. Calls without a phase are given phase.
+func (l *usageLedger) record(stepOrder int, phase string, calls []types.LLMUsage) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	for _, call := range calls {
+		if call.Phase == "" {
+			call.Phase = phase
+		}
+		call.StepOrder = stepOrder
+		call.Time = workflow.Now(l.ctx)
+		l.policy.Prices.Price(&call)
+		l.totals.Add(call)
+	}
+
+	if hasUsageMemo(l.ctx) {
+		if err := workflow.UpsertMemo(l.ctx, map[string]interface{}{UsageMemoKey: l.totals}); err != nil {
+			workflow.GetLogger(l.ctx).Warn("failed to update usage memo", zap.Error(err))
+		}
+	}
+	return l.checkBudget()
+}
+
+// checkBudget fails if the ticket has spent more than its budget, or the
+// run more than its share of the daily budget
+func (l *usageLedger) checkBudget() error {
+	var err error
+	if spent := l.policy.PriorCost + l.totals.Cost; l.policy.Budget > 0 && spent > l.policy.Budget {
+		err = fmt.Errorf("%w: spent $%.2f of the $%.2f ticket budget", usage.ErrBudgetExceeded, spent, l.policy.Budget)
+	} else if l.policy.DailyAllowance > 0 && l.totals.Cost > l.policy.DailyAllowance {
+		err = fmt.Errorf("%w: spent $%.2f, more than the $%.2f left of the daily budget when the run started", usage.ErrBudgetExceeded, l.totals.Cost, l.policy.DailyAllowance)
+	}
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeBudgetExceeded, err)
+	}
+	return nil
+}
+
+// result returns the run's usage for its result
+func (l *usageLedger) result() *types.UsageTotals {
+	totals := l.totals
+	return &totals
+}
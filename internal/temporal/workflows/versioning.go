@@ -0,0 +1,56 @@
+package workflows
+
+import (
+	"go.temporal.io/sdk/workflow"
+)
+
+// Change IDs for workflow.GetVersion. Workflows are replayed from their
+// history whenever a worker picks them up again, so any change to the
+// commands a workflow issues (adding, removing or reordering activities,
+// child workflows, timers, signals or search attribute upserts) must be
+// guarded with a change ID, or runs started on an older worker fail with a
+// nondeterminism error after a deploy.
+//
+// To make a change, add a constant here and branch on its version, keeping
+// the old code path for workflow.DefaultVersion. Never rename or reuse a
+// change ID. The old path can be removed, and the minimum supported version
+// raised, once no open run can still take it. TestReplayHistories replays the
+// histories in testdata/histories against the current code to catch
+// incompatible changes before they ship.
+const (
+	// searchAttributesChangeID guards the Outcome and PRNumber upserts, which
+	// runs started before search attributes were introduced don't record
+	searchAttributesChangeID = "search-attributes"
+	// usageMemoChangeID guards the usage memo upserts, which runs started
+	// before usage accounting was introduced don't record
+	usageMemoChangeID = "usage-memo"
+	// cleanupReportChangeID guards restoring a failed run's Jira status
+	// before posting the failure comment, which runs started before the
+	// comment reported cleanup results did after it
+	cleanupReportChangeID = "cleanup-report"
+)
+
+// searchAttributesVersion is the current version of searchAttributesChangeID
+const searchAttributesVersion = 1
+
+// usageMemoVersion is the current version of usageMemoChangeID
+const usageMemoVersion = 1
+
+// cleanupReportVersion is the current version of cleanupReportChangeID
+const cleanupReportVersion = 1
+
+// hasSearchAttributes reports whether the run upserts search attributes
+func hasSearchAttributes(ctx workflow.Context) bool {
+	return workflow.GetVersion(ctx, searchAttributesChangeID, workflow.DefaultVersion, searchAttributesVersion) >= searchAttributesVersion
+}
+
+// hasUsageMemo reports whether the run upserts its usage memo
+func hasUsageMemo(ctx workflow.Context) bool {
+	return workflow.GetVersion(ctx, usageMemoChangeID, workflow.DefaultVersion, usageMemoVersion) >= usageMemoVersion
+}
+
+// hasCleanupReport reports whether the run restores its Jira status before
+// posting a failure comment that reports the cleanup results
+func hasCleanupReport(ctx workflow.Context) bool {
+	return workflow.GetVersion(ctx, cleanupReportChangeID, workflow.DefaultVersion, cleanupReportVersion) >= cleanupReportVersion
+}
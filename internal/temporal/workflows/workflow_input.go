@@ -9,5 +9,15 @@ type WorkflowInput struct {
 	Task       *types.Task
 	Plan       *types.ImplementationPlan
 	Repository *types.RepositoryInfo
+	// CommitPerStep commits after each codegen plan step instead of squashing
+	// all generated changes into one commit
+	CommitPerStep bool
+	// Cleanup controls how a failed or cancelled run is cleaned up
+	Cleanup CleanupPolicy
+	// Usage prices the run's LLM calls and bounds what it may spend
+	Usage UsagePolicy
+	// ArtifactsURL lists the workflow's archived artifacts; the pull request
+	// links to it with the run's ID. Empty when artifacts aren't archived.
+	ArtifactsURL string
 }
 
@@ -0,0 +1,12 @@
+package workflows
+
+import (
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// WorkflowResult is the result of the implementation workflow
+type WorkflowResult struct {
+	PRInfo  *types.PRInfo
+	Commits []types.CommitInfo
+	Usage   *types.UsageTotals
+}
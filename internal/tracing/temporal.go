@@ -0,0 +1,23 @@
+package tracing
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.temporal.io/sdk/contrib/opentelemetry"
+	"go.temporal.io/sdk/interceptor"
+)
+
+// NewTemporalInterceptor returns a Temporal interceptor that traces workflow
+// starts, workflows and activities and carries the trace context between
+// them in Temporal headers. Set on the client, it applies to workers created
+// from it too. Spans are not repeated when workflows are replayed.
+func NewTemporalInterceptor() (interceptor.Interceptor, error) {
+	i, err := opentelemetry.NewTracingInterceptor(opentelemetry.TracerOptions{
+		TextMapPropagator: otel.GetTextMapPropagator(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing interceptor: %w", err)
+	}
+	return i, nil
+}
@@ -0,0 +1,142 @@
+// Package tracing sets up OpenTelemetry tracing for the leader and worker.
+//
+// A ticket run is one trace: the leader's ticket span covers planning and the
+// workflow start, and the Temporal tracing interceptor carries the trace
+// through the workflow to the activities on the worker. Jira, GitHub and
+// LLM calls made with a traced context are recorded as child spans.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// Exporters
+const (
+	// ExporterNone disables tracing
+	ExporterNone = ""
+	// ExporterOTLP sends spans to an OTLP gRPC collector
+	ExporterOTLP = "otlp"
+	// ExporterStdout writes spans to stdout as JSON
+	ExporterStdout = "stdout"
+	// ExporterFile writes spans to a file as JSON
+	ExporterFile = "file"
+)
+
+// instrumentationName names the tracer used for Khitomer's own spans
+const instrumentationName = "github.com/clintrovert/khitomer"
+
+// Config configures span export
+type Config struct {
+	// Exporter is one of the Exporter constants
+	Exporter string
+	// Endpoint is the OTLP collector address; empty uses the
+	// OTEL_EXPORTER_OTLP_ENDPOINT variable or localhost:4317
+	Endpoint string
+	// Insecure disables TLS to the OTLP collector
+	Insecure bool
+	// File is the path spans are written to by the file exporter
+	File string
+	// SampleRatio is the fraction of new traces recorded. Traces started
+	// elsewhere follow the caller's sampling decision.
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and propagator for service. The
+// returned function flushes buffered spans and must be called on shutdown.
+// With ExporterNone, spans are not recorded but trace context is still
+// propagated.
+func Setup(ctx context.Context, cfg Config, service string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var closer io.Closer
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var opts []otlptracegrpc.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterFile:
+		var f *os.File
+		f, err = os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		closer = f
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(service)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// Start starts a span for Khitomer's own work
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End records err, if any, on span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TaskAttributes describes a task on a span
+func TaskAttributes(task *types.Task) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("jira.ticket", task.JiraTicketID),
+		attribute.String("repository", task.RepositoryOwner+"/"+task.RepositoryName),
+	}
+}
@@ -0,0 +1,21 @@
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// InstrumentTransport wraps base, or http.DefaultTransport if base is nil,
+// recording a client span named after service for each request and
+// propagating the trace context in its headers
+func InstrumentTransport(service string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return service + " " + r.Method
+		}),
+	)
+}
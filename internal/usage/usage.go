@@ -0,0 +1,76 @@
+// Package usage prices LLM calls and holds the budgets that bound what a
+// ticket run and a day of runs may spend.
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/clintrovert/khitomer/pkg/types"
+)
+
+// ErrBudgetExceeded is returned when a run would exceed a budget
+var ErrBudgetExceeded = errors.New("LLM budget exceeded")
+
+// Price is what a model costs, in US dollars per million tokens
+type Price struct {
+	Prompt     float64
+	Completion float64
+}
+
+// PriceTable maps model names to their prices
+type PriceTable map[string]Price
+
+// ParsePrices parses model:prompt:completion entries, with prices in US
+// dollars per million tokens
+func ParsePrices(entries []string) (PriceTable, error) {
+	table := make(PriceTable, len(entries))
+	for _, entry := range entries {
+		// Model names may contain colons, so prices are taken from the end
+		parts := strings.Split(entry, ":")
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("invalid price %q, want model:prompt:completion", entry)
+		}
+		model := strings.Join(parts[:len(parts)-2], ":")
+		prompt, err := strconv.ParseFloat(parts[len(parts)-2], 64)
+		if err != nil || prompt < 0 {
+			return nil, fmt.Errorf("invalid prompt price in %q", entry)
+		}
+		completion, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+		if err != nil || completion < 0 {
+			return nil, fmt.Errorf("invalid completion price in %q", entry)
+		}
+		if model == "" {
+			return nil, fmt.Errorf("invalid price %q, model is empty", entry)
+		}
+		table[model] = Price{Prompt: prompt, Completion: completion}
+	}
+	return table, nil
+}
+
+// Cost returns the price of a call. Models missing from the table cost
+// nothing; ok reports whether the model was priced.
+func (t PriceTable) Cost(model string, promptTokens, completionTokens int) (cost float64, ok bool) {
+	price, ok := t[model]
+	if !ok {
+		return 0, false
+	}
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1e6, true
+}
+
+// Price sets the cost of a call from its token counts
+func (t PriceTable) Price(usage *types.LLMUsage) {
+	usage.Cost, _ = t.Cost(usage.Model, usage.PromptTokens, usage.CompletionTokens)
+}
+
+// Config configures pricing and budgets. Zero budgets are unlimited.
+type Config struct {
+	Prices PriceTable
+	// TicketBudget is the most one ticket may spend across its runs, in US
+	// dollars
+	TicketBudget float64
+	// DailyBudget is the most all runs may spend in a UTC day, in US dollars
+	DailyBudget float64
+}
@@ -0,0 +1,33 @@
+package types
+
+// CommitMessage describes a generated commit. It is rendered through the
+// worker's commit message template.
+type CommitMessage struct {
+	// Type is the conventional-commit type (feat, fix, ...). When empty it is
+	// derived from IssueType.
+	Type        string
+	IssueType   string
+	JiraKey     string
+	Subject     string
+	Body        string
+	PlanSummary string
+	// CoAuthors are "Name <email>" identities added as Co-authored-by trailers
+	CoAuthors []string
+}
+
+// Commit kinds recorded in CommitInfo
+const (
+	CommitKindSquash = "squash"
+	CommitKindStep   = "step"
+	CommitKindFixup  = "fixup"
+)
+
+// CommitInfo records a commit created by a workflow
+type CommitInfo struct {
+	SHA     string
+	Subject string
+	// Kind is CommitKindSquash, CommitKindStep or CommitKindFixup
+	Kind string
+	// StepOrder is the plan step a CommitKindStep commit implements
+	StepOrder int
+}
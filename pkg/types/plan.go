@@ -1,5 +1,18 @@
 package types
 
+import (
+	"fmt"
+	"sort"
+)
+
+// Plan step activity types
+const (
+	StepTypeCodegen    = "codegen"
+	StepTypeTesting    = "testing"
+	StepTypeDeployment = "deployment"
+	StepTypeReview     = "review"
+)
+
 // ImplementationPlan represents the plan generated by AI
 type ImplementationPlan struct {
 	Summary             string
@@ -7,6 +20,11 @@ type ImplementationPlan struct {
 	FilesToModify       []string
 	FilesToCreate       []string
 	EstimatedComplexity string
+	// Usage is the LLM usage of generating the plan, if it was generated
+	Usage *LLMUsage
+	// Exchange is the prompt and response the plan was generated from. It
+	// is archived by the leader rather than passed to workflows.
+	Exchange *LLMExchange `json:"-"`
 }
 
 // PlanStep represents a single step in the implementation plan
@@ -15,4 +33,61 @@ type PlanStep struct {
 	Description  string
 	ActivityType string // codegen, testing, deployment, review
 	Parameters   map[string]string
+	// DependsOn lists the orders of steps that must complete before this one
+	DependsOn []int
+}
+
+// OrderedSteps returns the plan's steps in execution order: every step comes
+// after the steps it depends on, and otherwise steps run by Order. It fails
+// on duplicate orders, unknown dependencies and dependency cycles.
+func (p *ImplementationPlan) OrderedSteps() ([]PlanStep, error) {
+	steps := append([]PlanStep(nil), p.Steps...)
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].Order < steps[j].Order
+	})
+
+	index := make(map[int]int, len(steps))
+	for i, step := range steps {
+		if _, ok := index[step.Order]; ok {
+			return nil, fmt.Errorf("duplicate plan step order %d", step.Order)
+		}
+		index[step.Order] = i
+	}
+
+	remaining := make([]int, len(steps))
+	dependents := make([][]int, len(steps))
+	for i, step := range steps {
+		for _, dep := range step.DependsOn {
+			j, ok := index[dep]
+			if !ok {
+				return nil, fmt.Errorf("plan step %d depends on unknown step %d", step.Order, dep)
+			}
+			remaining[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	// Repeatedly take the lowest-ordered step whose dependencies are done
+	ordered := make([]PlanStep, 0, len(steps))
+	done := make([]bool, len(steps))
+	for len(ordered) < len(steps) {
+		next := -1
+		for i := range steps {
+			if !done[i] && remaining[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			return nil, fmt.Errorf("plan steps have a dependency cycle")
+		}
+
+		done[next] = true
+		ordered = append(ordered, steps[next])
+		for _, d := range dependents[next] {
+			remaining[d]--
+		}
+	}
+
+	return ordered, nil
 }
@@ -16,4 +16,5 @@ type PRInfo struct {
 	Title       string
 	Description string
 	Status      string
+	Draft       bool
 }
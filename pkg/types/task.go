@@ -10,12 +10,17 @@ type Task struct {
 	Title           string
 	Description     string
 	Status          string
+	IssueType       string
 	Assignee        string
+	AssigneeEmail   string
 	RepositoryOwner string
 	RepositoryName  string
 	RepositoryURL   string
 	BaseBranch      string
 	CreatedAt       time.Time
+	// PlanFeedback is feedback on an earlier plan for the ticket, which
+	// replanning takes into account
+	PlanFeedback string
 }
 
 // ProcessedTask tracks tasks that have been processed
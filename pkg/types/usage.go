@@ -0,0 +1,61 @@
+package types
+
+import "time"
+
+// LLM call phases recorded in LLMUsage
+const (
+	UsagePhasePlan    = "plan"
+	UsagePhaseCodegen = "codegen"
+	UsagePhaseRepair  = "repair"
+)
+
+// LLMUsage records the tokens used by one LLM call
+type LLMUsage struct {
+	// Phase is UsagePhasePlan, UsagePhaseCodegen or UsagePhaseRepair
+	Phase            string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	// Cost is in US dollars, priced from the configured price table
+	Cost float64
+	// StepOrder is the plan step a codegen or repair call belongs to
+	StepOrder int
+	// Time is when the call was recorded, so spend can be attributed to the
+	// day it happened
+	Time time.Time
+}
+
+// UsageTotals sums the LLM usage of a ticket run
+type UsageTotals struct {
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+	Calls            []LLMUsage
+}
+
+// Add records a call in the totals
+func (t *UsageTotals) Add(usage LLMUsage) {
+	t.PromptTokens += usage.PromptTokens
+	t.CompletionTokens += usage.CompletionTokens
+	t.Cost += usage.Cost
+	t.Calls = append(t.Calls, usage)
+}
+
+// TotalTokens returns the prompt and completion tokens used
+func (t *UsageTotals) TotalTokens() int {
+	return t.PromptTokens + t.CompletionTokens
+}
+
+// LLMMessage is one message of an LLM prompt
+type LLMMessage struct {
+	Role    string
+	Content string
+}
+
+// LLMExchange is a prompt sent to an LLM and the response it returned, kept
+// in the run's artifact archive
+type LLMExchange struct {
+	Usage    LLMUsage
+	Messages []LLMMessage
+	Response string
+}
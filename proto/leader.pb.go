@@ -21,12 +21,15 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// The repository defaults to the ticket's. The configured planner is run
+// unless a plan is given.
 type StartWorkflowRequest struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
 	JiraTicketId    string                 `protobuf:"bytes,1,opt,name=jira_ticket_id,json=jiraTicketId,proto3" json:"jira_ticket_id,omitempty"`
 	RepositoryOwner string                 `protobuf:"bytes,2,opt,name=repository_owner,json=repositoryOwner,proto3" json:"repository_owner,omitempty"`
 	RepositoryName  string                 `protobuf:"bytes,3,opt,name=repository_name,json=repositoryName,proto3" json:"repository_name,omitempty"`
 	BaseBranch      string                 `protobuf:"bytes,4,opt,name=base_branch,json=baseBranch,proto3" json:"base_branch,omitempty"`
+	Plan            *ImplementationPlan    `protobuf:"bytes,5,opt,name=plan,proto3" json:"plan,omitempty"`
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
@@ -89,6 +92,13 @@ func (x *StartWorkflowRequest) GetBaseBranch() string {
 	return ""
 }
 
+func (x *StartWorkflowRequest) GetPlan() *ImplementationPlan {
+	if x != nil {
+		return x.Plan
+	}
+	return nil
+}
+
 type StartWorkflowResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	WorkflowId    string                 `protobuf:"bytes,1,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
@@ -529,55 +539,1533 @@ func (x *GetProcessedTasksResponse) GetTotal() int32 {
 	return 0
 }
 
-var File_proto_leader_proto protoreflect.FileDescriptor
+type SubmitReviewRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkflowId    string                 `protobuf:"bytes,1,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	StepOrder     int32                  `protobuf:"varint,2,opt,name=step_order,json=stepOrder,proto3" json:"step_order,omitempty"`
+	Approved      bool                   `protobuf:"varint,3,opt,name=approved,proto3" json:"approved,omitempty"`
+	Reviewer      string                 `protobuf:"bytes,4,opt,name=reviewer,proto3" json:"reviewer,omitempty"`
+	Comment       string                 `protobuf:"bytes,5,opt,name=comment,proto3" json:"comment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_proto_leader_proto_rawDesc = "" +
-	"\n" +
-	"\x12proto/leader.proto\x12\x06leader\"\xb1\x01\n" +
-	"\x14StartWorkflowRequest\x12$\n" +
-	"\x0ejira_ticket_id\x18\x01 \x01(\tR\fjiraTicketId\x12)\n" +
-	"\x10repository_owner\x18\x02 \x01(\tR\x0frepositoryOwner\x12'\n" +
-	"\x0frepository_name\x18\x03 \x01(\tR\x0erepositoryName\x12\x1f\n" +
-	"\vbase_branch\x18\x04 \x01(\tR\n" +
-	"baseBranch\"P\n" +
-	"\x15StartWorkflowResponse\x12\x1f\n" +
-	"\vworkflow_id\x18\x01 \x01(\tR\n" +
-	"workflowId\x12\x16\n" +
-	"\x06status\x18\x02 \x01(\tR\x06status\";\n" +
-	"\x18GetWorkflowStatusRequest\x12\x1f\n" +
-	"\vworkflow_id\x18\x01 \x01(\tR\n" +
-	"workflowId\"\xb6\x01\n" +
-	"\x19GetWorkflowStatusResponse\x12\x1f\n" +
-	"\vworkflow_id\x18\x01 \x01(\tR\n" +
-	"workflowId\x12\x16\n" +
-	"\x06status\x18\x02 \x01(\tR\x06status\x12$\n" +
-	"\x0ejira_ticket_id\x18\x03 \x01(\tR\fjiraTicketId\x12\x15\n" +
-	"\x06pr_url\x18\x04 \x01(\tR\x05prUrl\x12#\n" +
-	"\rerror_message\x18\x05 \x01(\tR\ferrorMessage\"8\n" +
-	"\x15CancelWorkflowRequest\x12\x1f\n" +
-	"\vworkflow_id\x18\x01 \x01(\tR\n" +
-	"workflowId\"L\n" +
-	"\x16CancelWorkflowResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"H\n" +
-	"\x18GetProcessedTasksRequest\x12\x14\n" +
-	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
-	"\x06offset\x18\x02 \x01(\x05R\x06offset\"\x8d\x01\n" +
-	"\rProcessedTask\x12$\n" +
-	"\x0ejira_ticket_id\x18\x01 \x01(\tR\fjiraTicketId\x12\x1f\n" +
-	"\vworkflow_id\x18\x02 \x01(\tR\n" +
-	"workflowId\x12\x16\n" +
-	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1d\n" +
-	"\n" +
-	"created_at\x18\x04 \x01(\tR\tcreatedAt\"^\n" +
-	"\x19GetProcessedTasksResponse\x12+\n" +
-	"\x05tasks\x18\x01 \x03(\v2\x15.leader.ProcessedTaskR\x05tasks\x12\x14\n" +
-	"\x05total\x18\x02 \x01(\x05R\x05total2\xe2\x02\n" +
+func (x *SubmitReviewRequest) Reset() {
+	*x = SubmitReviewRequest{}
+	mi := &file_proto_leader_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitReviewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitReviewRequest) ProtoMessage() {}
+
+func (x *SubmitReviewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitReviewRequest.ProtoReflect.Descriptor instead.
+func (*SubmitReviewRequest) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SubmitReviewRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *SubmitReviewRequest) GetStepOrder() int32 {
+	if x != nil {
+		return x.StepOrder
+	}
+	return 0
+}
+
+func (x *SubmitReviewRequest) GetApproved() bool {
+	if x != nil {
+		return x.Approved
+	}
+	return false
+}
+
+func (x *SubmitReviewRequest) GetReviewer() string {
+	if x != nil {
+		return x.Reviewer
+	}
+	return ""
+}
+
+func (x *SubmitReviewRequest) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+type SubmitReviewResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitReviewResponse) Reset() {
+	*x = SubmitReviewResponse{}
+	mi := &file_proto_leader_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitReviewResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitReviewResponse) ProtoMessage() {}
+
+func (x *SubmitReviewResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitReviewResponse.ProtoReflect.Descriptor instead.
+func (*SubmitReviewResponse) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SubmitReviewResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *SubmitReviewResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ListWorkflowsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JiraTicket    string                 `protobuf:"bytes,1,opt,name=jira_ticket,json=jiraTicket,proto3" json:"jira_ticket,omitempty"`
+	Repository    string                 `protobuf:"bytes,2,opt,name=repository,proto3" json:"repository,omitempty"` // owner/name
+	Assignee      string                 `protobuf:"bytes,3,opt,name=assignee,proto3" json:"assignee,omitempty"`
+	Complexity    string                 `protobuf:"bytes,4,opt,name=complexity,proto3" json:"complexity,omitempty"`
+	Outcome       string                 `protobuf:"bytes,5,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"` // Temporal execution status, e.g. Running
+	PageSize      int32                  `protobuf:"varint,7,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string                 `protobuf:"bytes,8,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorkflowsRequest) Reset() {
+	*x = ListWorkflowsRequest{}
+	mi := &file_proto_leader_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorkflowsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorkflowsRequest) ProtoMessage() {}
+
+func (x *ListWorkflowsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorkflowsRequest.ProtoReflect.Descriptor instead.
+func (*ListWorkflowsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListWorkflowsRequest) GetJiraTicket() string {
+	if x != nil {
+		return x.JiraTicket
+	}
+	return ""
+}
+
+func (x *ListWorkflowsRequest) GetRepository() string {
+	if x != nil {
+		return x.Repository
+	}
+	return ""
+}
+
+func (x *ListWorkflowsRequest) GetAssignee() string {
+	if x != nil {
+		return x.Assignee
+	}
+	return ""
+}
+
+func (x *ListWorkflowsRequest) GetComplexity() string {
+	if x != nil {
+		return x.Complexity
+	}
+	return ""
+}
+
+func (x *ListWorkflowsRequest) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *ListWorkflowsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListWorkflowsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListWorkflowsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type WorkflowSummary struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	WorkflowId       string                 `protobuf:"bytes,1,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	RunId            string                 `protobuf:"bytes,2,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	Status           string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	JiraTicket       string                 `protobuf:"bytes,4,opt,name=jira_ticket,json=jiraTicket,proto3" json:"jira_ticket,omitempty"`
+	Repository       string                 `protobuf:"bytes,5,opt,name=repository,proto3" json:"repository,omitempty"`
+	Assignee         string                 `protobuf:"bytes,6,opt,name=assignee,proto3" json:"assignee,omitempty"`
+	Complexity       string                 `protobuf:"bytes,7,opt,name=complexity,proto3" json:"complexity,omitempty"`
+	PrNumber         int64                  `protobuf:"varint,8,opt,name=pr_number,json=prNumber,proto3" json:"pr_number,omitempty"`
+	Outcome          string                 `protobuf:"bytes,9,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	StartTime        string                 `protobuf:"bytes,10,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	CloseTime        string                 `protobuf:"bytes,11,opt,name=close_time,json=closeTime,proto3" json:"close_time,omitempty"`
+	PromptTokens     int64                  `protobuf:"varint,12,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	CompletionTokens int64                  `protobuf:"varint,13,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	Cost             float64                `protobuf:"fixed64,14,opt,name=cost,proto3" json:"cost,omitempty"` // US dollars
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *WorkflowSummary) Reset() {
+	*x = WorkflowSummary{}
+	mi := &file_proto_leader_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkflowSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkflowSummary) ProtoMessage() {}
+
+func (x *WorkflowSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkflowSummary.ProtoReflect.Descriptor instead.
+func (*WorkflowSummary) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WorkflowSummary) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *WorkflowSummary) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+func (x *WorkflowSummary) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *WorkflowSummary) GetJiraTicket() string {
+	if x != nil {
+		return x.JiraTicket
+	}
+	return ""
+}
+
+func (x *WorkflowSummary) GetRepository() string {
+	if x != nil {
+		return x.Repository
+	}
+	return ""
+}
+
+func (x *WorkflowSummary) GetAssignee() string {
+	if x != nil {
+		return x.Assignee
+	}
+	return ""
+}
+
+func (x *WorkflowSummary) GetComplexity() string {
+	if x != nil {
+		return x.Complexity
+	}
+	return ""
+}
+
+func (x *WorkflowSummary) GetPrNumber() int64 {
+	if x != nil {
+		return x.PrNumber
+	}
+	return 0
+}
+
+func (x *WorkflowSummary) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *WorkflowSummary) GetStartTime() string {
+	if x != nil {
+		return x.StartTime
+	}
+	return ""
+}
+
+func (x *WorkflowSummary) GetCloseTime() string {
+	if x != nil {
+		return x.CloseTime
+	}
+	return ""
+}
+
+func (x *WorkflowSummary) GetPromptTokens() int64 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *WorkflowSummary) GetCompletionTokens() int64 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *WorkflowSummary) GetCost() float64 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+type ListWorkflowsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Workflows     []*WorkflowSummary     `protobuf:"bytes,1,rep,name=workflows,proto3" json:"workflows,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorkflowsResponse) Reset() {
+	*x = ListWorkflowsResponse{}
+	mi := &file_proto_leader_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorkflowsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorkflowsResponse) ProtoMessage() {}
+
+func (x *ListWorkflowsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorkflowsResponse.ProtoReflect.Descriptor instead.
+func (*ListWorkflowsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListWorkflowsResponse) GetWorkflows() []*WorkflowSummary {
+	if x != nil {
+		return x.Workflows
+	}
+	return nil
+}
+
+func (x *ListWorkflowsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type WatchWorkflowRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkflowId    string                 `protobuf:"bytes,1,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	LastEventId   int64                  `protobuf:"varint,2,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"` // resume after this event; 0 streams all events
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchWorkflowRequest) Reset() {
+	*x = WatchWorkflowRequest{}
+	mi := &file_proto_leader_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchWorkflowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchWorkflowRequest) ProtoMessage() {}
+
+func (x *WatchWorkflowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchWorkflowRequest.ProtoReflect.Descriptor instead.
+func (*WatchWorkflowRequest) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *WatchWorkflowRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *WatchWorkflowRequest) GetLastEventId() int64 {
+	if x != nil {
+		return x.LastEventId
+	}
+	return 0
+}
+
+type WorkflowEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Time          string                 `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	Step          string                 `protobuf:"bytes,4,opt,name=step,proto3" json:"step,omitempty"`
+	StepOrder     int32                  `protobuf:"varint,5,opt,name=step_order,json=stepOrder,proto3" json:"step_order,omitempty"`
+	Message       string                 `protobuf:"bytes,6,opt,name=message,proto3" json:"message,omitempty"`
+	Data          map[string]string      `protobuf:"bytes,7,rep,name=data,proto3" json:"data,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkflowEvent) Reset() {
+	*x = WorkflowEvent{}
+	mi := &file_proto_leader_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkflowEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkflowEvent) ProtoMessage() {}
+
+func (x *WorkflowEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkflowEvent.ProtoReflect.Descriptor instead.
+func (*WorkflowEvent) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WorkflowEvent) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *WorkflowEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *WorkflowEvent) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *WorkflowEvent) GetStep() string {
+	if x != nil {
+		return x.Step
+	}
+	return ""
+}
+
+func (x *WorkflowEvent) GetStepOrder() int32 {
+	if x != nil {
+		return x.StepOrder
+	}
+	return 0
+}
+
+func (x *WorkflowEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *WorkflowEvent) GetData() map[string]string {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// A ticket is identified by jira_ticket_id, or inline by title and
+// repository. Repository fields override the ticket's.
+type PreviewPlanRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	JiraTicketId    string                 `protobuf:"bytes,1,opt,name=jira_ticket_id,json=jiraTicketId,proto3" json:"jira_ticket_id,omitempty"`
+	Title           string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Description     string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	RepositoryOwner string                 `protobuf:"bytes,4,opt,name=repository_owner,json=repositoryOwner,proto3" json:"repository_owner,omitempty"`
+	RepositoryName  string                 `protobuf:"bytes,5,opt,name=repository_name,json=repositoryName,proto3" json:"repository_name,omitempty"`
+	BaseBranch      string                 `protobuf:"bytes,6,opt,name=base_branch,json=baseBranch,proto3" json:"base_branch,omitempty"`
+	IncludeDiff     bool                   `protobuf:"varint,7,opt,name=include_diff,json=includeDiff,proto3" json:"include_diff,omitempty"` // also run codegen in a throwaway workspace
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PreviewPlanRequest) Reset() {
+	*x = PreviewPlanRequest{}
+	mi := &file_proto_leader_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreviewPlanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewPlanRequest) ProtoMessage() {}
+
+func (x *PreviewPlanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewPlanRequest.ProtoReflect.Descriptor instead.
+func (*PreviewPlanRequest) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *PreviewPlanRequest) GetJiraTicketId() string {
+	if x != nil {
+		return x.JiraTicketId
+	}
+	return ""
+}
+
+func (x *PreviewPlanRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *PreviewPlanRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *PreviewPlanRequest) GetRepositoryOwner() string {
+	if x != nil {
+		return x.RepositoryOwner
+	}
+	return ""
+}
+
+func (x *PreviewPlanRequest) GetRepositoryName() string {
+	if x != nil {
+		return x.RepositoryName
+	}
+	return ""
+}
+
+func (x *PreviewPlanRequest) GetBaseBranch() string {
+	if x != nil {
+		return x.BaseBranch
+	}
+	return ""
+}
+
+func (x *PreviewPlanRequest) GetIncludeDiff() bool {
+	if x != nil {
+		return x.IncludeDiff
+	}
+	return false
+}
+
+type PreviewPlanResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Task  *TaskMetadata          `protobuf:"bytes,1,opt,name=task,proto3" json:"task,omitempty"`
+	Plan  *ImplementationPlan    `protobuf:"bytes,2,opt,name=plan,proto3" json:"plan,omitempty"`
+	// Set only when include_diff was requested
+	Codegen       *CodeGenerationOutput `protobuf:"bytes,3,opt,name=codegen,proto3" json:"codegen,omitempty"`
+	Diff          string                `protobuf:"bytes,4,opt,name=diff,proto3" json:"diff,omitempty"`
+	DiffTruncated bool                  `protobuf:"varint,5,opt,name=diff_truncated,json=diffTruncated,proto3" json:"diff_truncated,omitempty"`
+	FilesChanged  int32                 `protobuf:"varint,6,opt,name=files_changed,json=filesChanged,proto3" json:"files_changed,omitempty"`
+	// LLM usage of planning and, with the diff, code generation
+	Usage         *LLMUsageTotals `protobuf:"bytes,7,opt,name=usage,proto3" json:"usage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PreviewPlanResponse) Reset() {
+	*x = PreviewPlanResponse{}
+	mi := &file_proto_leader_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreviewPlanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewPlanResponse) ProtoMessage() {}
+
+func (x *PreviewPlanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewPlanResponse.ProtoReflect.Descriptor instead.
+func (*PreviewPlanResponse) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *PreviewPlanResponse) GetTask() *TaskMetadata {
+	if x != nil {
+		return x.Task
+	}
+	return nil
+}
+
+func (x *PreviewPlanResponse) GetPlan() *ImplementationPlan {
+	if x != nil {
+		return x.Plan
+	}
+	return nil
+}
+
+func (x *PreviewPlanResponse) GetCodegen() *CodeGenerationOutput {
+	if x != nil {
+		return x.Codegen
+	}
+	return nil
+}
+
+func (x *PreviewPlanResponse) GetDiff() string {
+	if x != nil {
+		return x.Diff
+	}
+	return ""
+}
+
+func (x *PreviewPlanResponse) GetDiffTruncated() bool {
+	if x != nil {
+		return x.DiffTruncated
+	}
+	return false
+}
+
+func (x *PreviewPlanResponse) GetFilesChanged() int32 {
+	if x != nil {
+		return x.FilesChanged
+	}
+	return 0
+}
+
+func (x *PreviewPlanResponse) GetUsage() *LLMUsageTotals {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+type GetWorkflowUsageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkflowId    string                 `protobuf:"bytes,1,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWorkflowUsageRequest) Reset() {
+	*x = GetWorkflowUsageRequest{}
+	mi := &file_proto_leader_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWorkflowUsageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWorkflowUsageRequest) ProtoMessage() {}
+
+func (x *GetWorkflowUsageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWorkflowUsageRequest.ProtoReflect.Descriptor instead.
+func (*GetWorkflowUsageRequest) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetWorkflowUsageRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+// One LLM call made for a ticket
+type LLMUsage struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Phase            string                 `protobuf:"bytes,1,opt,name=phase,proto3" json:"phase,omitempty"` // plan, codegen or repair
+	Model            string                 `protobuf:"bytes,2,opt,name=model,proto3" json:"model,omitempty"`
+	PromptTokens     int64                  `protobuf:"varint,3,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	CompletionTokens int64                  `protobuf:"varint,4,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	Cost             float64                `protobuf:"fixed64,5,opt,name=cost,proto3" json:"cost,omitempty"`                           // US dollars
+	StepOrder        int32                  `protobuf:"varint,6,opt,name=step_order,json=stepOrder,proto3" json:"step_order,omitempty"` // plan step of codegen and repair calls
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *LLMUsage) Reset() {
+	*x = LLMUsage{}
+	mi := &file_proto_leader_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LLMUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LLMUsage) ProtoMessage() {}
+
+func (x *LLMUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LLMUsage.ProtoReflect.Descriptor instead.
+func (*LLMUsage) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *LLMUsage) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *LLMUsage) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *LLMUsage) GetPromptTokens() int64 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *LLMUsage) GetCompletionTokens() int64 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *LLMUsage) GetCost() float64 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+func (x *LLMUsage) GetStepOrder() int32 {
+	if x != nil {
+		return x.StepOrder
+	}
+	return 0
+}
+
+type LLMUsageTotals struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	PromptTokens     int64                  `protobuf:"varint,1,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	CompletionTokens int64                  `protobuf:"varint,2,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	Cost             float64                `protobuf:"fixed64,3,opt,name=cost,proto3" json:"cost,omitempty"` // US dollars
+	Calls            []*LLMUsage            `protobuf:"bytes,4,rep,name=calls,proto3" json:"calls,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *LLMUsageTotals) Reset() {
+	*x = LLMUsageTotals{}
+	mi := &file_proto_leader_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LLMUsageTotals) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LLMUsageTotals) ProtoMessage() {}
+
+func (x *LLMUsageTotals) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LLMUsageTotals.ProtoReflect.Descriptor instead.
+func (*LLMUsageTotals) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *LLMUsageTotals) GetPromptTokens() int64 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *LLMUsageTotals) GetCompletionTokens() int64 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *LLMUsageTotals) GetCost() float64 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+func (x *LLMUsageTotals) GetCalls() []*LLMUsage {
+	if x != nil {
+		return x.Calls
+	}
+	return nil
+}
+
+type GetUsageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsageRequest) Reset() {
+	*x = GetUsageRequest{}
+	mi := &file_proto_leader_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsageRequest) ProtoMessage() {}
+
+func (x *GetUsageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsageRequest.ProtoReflect.Descriptor instead.
+func (*GetUsageRequest) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{21}
+}
+
+type GetUsageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SpentToday    float64                `protobuf:"fixed64,1,opt,name=spent_today,json=spentToday,proto3" json:"spent_today,omitempty"`       // US dollars since midnight UTC
+	DailyBudget   float64                `protobuf:"fixed64,2,opt,name=daily_budget,json=dailyBudget,proto3" json:"daily_budget,omitempty"`    // 0 is unlimited
+	TicketBudget  float64                `protobuf:"fixed64,3,opt,name=ticket_budget,json=ticketBudget,proto3" json:"ticket_budget,omitempty"` // 0 is unlimited
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsageResponse) Reset() {
+	*x = GetUsageResponse{}
+	mi := &file_proto_leader_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsageResponse) ProtoMessage() {}
+
+func (x *GetUsageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsageResponse.ProtoReflect.Descriptor instead.
+func (*GetUsageResponse) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GetUsageResponse) GetSpentToday() float64 {
+	if x != nil {
+		return x.SpentToday
+	}
+	return 0
+}
+
+func (x *GetUsageResponse) GetDailyBudget() float64 {
+	if x != nil {
+		return x.DailyBudget
+	}
+	return 0
+}
+
+func (x *GetUsageResponse) GetTicketBudget() float64 {
+	if x != nil {
+		return x.TicketBudget
+	}
+	return 0
+}
+
+// An empty run_id selects the workflow's latest run
+type ListArtifactsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkflowId    string                 `protobuf:"bytes,1,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	RunId         string                 `protobuf:"bytes,2,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListArtifactsRequest) Reset() {
+	*x = ListArtifactsRequest{}
+	mi := &file_proto_leader_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListArtifactsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListArtifactsRequest) ProtoMessage() {}
+
+func (x *ListArtifactsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListArtifactsRequest.ProtoReflect.Descriptor instead.
+func (*ListArtifactsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ListArtifactsRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *ListArtifactsRequest) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+type Artifact struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // path within the run, e.g. diffs/step-01-attempt-1.diff
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	ModifiedAt    string                 `protobuf:"bytes,3,opt,name=modified_at,json=modifiedAt,proto3" json:"modified_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Artifact) Reset() {
+	*x = Artifact{}
+	mi := &file_proto_leader_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Artifact) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Artifact) ProtoMessage() {}
+
+func (x *Artifact) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Artifact.ProtoReflect.Descriptor instead.
+func (*Artifact) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *Artifact) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Artifact) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Artifact) GetModifiedAt() string {
+	if x != nil {
+		return x.ModifiedAt
+	}
+	return ""
+}
+
+type ListArtifactsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkflowId    string                 `protobuf:"bytes,1,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	RunId         string                 `protobuf:"bytes,2,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	Artifacts     []*Artifact            `protobuf:"bytes,3,rep,name=artifacts,proto3" json:"artifacts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListArtifactsResponse) Reset() {
+	*x = ListArtifactsResponse{}
+	mi := &file_proto_leader_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListArtifactsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListArtifactsResponse) ProtoMessage() {}
+
+func (x *ListArtifactsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListArtifactsResponse.ProtoReflect.Descriptor instead.
+func (*ListArtifactsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ListArtifactsResponse) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *ListArtifactsResponse) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+func (x *ListArtifactsResponse) GetArtifacts() []*Artifact {
+	if x != nil {
+		return x.Artifacts
+	}
+	return nil
+}
+
+// An empty run_id selects the workflow's latest run
+type GetArtifactRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkflowId    string                 `protobuf:"bytes,1,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	RunId         string                 `protobuf:"bytes,2,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetArtifactRequest) Reset() {
+	*x = GetArtifactRequest{}
+	mi := &file_proto_leader_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetArtifactRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetArtifactRequest) ProtoMessage() {}
+
+func (x *GetArtifactRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetArtifactRequest.ProtoReflect.Descriptor instead.
+func (*GetArtifactRequest) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *GetArtifactRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *GetArtifactRequest) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+func (x *GetArtifactRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type GetArtifactResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ContentType   string                 `protobuf:"bytes,1,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Content       []byte                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetArtifactResponse) Reset() {
+	*x = GetArtifactResponse{}
+	mi := &file_proto_leader_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetArtifactResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetArtifactResponse) ProtoMessage() {}
+
+func (x *GetArtifactResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_leader_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetArtifactResponse.ProtoReflect.Descriptor instead.
+func (*GetArtifactResponse) Descriptor() ([]byte, []int) {
+	return file_proto_leader_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *GetArtifactResponse) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *GetArtifactResponse) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+var File_proto_leader_proto protoreflect.FileDescriptor
+
+const file_proto_leader_proto_rawDesc = "" +
+	"\n" +
+	"\x12proto/leader.proto\x12\x06leader\x1a\x15proto/workflows.proto\"\xe4\x01\n" +
+	"\x14StartWorkflowRequest\x12$\n" +
+	"\x0ejira_ticket_id\x18\x01 \x01(\tR\fjiraTicketId\x12)\n" +
+	"\x10repository_owner\x18\x02 \x01(\tR\x0frepositoryOwner\x12'\n" +
+	"\x0frepository_name\x18\x03 \x01(\tR\x0erepositoryName\x12\x1f\n" +
+	"\vbase_branch\x18\x04 \x01(\tR\n" +
+	"baseBranch\x121\n" +
+	"\x04plan\x18\x05 \x01(\v2\x1d.workflows.ImplementationPlanR\x04plan\"P\n" +
+	"\x15StartWorkflowResponse\x12\x1f\n" +
+	"\vworkflow_id\x18\x01 \x01(\tR\n" +
+	"workflowId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\";\n" +
+	"\x18GetWorkflowStatusRequest\x12\x1f\n" +
+	"\vworkflow_id\x18\x01 \x01(\tR\n" +
+	"workflowId\"\xb6\x01\n" +
+	"\x19GetWorkflowStatusResponse\x12\x1f\n" +
+	"\vworkflow_id\x18\x01 \x01(\tR\n" +
+	"workflowId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12$\n" +
+	"\x0ejira_ticket_id\x18\x03 \x01(\tR\fjiraTicketId\x12\x15\n" +
+	"\x06pr_url\x18\x04 \x01(\tR\x05prUrl\x12#\n" +
+	"\rerror_message\x18\x05 \x01(\tR\ferrorMessage\"8\n" +
+	"\x15CancelWorkflowRequest\x12\x1f\n" +
+	"\vworkflow_id\x18\x01 \x01(\tR\n" +
+	"workflowId\"L\n" +
+	"\x16CancelWorkflowResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"H\n" +
+	"\x18GetProcessedTasksRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\"\x8d\x01\n" +
+	"\rProcessedTask\x12$\n" +
+	"\x0ejira_ticket_id\x18\x01 \x01(\tR\fjiraTicketId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\tR\tcreatedAt\"^\n" +
+	"\x19GetProcessedTasksResponse\x12+\n" +
+	"\x05tasks\x18\x01 \x03(\v2\x15.leader.ProcessedTaskR\x05tasks\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\"\xa7\x01\n" +
+	"\x13SubmitReviewRequest\x12\x1f\n" +
+	"\vworkflow_id\x18\x01 \x01(\tR\n" +
+	"workflowId\x12\x1d\n" +
+	"\n" +
+	"step_order\x18\x02 \x01(\x05R\tstepOrder\x12\x1a\n" +
+	"\bapproved\x18\x03 \x01(\bR\bapproved\x12\x1a\n" +
+	"\breviewer\x18\x04 \x01(\tR\breviewer\x12\x18\n" +
+	"\acomment\x18\x05 \x01(\tR\acomment\"J\n" +
+	"\x14SubmitReviewResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\x81\x02\n" +
+	"\x14ListWorkflowsRequest\x12\x1f\n" +
+	"\vjira_ticket\x18\x01 \x01(\tR\n" +
+	"jiraTicket\x12\x1e\n" +
+	"\n" +
+	"repository\x18\x02 \x01(\tR\n" +
+	"repository\x12\x1a\n" +
+	"\bassignee\x18\x03 \x01(\tR\bassignee\x12\x1e\n" +
+	"\n" +
+	"complexity\x18\x04 \x01(\tR\n" +
+	"complexity\x12\x18\n" +
+	"\aoutcome\x18\x05 \x01(\tR\aoutcome\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x12\x1b\n" +
+	"\tpage_size\x18\a \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\b \x01(\tR\tpageToken\"\xb9\x03\n" +
+	"\x0fWorkflowSummary\x12\x1f\n" +
+	"\vworkflow_id\x18\x01 \x01(\tR\n" +
+	"workflowId\x12\x15\n" +
+	"\x06run_id\x18\x02 \x01(\tR\x05runId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1f\n" +
+	"\vjira_ticket\x18\x04 \x01(\tR\n" +
+	"jiraTicket\x12\x1e\n" +
+	"\n" +
+	"repository\x18\x05 \x01(\tR\n" +
+	"repository\x12\x1a\n" +
+	"\bassignee\x18\x06 \x01(\tR\bassignee\x12\x1e\n" +
+	"\n" +
+	"complexity\x18\a \x01(\tR\n" +
+	"complexity\x12\x1b\n" +
+	"\tpr_number\x18\b \x01(\x03R\bprNumber\x12\x18\n" +
+	"\aoutcome\x18\t \x01(\tR\aoutcome\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\n" +
+	" \x01(\tR\tstartTime\x12\x1d\n" +
+	"\n" +
+	"close_time\x18\v \x01(\tR\tcloseTime\x12#\n" +
+	"\rprompt_tokens\x18\f \x01(\x03R\fpromptTokens\x12+\n" +
+	"\x11completion_tokens\x18\r \x01(\x03R\x10completionTokens\x12\x12\n" +
+	"\x04cost\x18\x0e \x01(\x01R\x04cost\"v\n" +
+	"\x15ListWorkflowsResponse\x125\n" +
+	"\tworkflows\x18\x01 \x03(\v2\x17.leader.WorkflowSummaryR\tworkflows\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"[\n" +
+	"\x14WatchWorkflowRequest\x12\x1f\n" +
+	"\vworkflow_id\x18\x01 \x01(\tR\n" +
+	"workflowId\x12\"\n" +
+	"\rlast_event_id\x18\x02 \x01(\x03R\vlastEventId\"\x82\x02\n" +
+	"\rWorkflowEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x12\n" +
+	"\x04time\x18\x03 \x01(\tR\x04time\x12\x12\n" +
+	"\x04step\x18\x04 \x01(\tR\x04step\x12\x1d\n" +
+	"\n" +
+	"step_order\x18\x05 \x01(\x05R\tstepOrder\x12\x18\n" +
+	"\amessage\x18\x06 \x01(\tR\amessage\x123\n" +
+	"\x04data\x18\a \x03(\v2\x1f.leader.WorkflowEvent.DataEntryR\x04data\x1a7\n" +
+	"\tDataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x8a\x02\n" +
+	"\x12PreviewPlanRequest\x12$\n" +
+	"\x0ejira_ticket_id\x18\x01 \x01(\tR\fjiraTicketId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12)\n" +
+	"\x10repository_owner\x18\x04 \x01(\tR\x0frepositoryOwner\x12'\n" +
+	"\x0frepository_name\x18\x05 \x01(\tR\x0erepositoryName\x12\x1f\n" +
+	"\vbase_branch\x18\x06 \x01(\tR\n" +
+	"baseBranch\x12!\n" +
+	"\finclude_diff\x18\a \x01(\bR\vincludeDiff\"\xbe\x02\n" +
+	"\x13PreviewPlanResponse\x12+\n" +
+	"\x04task\x18\x01 \x01(\v2\x17.workflows.TaskMetadataR\x04task\x121\n" +
+	"\x04plan\x18\x02 \x01(\v2\x1d.workflows.ImplementationPlanR\x04plan\x129\n" +
+	"\acodegen\x18\x03 \x01(\v2\x1f.workflows.CodeGenerationOutputR\acodegen\x12\x12\n" +
+	"\x04diff\x18\x04 \x01(\tR\x04diff\x12%\n" +
+	"\x0ediff_truncated\x18\x05 \x01(\bR\rdiffTruncated\x12#\n" +
+	"\rfiles_changed\x18\x06 \x01(\x05R\ffilesChanged\x12,\n" +
+	"\x05usage\x18\a \x01(\v2\x16.leader.LLMUsageTotalsR\x05usage\":\n" +
+	"\x17GetWorkflowUsageRequest\x12\x1f\n" +
+	"\vworkflow_id\x18\x01 \x01(\tR\n" +
+	"workflowId\"\xbb\x01\n" +
+	"\bLLMUsage\x12\x14\n" +
+	"\x05phase\x18\x01 \x01(\tR\x05phase\x12\x14\n" +
+	"\x05model\x18\x02 \x01(\tR\x05model\x12#\n" +
+	"\rprompt_tokens\x18\x03 \x01(\x03R\fpromptTokens\x12+\n" +
+	"\x11completion_tokens\x18\x04 \x01(\x03R\x10completionTokens\x12\x12\n" +
+	"\x04cost\x18\x05 \x01(\x01R\x04cost\x12\x1d\n" +
+	"\n" +
+	"step_order\x18\x06 \x01(\x05R\tstepOrder\"\x9e\x01\n" +
+	"\x0eLLMUsageTotals\x12#\n" +
+	"\rprompt_tokens\x18\x01 \x01(\x03R\fpromptTokens\x12+\n" +
+	"\x11completion_tokens\x18\x02 \x01(\x03R\x10completionTokens\x12\x12\n" +
+	"\x04cost\x18\x03 \x01(\x01R\x04cost\x12&\n" +
+	"\x05calls\x18\x04 \x03(\v2\x10.leader.LLMUsageR\x05calls\"\x11\n" +
+	"\x0fGetUsageRequest\"{\n" +
+	"\x10GetUsageResponse\x12\x1f\n" +
+	"\vspent_today\x18\x01 \x01(\x01R\n" +
+	"spentToday\x12!\n" +
+	"\fdaily_budget\x18\x02 \x01(\x01R\vdailyBudget\x12#\n" +
+	"\rticket_budget\x18\x03 \x01(\x01R\fticketBudget\"N\n" +
+	"\x14ListArtifactsRequest\x12\x1f\n" +
+	"\vworkflow_id\x18\x01 \x01(\tR\n" +
+	"workflowId\x12\x15\n" +
+	"\x06run_id\x18\x02 \x01(\tR\x05runId\"S\n" +
+	"\bArtifact\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x1f\n" +
+	"\vmodified_at\x18\x03 \x01(\tR\n" +
+	"modifiedAt\"\x7f\n" +
+	"\x15ListArtifactsResponse\x12\x1f\n" +
+	"\vworkflow_id\x18\x01 \x01(\tR\n" +
+	"workflowId\x12\x15\n" +
+	"\x06run_id\x18\x02 \x01(\tR\x05runId\x12.\n" +
+	"\tartifacts\x18\x03 \x03(\v2\x10.leader.ArtifactR\tartifacts\"`\n" +
+	"\x12GetArtifactRequest\x12\x1f\n" +
+	"\vworkflow_id\x18\x01 \x01(\tR\n" +
+	"workflowId\x12\x15\n" +
+	"\x06run_id\x18\x02 \x01(\tR\x05runId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\"R\n" +
+	"\x13GetArtifactResponse\x12!\n" +
+	"\fcontent_type\x18\x01 \x01(\tR\vcontentType\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\fR\acontent2\xad\a\n" +
 	"\rLeaderService\x12L\n" +
 	"\rStartWorkflow\x12\x1c.leader.StartWorkflowRequest\x1a\x1d.leader.StartWorkflowResponse\x12X\n" +
 	"\x11GetWorkflowStatus\x12 .leader.GetWorkflowStatusRequest\x1a!.leader.GetWorkflowStatusResponse\x12O\n" +
 	"\x0eCancelWorkflow\x12\x1d.leader.CancelWorkflowRequest\x1a\x1e.leader.CancelWorkflowResponse\x12X\n" +
-	"\x11GetProcessedTasks\x12 .leader.GetProcessedTasksRequest\x1a!.leader.GetProcessedTasksResponseB'Z%github.com/clintrovert/khitomer/protob\x06proto3"
+	"\x11GetProcessedTasks\x12 .leader.GetProcessedTasksRequest\x1a!.leader.GetProcessedTasksResponse\x12I\n" +
+	"\fSubmitReview\x12\x1b.leader.SubmitReviewRequest\x1a\x1c.leader.SubmitReviewResponse\x12L\n" +
+	"\rListWorkflows\x12\x1c.leader.ListWorkflowsRequest\x1a\x1d.leader.ListWorkflowsResponse\x12F\n" +
+	"\rWatchWorkflow\x12\x1c.leader.WatchWorkflowRequest\x1a\x15.leader.WorkflowEvent0\x01\x12F\n" +
+	"\vPreviewPlan\x12\x1a.leader.PreviewPlanRequest\x1a\x1b.leader.PreviewPlanResponse\x12K\n" +
+	"\x10GetWorkflowUsage\x12\x1f.leader.GetWorkflowUsageRequest\x1a\x16.leader.LLMUsageTotals\x12=\n" +
+	"\bGetUsage\x12\x17.leader.GetUsageRequest\x1a\x18.leader.GetUsageResponse\x12L\n" +
+	"\rListArtifacts\x12\x1c.leader.ListArtifactsRequest\x1a\x1d.leader.ListArtifactsResponse\x12F\n" +
+	"\vGetArtifact\x12\x1a.leader.GetArtifactRequest\x1a\x1b.leader.GetArtifactResponseB'Z%github.com/clintrovert/khitomer/protob\x06proto3"
 
 var (
 	file_proto_leader_proto_rawDescOnce sync.Once
@@ -591,7 +2079,7 @@ func file_proto_leader_proto_rawDescGZIP() []byte {
 	return file_proto_leader_proto_rawDescData
 }
 
-var file_proto_leader_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_proto_leader_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
 var file_proto_leader_proto_goTypes = []any{
 	(*StartWorkflowRequest)(nil),      // 0: leader.StartWorkflowRequest
 	(*StartWorkflowResponse)(nil),     // 1: leader.StartWorkflowResponse
@@ -602,22 +2090,70 @@ var file_proto_leader_proto_goTypes = []any{
 	(*GetProcessedTasksRequest)(nil),  // 6: leader.GetProcessedTasksRequest
 	(*ProcessedTask)(nil),             // 7: leader.ProcessedTask
 	(*GetProcessedTasksResponse)(nil), // 8: leader.GetProcessedTasksResponse
+	(*SubmitReviewRequest)(nil),       // 9: leader.SubmitReviewRequest
+	(*SubmitReviewResponse)(nil),      // 10: leader.SubmitReviewResponse
+	(*ListWorkflowsRequest)(nil),      // 11: leader.ListWorkflowsRequest
+	(*WorkflowSummary)(nil),           // 12: leader.WorkflowSummary
+	(*ListWorkflowsResponse)(nil),     // 13: leader.ListWorkflowsResponse
+	(*WatchWorkflowRequest)(nil),      // 14: leader.WatchWorkflowRequest
+	(*WorkflowEvent)(nil),             // 15: leader.WorkflowEvent
+	(*PreviewPlanRequest)(nil),        // 16: leader.PreviewPlanRequest
+	(*PreviewPlanResponse)(nil),       // 17: leader.PreviewPlanResponse
+	(*GetWorkflowUsageRequest)(nil),   // 18: leader.GetWorkflowUsageRequest
+	(*LLMUsage)(nil),                  // 19: leader.LLMUsage
+	(*LLMUsageTotals)(nil),            // 20: leader.LLMUsageTotals
+	(*GetUsageRequest)(nil),           // 21: leader.GetUsageRequest
+	(*GetUsageResponse)(nil),          // 22: leader.GetUsageResponse
+	(*ListArtifactsRequest)(nil),      // 23: leader.ListArtifactsRequest
+	(*Artifact)(nil),                  // 24: leader.Artifact
+	(*ListArtifactsResponse)(nil),     // 25: leader.ListArtifactsResponse
+	(*GetArtifactRequest)(nil),        // 26: leader.GetArtifactRequest
+	(*GetArtifactResponse)(nil),       // 27: leader.GetArtifactResponse
+	nil,                               // 28: leader.WorkflowEvent.DataEntry
+	(*ImplementationPlan)(nil),        // 29: workflows.ImplementationPlan
+	(*TaskMetadata)(nil),              // 30: workflows.TaskMetadata
+	(*CodeGenerationOutput)(nil),      // 31: workflows.CodeGenerationOutput
 }
 var file_proto_leader_proto_depIdxs = []int32{
-	7, // 0: leader.GetProcessedTasksResponse.tasks:type_name -> leader.ProcessedTask
-	0, // 1: leader.LeaderService.StartWorkflow:input_type -> leader.StartWorkflowRequest
-	2, // 2: leader.LeaderService.GetWorkflowStatus:input_type -> leader.GetWorkflowStatusRequest
-	4, // 3: leader.LeaderService.CancelWorkflow:input_type -> leader.CancelWorkflowRequest
-	6, // 4: leader.LeaderService.GetProcessedTasks:input_type -> leader.GetProcessedTasksRequest
-	1, // 5: leader.LeaderService.StartWorkflow:output_type -> leader.StartWorkflowResponse
-	3, // 6: leader.LeaderService.GetWorkflowStatus:output_type -> leader.GetWorkflowStatusResponse
-	5, // 7: leader.LeaderService.CancelWorkflow:output_type -> leader.CancelWorkflowResponse
-	8, // 8: leader.LeaderService.GetProcessedTasks:output_type -> leader.GetProcessedTasksResponse
-	5, // [5:9] is the sub-list for method output_type
-	1, // [1:5] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	29, // 0: leader.StartWorkflowRequest.plan:type_name -> workflows.ImplementationPlan
+	7,  // 1: leader.GetProcessedTasksResponse.tasks:type_name -> leader.ProcessedTask
+	12, // 2: leader.ListWorkflowsResponse.workflows:type_name -> leader.WorkflowSummary
+	28, // 3: leader.WorkflowEvent.data:type_name -> leader.WorkflowEvent.DataEntry
+	30, // 4: leader.PreviewPlanResponse.task:type_name -> workflows.TaskMetadata
+	29, // 5: leader.PreviewPlanResponse.plan:type_name -> workflows.ImplementationPlan
+	31, // 6: leader.PreviewPlanResponse.codegen:type_name -> workflows.CodeGenerationOutput
+	20, // 7: leader.PreviewPlanResponse.usage:type_name -> leader.LLMUsageTotals
+	19, // 8: leader.LLMUsageTotals.calls:type_name -> leader.LLMUsage
+	24, // 9: leader.ListArtifactsResponse.artifacts:type_name -> leader.Artifact
+	0,  // 10: leader.LeaderService.StartWorkflow:input_type -> leader.StartWorkflowRequest
+	2,  // 11: leader.LeaderService.GetWorkflowStatus:input_type -> leader.GetWorkflowStatusRequest
+	4,  // 12: leader.LeaderService.CancelWorkflow:input_type -> leader.CancelWorkflowRequest
+	6,  // 13: leader.LeaderService.GetProcessedTasks:input_type -> leader.GetProcessedTasksRequest
+	9,  // 14: leader.LeaderService.SubmitReview:input_type -> leader.SubmitReviewRequest
+	11, // 15: leader.LeaderService.ListWorkflows:input_type -> leader.ListWorkflowsRequest
+	14, // 16: leader.LeaderService.WatchWorkflow:input_type -> leader.WatchWorkflowRequest
+	16, // 17: leader.LeaderService.PreviewPlan:input_type -> leader.PreviewPlanRequest
+	18, // 18: leader.LeaderService.GetWorkflowUsage:input_type -> leader.GetWorkflowUsageRequest
+	21, // 19: leader.LeaderService.GetUsage:input_type -> leader.GetUsageRequest
+	23, // 20: leader.LeaderService.ListArtifacts:input_type -> leader.ListArtifactsRequest
+	26, // 21: leader.LeaderService.GetArtifact:input_type -> leader.GetArtifactRequest
+	1,  // 22: leader.LeaderService.StartWorkflow:output_type -> leader.StartWorkflowResponse
+	3,  // 23: leader.LeaderService.GetWorkflowStatus:output_type -> leader.GetWorkflowStatusResponse
+	5,  // 24: leader.LeaderService.CancelWorkflow:output_type -> leader.CancelWorkflowResponse
+	8,  // 25: leader.LeaderService.GetProcessedTasks:output_type -> leader.GetProcessedTasksResponse
+	10, // 26: leader.LeaderService.SubmitReview:output_type -> leader.SubmitReviewResponse
+	13, // 27: leader.LeaderService.ListWorkflows:output_type -> leader.ListWorkflowsResponse
+	15, // 28: leader.LeaderService.WatchWorkflow:output_type -> leader.WorkflowEvent
+	17, // 29: leader.LeaderService.PreviewPlan:output_type -> leader.PreviewPlanResponse
+	20, // 30: leader.LeaderService.GetWorkflowUsage:output_type -> leader.LLMUsageTotals
+	22, // 31: leader.LeaderService.GetUsage:output_type -> leader.GetUsageResponse
+	25, // 32: leader.LeaderService.ListArtifacts:output_type -> leader.ListArtifactsResponse
+	27, // 33: leader.LeaderService.GetArtifact:output_type -> leader.GetArtifactResponse
+	22, // [22:34] is the sub-list for method output_type
+	10, // [10:22] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_proto_leader_proto_init() }
@@ -625,13 +2161,14 @@ func file_proto_leader_proto_init() {
 	if File_proto_leader_proto != nil {
 		return
 	}
+	file_proto_workflows_proto_init()
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_leader_proto_rawDesc), len(file_proto_leader_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   9,
+			NumMessages:   29,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
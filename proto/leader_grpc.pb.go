@@ -23,6 +23,14 @@ const (
 	LeaderService_GetWorkflowStatus_FullMethodName = "/leader.LeaderService/GetWorkflowStatus"
 	LeaderService_CancelWorkflow_FullMethodName    = "/leader.LeaderService/CancelWorkflow"
 	LeaderService_GetProcessedTasks_FullMethodName = "/leader.LeaderService/GetProcessedTasks"
+	LeaderService_SubmitReview_FullMethodName      = "/leader.LeaderService/SubmitReview"
+	LeaderService_ListWorkflows_FullMethodName     = "/leader.LeaderService/ListWorkflows"
+	LeaderService_WatchWorkflow_FullMethodName     = "/leader.LeaderService/WatchWorkflow"
+	LeaderService_PreviewPlan_FullMethodName       = "/leader.LeaderService/PreviewPlan"
+	LeaderService_GetWorkflowUsage_FullMethodName  = "/leader.LeaderService/GetWorkflowUsage"
+	LeaderService_GetUsage_FullMethodName          = "/leader.LeaderService/GetUsage"
+	LeaderService_ListArtifacts_FullMethodName     = "/leader.LeaderService/ListArtifacts"
+	LeaderService_GetArtifact_FullMethodName       = "/leader.LeaderService/GetArtifact"
 )
 
 // LeaderServiceClient is the client API for LeaderService service.
@@ -39,6 +47,23 @@ type LeaderServiceClient interface {
 	CancelWorkflow(ctx context.Context, in *CancelWorkflowRequest, opts ...grpc.CallOption) (*CancelWorkflowResponse, error)
 	// Get list of processed tasks
 	GetProcessedTasks(ctx context.Context, in *GetProcessedTasksRequest, opts ...grpc.CallOption) (*GetProcessedTasksResponse, error)
+	// Approve or reject a review step of a running workflow
+	SubmitReview(ctx context.Context, in *SubmitReviewRequest, opts ...grpc.CallOption) (*SubmitReviewResponse, error)
+	// List workflows, filtered by search attributes
+	ListWorkflows(ctx context.Context, in *ListWorkflowsRequest, opts ...grpc.CallOption) (*ListWorkflowsResponse, error)
+	// Stream a workflow's progress events until it closes
+	WatchWorkflow(ctx context.Context, in *WatchWorkflowRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WorkflowEvent], error)
+	// Plan a ticket, and optionally generate its diff, without touching the
+	// repository or Jira
+	PreviewPlan(ctx context.Context, in *PreviewPlanRequest, opts ...grpc.CallOption) (*PreviewPlanResponse, error)
+	// Get the LLM usage and cost of a workflow run
+	GetWorkflowUsage(ctx context.Context, in *GetWorkflowUsageRequest, opts ...grpc.CallOption) (*LLMUsageTotals, error)
+	// Get today's LLM spend and the configured budgets
+	GetUsage(ctx context.Context, in *GetUsageRequest, opts ...grpc.CallOption) (*GetUsageResponse, error)
+	// List the artifacts archived for a workflow run
+	ListArtifacts(ctx context.Context, in *ListArtifactsRequest, opts ...grpc.CallOption) (*ListArtifactsResponse, error)
+	// Get an archived artifact of a workflow run
+	GetArtifact(ctx context.Context, in *GetArtifactRequest, opts ...grpc.CallOption) (*GetArtifactResponse, error)
 }
 
 type leaderServiceClient struct {
@@ -89,6 +114,95 @@ func (c *leaderServiceClient) GetProcessedTasks(ctx context.Context, in *GetProc
 	return out, nil
 }
 
+func (c *leaderServiceClient) SubmitReview(ctx context.Context, in *SubmitReviewRequest, opts ...grpc.CallOption) (*SubmitReviewResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitReviewResponse)
+	err := c.cc.Invoke(ctx, LeaderService_SubmitReview_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *leaderServiceClient) ListWorkflows(ctx context.Context, in *ListWorkflowsRequest, opts ...grpc.CallOption) (*ListWorkflowsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWorkflowsResponse)
+	err := c.cc.Invoke(ctx, LeaderService_ListWorkflows_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *leaderServiceClient) WatchWorkflow(ctx context.Context, in *WatchWorkflowRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WorkflowEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LeaderService_ServiceDesc.Streams[0], LeaderService_WatchWorkflow_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchWorkflowRequest, WorkflowEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LeaderService_WatchWorkflowClient = grpc.ServerStreamingClient[WorkflowEvent]
+
+func (c *leaderServiceClient) PreviewPlan(ctx context.Context, in *PreviewPlanRequest, opts ...grpc.CallOption) (*PreviewPlanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PreviewPlanResponse)
+	err := c.cc.Invoke(ctx, LeaderService_PreviewPlan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *leaderServiceClient) GetWorkflowUsage(ctx context.Context, in *GetWorkflowUsageRequest, opts ...grpc.CallOption) (*LLMUsageTotals, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LLMUsageTotals)
+	err := c.cc.Invoke(ctx, LeaderService_GetWorkflowUsage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *leaderServiceClient) GetUsage(ctx context.Context, in *GetUsageRequest, opts ...grpc.CallOption) (*GetUsageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUsageResponse)
+	err := c.cc.Invoke(ctx, LeaderService_GetUsage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *leaderServiceClient) ListArtifacts(ctx context.Context, in *ListArtifactsRequest, opts ...grpc.CallOption) (*ListArtifactsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListArtifactsResponse)
+	err := c.cc.Invoke(ctx, LeaderService_ListArtifacts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *leaderServiceClient) GetArtifact(ctx context.Context, in *GetArtifactRequest, opts ...grpc.CallOption) (*GetArtifactResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetArtifactResponse)
+	err := c.cc.Invoke(ctx, LeaderService_GetArtifact_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LeaderServiceServer is the server API for LeaderService service.
 // All implementations must embed UnimplementedLeaderServiceServer
 // for forward compatibility.
@@ -103,6 +217,23 @@ type LeaderServiceServer interface {
 	CancelWorkflow(context.Context, *CancelWorkflowRequest) (*CancelWorkflowResponse, error)
 	// Get list of processed tasks
 	GetProcessedTasks(context.Context, *GetProcessedTasksRequest) (*GetProcessedTasksResponse, error)
+	// Approve or reject a review step of a running workflow
+	SubmitReview(context.Context, *SubmitReviewRequest) (*SubmitReviewResponse, error)
+	// List workflows, filtered by search attributes
+	ListWorkflows(context.Context, *ListWorkflowsRequest) (*ListWorkflowsResponse, error)
+	// Stream a workflow's progress events until it closes
+	WatchWorkflow(*WatchWorkflowRequest, grpc.ServerStreamingServer[WorkflowEvent]) error
+	// Plan a ticket, and optionally generate its diff, without touching the
+	// repository or Jira
+	PreviewPlan(context.Context, *PreviewPlanRequest) (*PreviewPlanResponse, error)
+	// Get the LLM usage and cost of a workflow run
+	GetWorkflowUsage(context.Context, *GetWorkflowUsageRequest) (*LLMUsageTotals, error)
+	// Get today's LLM spend and the configured budgets
+	GetUsage(context.Context, *GetUsageRequest) (*GetUsageResponse, error)
+	// List the artifacts archived for a workflow run
+	ListArtifacts(context.Context, *ListArtifactsRequest) (*ListArtifactsResponse, error)
+	// Get an archived artifact of a workflow run
+	GetArtifact(context.Context, *GetArtifactRequest) (*GetArtifactResponse, error)
 	mustEmbedUnimplementedLeaderServiceServer()
 }
 
@@ -125,6 +256,30 @@ func (UnimplementedLeaderServiceServer) CancelWorkflow(context.Context, *CancelW
 func (UnimplementedLeaderServiceServer) GetProcessedTasks(context.Context, *GetProcessedTasksRequest) (*GetProcessedTasksResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetProcessedTasks not implemented")
 }
+func (UnimplementedLeaderServiceServer) SubmitReview(context.Context, *SubmitReviewRequest) (*SubmitReviewResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SubmitReview not implemented")
+}
+func (UnimplementedLeaderServiceServer) ListWorkflows(context.Context, *ListWorkflowsRequest) (*ListWorkflowsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListWorkflows not implemented")
+}
+func (UnimplementedLeaderServiceServer) WatchWorkflow(*WatchWorkflowRequest, grpc.ServerStreamingServer[WorkflowEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchWorkflow not implemented")
+}
+func (UnimplementedLeaderServiceServer) PreviewPlan(context.Context, *PreviewPlanRequest) (*PreviewPlanResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method PreviewPlan not implemented")
+}
+func (UnimplementedLeaderServiceServer) GetWorkflowUsage(context.Context, *GetWorkflowUsageRequest) (*LLMUsageTotals, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetWorkflowUsage not implemented")
+}
+func (UnimplementedLeaderServiceServer) GetUsage(context.Context, *GetUsageRequest) (*GetUsageResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetUsage not implemented")
+}
+func (UnimplementedLeaderServiceServer) ListArtifacts(context.Context, *ListArtifactsRequest) (*ListArtifactsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListArtifacts not implemented")
+}
+func (UnimplementedLeaderServiceServer) GetArtifact(context.Context, *GetArtifactRequest) (*GetArtifactResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetArtifact not implemented")
+}
 func (UnimplementedLeaderServiceServer) mustEmbedUnimplementedLeaderServiceServer() {}
 func (UnimplementedLeaderServiceServer) testEmbeddedByValue()                       {}
 
@@ -218,6 +373,143 @@ func _LeaderService_GetProcessedTasks_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LeaderService_SubmitReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeaderServiceServer).SubmitReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LeaderService_SubmitReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeaderServiceServer).SubmitReview(ctx, req.(*SubmitReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LeaderService_ListWorkflows_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWorkflowsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeaderServiceServer).ListWorkflows(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LeaderService_ListWorkflows_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeaderServiceServer).ListWorkflows(ctx, req.(*ListWorkflowsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LeaderService_WatchWorkflow_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchWorkflowRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LeaderServiceServer).WatchWorkflow(m, &grpc.GenericServerStream[WatchWorkflowRequest, WorkflowEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LeaderService_WatchWorkflowServer = grpc.ServerStreamingServer[WorkflowEvent]
+
+func _LeaderService_PreviewPlan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PreviewPlanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeaderServiceServer).PreviewPlan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LeaderService_PreviewPlan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeaderServiceServer).PreviewPlan(ctx, req.(*PreviewPlanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LeaderService_GetWorkflowUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWorkflowUsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeaderServiceServer).GetWorkflowUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LeaderService_GetWorkflowUsage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeaderServiceServer).GetWorkflowUsage(ctx, req.(*GetWorkflowUsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LeaderService_GetUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeaderServiceServer).GetUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LeaderService_GetUsage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeaderServiceServer).GetUsage(ctx, req.(*GetUsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LeaderService_ListArtifacts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListArtifactsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeaderServiceServer).ListArtifacts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LeaderService_ListArtifacts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeaderServiceServer).ListArtifacts(ctx, req.(*ListArtifactsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LeaderService_GetArtifact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetArtifactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeaderServiceServer).GetArtifact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LeaderService_GetArtifact_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeaderServiceServer).GetArtifact(ctx, req.(*GetArtifactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // LeaderService_ServiceDesc is the grpc.ServiceDesc for LeaderService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -241,7 +533,41 @@ var LeaderService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetProcessedTasks",
 			Handler:    _LeaderService_GetProcessedTasks_Handler,
 		},
+		{
+			MethodName: "SubmitReview",
+			Handler:    _LeaderService_SubmitReview_Handler,
+		},
+		{
+			MethodName: "ListWorkflows",
+			Handler:    _LeaderService_ListWorkflows_Handler,
+		},
+		{
+			MethodName: "PreviewPlan",
+			Handler:    _LeaderService_PreviewPlan_Handler,
+		},
+		{
+			MethodName: "GetWorkflowUsage",
+			Handler:    _LeaderService_GetWorkflowUsage_Handler,
+		},
+		{
+			MethodName: "GetUsage",
+			Handler:    _LeaderService_GetUsage_Handler,
+		},
+		{
+			MethodName: "ListArtifacts",
+			Handler:    _LeaderService_ListArtifacts_Handler,
+		},
+		{
+			MethodName: "GetArtifact",
+			Handler:    _LeaderService_GetArtifact_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchWorkflow",
+			Handler:       _LeaderService_WatchWorkflow_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/leader.proto",
 }
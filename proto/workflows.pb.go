@@ -213,6 +213,7 @@ type PlanStep struct {
 	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
 	ActivityType  string                 `protobuf:"bytes,3,opt,name=activity_type,json=activityType,proto3" json:"activity_type,omitempty"` // codegen, testing, deployment, review
 	Parameters    map[string]string      `protobuf:"bytes,4,rep,name=parameters,proto3" json:"parameters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	DependsOn     []int32                `protobuf:"varint,5,rep,packed,name=depends_on,json=dependsOn,proto3" json:"depends_on,omitempty"` // orders of steps that must complete first
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -275,6 +276,13 @@ func (x *PlanStep) GetParameters() map[string]string {
 	return nil
 }
 
+func (x *PlanStep) GetDependsOn() []int32 {
+	if x != nil {
+		return x.DependsOn
+	}
+	return nil
+}
+
 // GitHub repository information
 type RepositoryInfo struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -1000,14 +1008,16 @@ const file_proto_workflows_proto_rawDesc = "" +
 	"\x05steps\x18\x02 \x03(\v2\x13.workflows.PlanStepR\x05steps\x12&\n" +
 	"\x0ffiles_to_modify\x18\x03 \x03(\tR\rfilesToModify\x12&\n" +
 	"\x0ffiles_to_create\x18\x04 \x03(\tR\rfilesToCreate\x121\n" +
-	"\x14estimated_complexity\x18\x05 \x01(\tR\x13estimatedComplexity\"\xeb\x01\n" +
+	"\x14estimated_complexity\x18\x05 \x01(\tR\x13estimatedComplexity\"\x8a\x02\n" +
 	"\bPlanStep\x12\x14\n" +
 	"\x05order\x18\x01 \x01(\x05R\x05order\x12 \n" +
 	"\vdescription\x18\x02 \x01(\tR\vdescription\x12#\n" +
 	"\ractivity_type\x18\x03 \x01(\tR\factivityType\x12C\n" +
 	"\n" +
 	"parameters\x18\x04 \x03(\v2#.workflows.PlanStep.ParametersEntryR\n" +
-	"parameters\x1a=\n" +
+	"parameters\x12\x1d\n" +
+	"\n" +
+	"depends_on\x18\x05 \x03(\x05R\tdependsOn\x1a=\n" +
 	"\x0fParametersEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x9f\x01\n" +